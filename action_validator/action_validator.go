@@ -0,0 +1,133 @@
+// Package action_validator checks the responses of a bot against the game
+// state they answer, before they are sent to the server.
+//
+// The server ignores actions it cannot perform, or answers them with an
+// invalidPacketUsageError, only after the tick has passed. Validating them
+// on the client side reports the mistake while the bot can still learn from it.
+package action_validator
+
+import (
+	"errors"
+	"fmt"
+
+	"hackarena2-0-mono-tanks-go/packet/packets/bot_response"
+	"hackarena2-0-mono-tanks-go/packet/packets/bot_response/ability"
+	"hackarena2-0-mono-tanks-go/packet/packets/bot_response/movement"
+	"hackarena2-0-mono-tanks-go/packet/packets/bot_response/rotation"
+	"hackarena2-0-mono-tanks-go/packet/packets/game_state"
+)
+
+// Mode selects what happens to an invalid action.
+type Mode string
+
+const (
+	// Off disables the validation.
+	Off Mode = "off"
+	// Warn logs invalid actions and sends them anyway.
+	Warn Mode = "warn"
+	// Correct logs invalid actions and sends a pass instead.
+	Correct Mode = "correct"
+	// Reject logs invalid actions and sends nothing.
+	Reject Mode = "reject"
+)
+
+// Modes lists the valid modes.
+var Modes = []Mode{Off, Warn, Correct, Reject}
+
+// ParseMode returns the mode with the given name.
+func ParseMode(name string) (Mode, error) {
+	for _, mode := range Modes {
+		if string(mode) == name {
+			return mode, nil
+		}
+	}
+	return "", fmt.Errorf("unknown validation mode %q, expected one of %v", name, Modes)
+}
+
+var (
+	// ErrUnknownResponseType is returned for a response that is not a movement, rotation, ability use or pass.
+	ErrUnknownResponseType = errors.New("unknown response type")
+
+	// ErrUnknownDirection is returned for a movement that is neither forward nor backward.
+	ErrUnknownDirection = errors.New("unknown movement direction")
+
+	// ErrUnknownRotation is returned for a rotation that is neither left nor right.
+	ErrUnknownRotation = errors.New("unknown rotation")
+
+	// ErrEmptyRotation is returned for a rotation of neither the tank nor the turret.
+	ErrEmptyRotation = errors.New("rotation of neither the tank nor the turret")
+
+	// ErrUnknownAbility is returned for an ability type the server does not know.
+	ErrUnknownAbility = errors.New("unknown ability")
+
+	// ErrMissingItem is returned for an ability that needs a secondary item the tank is not carrying.
+	ErrMissingItem = errors.New("secondary item not carried")
+
+	// ErrNoBullets is returned for firing a bullet with an empty turret.
+	ErrNoBullets = errors.New("no bullets left")
+
+	// ErrDead is returned for any action other than a pass while the tank is destroyed.
+	ErrDead = errors.New("tank is destroyed")
+)
+
+// abilityItems maps the abilities to the secondary item they use up.
+var abilityItems = map[string]string{
+	ability.FireDoubleBullet: "doubleBullet",
+	ability.UseLaser:         "laser",
+	ability.UseRadar:         "radar",
+	ability.DropMine:         "mine",
+}
+
+// Validate checks that the player with the given ID can perform the response
+// in the game state. It returns nil for a valid response, or an error wrapping
+// one of the Err values of this package.
+func Validate(response bot_response.BotResponse, gameState *game_state.GameState, playerID string) error {
+	switch response.Type {
+	case bot_response.Pass:
+		return nil
+	case bot_response.Movement, bot_response.Rotation, bot_response.AbilityUse:
+	default:
+		return fmt.Errorf("%w %q", ErrUnknownResponseType, response.Type)
+	}
+
+	// The own tank is always visible, unless it is destroyed
+	tank := gameState.Tank(playerID)
+	if tank == nil {
+		return ErrDead
+	}
+
+	switch response.Type {
+	case bot_response.Movement:
+		if response.Direction != movement.Forward && response.Direction != movement.Backward {
+			return fmt.Errorf("%w %q", ErrUnknownDirection, response.Direction)
+		}
+
+	case bot_response.Rotation:
+		if response.TankRotation == "" && response.TurretRotation == "" {
+			return ErrEmptyRotation
+		}
+		for _, value := range []string{response.TankRotation, response.TurretRotation} {
+			if value != "" && value != rotation.Left && value != rotation.Right {
+				return fmt.Errorf("%w %q", ErrUnknownRotation, value)
+			}
+		}
+
+	case bot_response.AbilityUse:
+		if response.AbilityType == ability.FireBullet {
+			if tank.Turret.BulletCount != nil && *tank.Turret.BulletCount == 0 {
+				return ErrNoBullets
+			}
+			return nil
+		}
+
+		item, ok := abilityItems[response.AbilityType]
+		if !ok {
+			return fmt.Errorf("%w %q", ErrUnknownAbility, response.AbilityType)
+		}
+		if tank.SecondaryItem == nil || *tank.SecondaryItem != item {
+			return fmt.Errorf("%w: %s needs %s", ErrMissingItem, response.AbilityType, item)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package action_validator_test
+
+import (
+	"errors"
+	"hackarena2-0-mono-tanks-go/action_validator"
+	"hackarena2-0-mono-tanks-go/packet/packets/bot_response"
+	"hackarena2-0-mono-tanks-go/packet/packets/bot_response/ability"
+	"hackarena2-0-mono-tanks-go/packet/packets/bot_response/movement"
+	"hackarena2-0-mono-tanks-go/packet/packets/bot_response/rotation"
+	"hackarena2-0-mono-tanks-go/packet/packets/game_state"
+	"hackarena2-0-mono-tanks-go/scenario"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		response      bot_response.BotResponse
+		secondaryItem string
+		bulletCount   int
+		dead          bool
+		expected      error
+	}{
+		{name: "Pass", response: *bot_response.NewPass()},
+		{name: "Pass While Dead", response: *bot_response.NewPass(), dead: true},
+		{name: "Movement", response: *bot_response.NewMovement(movement.Forward)},
+		{name: "Unknown Direction", response: *bot_response.NewMovement("sideways"), expected: action_validator.ErrUnknownDirection},
+		{name: "Tank Rotation", response: *bot_response.NewRotation(rotation.Left, "")},
+		{name: "Turret Rotation", response: *bot_response.NewRotation("", rotation.Right)},
+		{name: "Empty Rotation", response: *bot_response.NewRotation("", ""), expected: action_validator.ErrEmptyRotation},
+		{name: "Unknown Rotation", response: *bot_response.NewRotation(rotation.Left, "around"), expected: action_validator.ErrUnknownRotation},
+		{name: "Fire Bullet", response: *bot_response.NewAbilityUse(ability.FireBullet)},
+		{name: "Fire Bullet Without Bullets", response: *bot_response.NewAbilityUse(ability.FireBullet), bulletCount: -1, expected: action_validator.ErrNoBullets},
+		{name: "Fire Double Bullet", response: *bot_response.NewAbilityUse(ability.FireDoubleBullet), secondaryItem: "doubleBullet"},
+		{name: "Fire Double Bullet Without Item", response: *bot_response.NewAbilityUse(ability.FireDoubleBullet), expected: action_validator.ErrMissingItem},
+		{name: "Use Laser", response: *bot_response.NewAbilityUse(ability.UseLaser), secondaryItem: "laser"},
+		{name: "Use Laser With Other Item", response: *bot_response.NewAbilityUse(ability.UseLaser), secondaryItem: "mine", expected: action_validator.ErrMissingItem},
+		{name: "Use Radar", response: *bot_response.NewAbilityUse(ability.UseRadar), secondaryItem: "radar"},
+		{name: "Drop Mine", response: *bot_response.NewAbilityUse(ability.DropMine), secondaryItem: "mine"},
+		{name: "Drop Mine Without Item", response: *bot_response.NewAbilityUse(ability.DropMine), expected: action_validator.ErrMissingItem},
+		{name: "Unknown Ability", response: *bot_response.NewAbilityUse("teleport"), expected: action_validator.ErrUnknownAbility},
+		{name: "Acting While Dead", response: *bot_response.NewMovement(movement.Forward), dead: true, expected: action_validator.ErrDead},
+		{name: "Unknown Response Type", response: bot_response.BotResponse{}, expected: action_validator.ErrUnknownResponseType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := scenario.MustParse(`
+				. . .
+				. ^ .
+				. . .
+			`)
+			tank := s.MyTank()
+			if tt.secondaryItem != "" {
+				tank.SecondaryItem = &tt.secondaryItem
+			}
+			if tt.bulletCount < 0 {
+				*tank.Turret.BulletCount = 0
+			}
+			if tt.dead {
+				s.GameState.Tanks = []game_state.Tank{}
+			}
+
+			err := action_validator.Validate(tt.response, s.GameState, scenario.MyID)
+			if tt.expected == nil {
+				if err != nil {
+					t.Errorf("Expected a valid action, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	for _, mode := range action_validator.Modes {
+		parsed, err := action_validator.ParseMode(string(mode))
+		if err != nil || parsed != mode {
+			t.Errorf("Expected %s to parse, got %q, %v", mode, parsed, err)
+		}
+	}
+	if _, err := action_validator.ParseMode("strict"); err == nil {
+		t.Errorf("Expected an error for an unknown mode")
+	}
+}
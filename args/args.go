@@ -2,15 +2,35 @@ package args
 
 import (
 	"fmt"
+	"slices"
+	"strings"
+
+	"hackarena2-0-mono-tanks-go/action_validator"
+	"hackarena2-0-mono-tanks-go/opponent_model"
+	"hackarena2-0-mono-tanks-go/strategies"
 
 	"github.com/urfave/cli/v2"
 )
 
+// NicknameSuffixAuto retries with a numbered suffix when the nickname is taken.
+const NicknameSuffixAuto = "auto"
+
 type Args struct {
-	Nickname string
-	Host     string
-	Port     uint
-	Code     string
+	Nickname       string
+	NicknameSuffix string
+	Host           string
+	Port           uint
+	Code           string
+	Events         bool
+	Validate       string
+	Strategy       string
+	Opponents      string
+
+	// Spectate is set when the spectate command is used, to watch the game instead of playing it.
+	Spectate bool
+
+	// Record is the file the spectator records the game to, empty for none.
+	Record string
 }
 
 func NewCLIApp() *cli.App {
@@ -31,7 +51,11 @@ func NewCLIApp() *cli.App {
 				Aliases:     []string{"n"},
 				Usage:       "Nickname of the bot that will be displayed in the game",
 				Destination: &args.Nickname,
-				Required:    true,
+			},
+			&cli.StringFlag{
+				Name:        "nickname-suffix",
+				Usage:       "Set to \"auto\" to reconnect as NICKNAME-2, NICKNAME-3 and so on when the nickname is already taken",
+				Destination: &args.NicknameSuffix,
 			},
 			&cli.StringFlag{
 				Name:        "host",
@@ -53,13 +77,83 @@ func NewCLIApp() *cli.App {
 				Value:       "",
 				Destination: &args.Code,
 			},
+			&cli.BoolFlag{
+				Name:        "events",
+				Usage:       "Deliver events extracted from consecutive game states to the bot's OnGameEvents method",
+				Destination: &args.Events,
+			},
+			&cli.StringFlag{
+				Name:        "validate",
+				Usage:       "What to do with actions that are invalid in the current game state: off, warn, correct (send a pass instead) or reject (send nothing)",
+				Value:       string(action_validator.Off),
+				Destination: &args.Validate,
+			},
+			&cli.StringFlag{
+				Name:        "opponents",
+				Usage:       "The file the profiles of the other bots are kept in between matches, empty to keep them in memory only",
+				Value:       opponent_model.DefaultPath,
+				Destination: &args.Opponents,
+			},
+			&cli.StringFlag{
+				Name:        "strategy",
+				Usage:       "The strategy the bot plays with: " + strings.Join(strategies.Names, " or "),
+				Value:       strategies.Random,
+				Destination: &args.Strategy,
+			},
+		},
+		Commands: []*cli.Command{
+			{
+				Name:  "spectate",
+				Usage: "Join the game as a spectator and watch the game states of all players",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:        "record",
+						Usage:       "Record the game to a file, one packet per line, to replay it later",
+						Destination: &args.Record,
+					},
+				},
+				Action: func(c *cli.Context) error {
+					// Validate the port number
+					if args.Port < 1 || args.Port > 65535 {
+						return fmt.Errorf("port must be between 1 and 65535")
+					}
+
+					args.Spectate = true
+
+					// Set the metadata for the application
+					c.App.Metadata = map[string]interface{}{
+						"args": args,
+					}
+					return nil
+				},
+			},
 		},
 		Action: func(c *cli.Context) error {
+			// The nickname is only required to play, so it can't be a required flag of the whole app
+			if args.Nickname == "" {
+				return fmt.Errorf("required flag \"nickname\" not set")
+			}
+
 			// Validate the port number
 			if args.Port < 1 || args.Port > 65535 {
 				return fmt.Errorf("port must be between 1 and 65535")
 			}
 
+			// Validate the nickname suffix mode
+			if args.NicknameSuffix != "" && args.NicknameSuffix != NicknameSuffixAuto {
+				return fmt.Errorf("nickname suffix must be %q or empty", NicknameSuffixAuto)
+			}
+
+			// Validate the action validation mode
+			if _, err := action_validator.ParseMode(args.Validate); err != nil {
+				return err
+			}
+
+			// Validate the strategy
+			if !slices.Contains(strategies.Names, args.Strategy) {
+				return fmt.Errorf("strategy must be one of %s", strings.Join(strategies.Names, ", "))
+			}
+
 			// Set the metadata for the application
 			c.App.Metadata = map[string]interface{}{
 				"args": args,
@@ -0,0 +1,90 @@
+package args
+
+import "testing"
+
+func TestSpectateDoesNotRequireNickname(t *testing.T) {
+	app := NewCLIApp()
+	if err := app.Run([]string{"bot", "--port", "5001", "spectate"}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	args := app.Metadata["args"].(*Args)
+	if !args.Spectate || args.Port != 5001 || args.Host != "localhost" || args.Record != "" {
+		t.Errorf("Expected spectator args for localhost:5001, got %+v", args)
+	}
+}
+
+func TestSpectateKeepsConnectionFlags(t *testing.T) {
+	app := NewCLIApp()
+	if err := app.Run([]string{"bot", "--host", "arena", "--port", "5001", "--code", "secret", "spectate", "--record", "game.jsonl"}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	args := app.Metadata["args"].(*Args)
+	if args.Host != "arena" || args.Port != 5001 || args.Code != "secret" || args.Record != "game.jsonl" {
+		t.Errorf("Expected spectator args for arena:5001 recording to game.jsonl, got %+v", args)
+	}
+}
+
+func TestPlayingRequiresNickname(t *testing.T) {
+	app := NewCLIApp()
+	if err := app.Run([]string{"bot", "--port", "5001"}); err == nil {
+		t.Fatalf("Expected an error without a nickname")
+	}
+
+	app = NewCLIApp()
+	if err := app.Run([]string{"bot", "--nickname", "GO1"}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if args := app.Metadata["args"].(*Args); args.Spectate || args.Nickname != "GO1" {
+		t.Errorf("Expected player args for GO1, got %+v", args)
+	}
+}
+
+func TestNicknameSuffix(t *testing.T) {
+	app := NewCLIApp()
+	if err := app.Run([]string{"bot", "--nickname", "GO1", "--nickname-suffix", "auto"}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if args := app.Metadata["args"].(*Args); args.NicknameSuffix != NicknameSuffixAuto {
+		t.Errorf("Expected nickname suffix %q, got %q", NicknameSuffixAuto, args.NicknameSuffix)
+	}
+
+	app = NewCLIApp()
+	if err := app.Run([]string{"bot", "--nickname", "GO1", "--nickname-suffix", "random"}); err == nil {
+		t.Errorf("Expected an error for an unknown nickname suffix mode")
+	}
+}
+
+func TestValidationIsOffByDefault(t *testing.T) {
+	app := NewCLIApp()
+	if err := app.Run([]string{"bot", "--nickname", "GO1"}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if args := app.Metadata["args"].(*Args); args.Validate != "off" {
+		t.Errorf("Expected validation to be off by default, got %q", args.Validate)
+	}
+}
+
+func TestStrategy(t *testing.T) {
+	app := NewCLIApp()
+	if err := app.Run([]string{"bot", "--nickname", "GO1"}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if args := app.Metadata["args"].(*Args); args.Strategy != "random" {
+		t.Errorf("Expected the random strategy by default, got %q", args.Strategy)
+	}
+
+	app = NewCLIApp()
+	if err := app.Run([]string{"bot", "--nickname", "GO1", "--strategy", "mcts"}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if args := app.Metadata["args"].(*Args); args.Strategy != "mcts" {
+		t.Errorf("Expected the mcts strategy, got %q", args.Strategy)
+	}
+
+	app = NewCLIApp()
+	if err := app.Run([]string{"bot", "--nickname", "GO1", "--strategy", "minimax"}); err == nil {
+		t.Errorf("Expected an error for an unknown strategy")
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"math/rand"
 
+	"hackarena2-0-mono-tanks-go/game_events"
+	"hackarena2-0-mono-tanks-go/mcts"
+	"hackarena2-0-mono-tanks-go/opponent_model"
 	"hackarena2-0-mono-tanks-go/packet/packets/bot_response"
 	"hackarena2-0-mono-tanks-go/packet/packets/bot_response/ability"
 	"hackarena2-0-mono-tanks-go/packet/packets/bot_response/movement"
@@ -12,11 +15,35 @@ import (
 	"hackarena2-0-mono-tanks-go/packet/packets/game_state"
 	"hackarena2-0-mono-tanks-go/packet/packets/lobby_data"
 	"hackarena2-0-mono-tanks-go/packet/warning"
+	"hackarena2-0-mono-tanks-go/strategies"
+	"hackarena2-0-mono-tanks-go/timing"
 )
 
 // Bot represents an AI player in the game.
 type Bot struct {
 	MyID string
+
+	// Search chooses the moves when the bot plays with strategies.MCTS, and is nil otherwise.
+	Search *mcts.Strategy
+
+	// Opponents learns how the other bots play, across the matches of a tournament.
+	// Call Opponents.Predict in NextMove to guess what a visible enemy does next.
+	Opponents *opponent_model.Model
+
+	// OpponentsPath is where the opponent profiles are saved when a game ends,
+	// empty to keep them in memory only.
+	OpponentsPath string
+}
+
+// Options are chosen with the command line flags, and applied by Configure.
+type Options struct {
+	// OpponentsPath is the file the opponent profiles are loaded from and saved to,
+	// empty to keep them in memory only. Set with the --opponents flag.
+	OpponentsPath string
+
+	// Strategy is the strategy the bot plays with, one of strategies.Names.
+	// An empty strategy plays with strategies.Random. Set with the --strategy flag.
+	Strategy string
 }
 
 // OnJoiningLobby is called when the bot joins a lobby, creating a new instance of the bot.
@@ -30,7 +57,30 @@ type Bot struct {
 // - A new instance of the bot.
 func OnJoiningLobby(lobbyData *lobby_data.LobbyData) *Bot {
 	return &Bot{
-		MyID: lobbyData.PlayerID,
+		MyID:      lobbyData.PlayerID,
+		Opponents: opponent_model.NewModel(lobbyData.PlayerID),
+	}
+}
+
+// Configure applies the options chosen with the command line flags. It is called right after
+// OnJoiningLobby, before the first game state.
+//
+// Parameters:
+//   - options: The options of the bot.
+func (b *Bot) Configure(options Options) {
+	// Load what was learned about the other bots in earlier matches
+	b.OpponentsPath = options.OpponentsPath
+	if b.OpponentsPath != "" {
+		opponents, err := opponent_model.Load(b.OpponentsPath, b.MyID)
+		if err != nil {
+			fmt.Printf("[System] ⚠️ Opponent profiles not loaded, starting over -> %v\n", err)
+		} else {
+			b.Opponents = opponents
+		}
+	}
+
+	if options.Strategy == strategies.MCTS {
+		b.Search = mcts.New(b.MyID, mcts.DefaultConfig)
 	}
 }
 
@@ -52,6 +102,39 @@ func (b *Bot) OnLobbyDataChanged(lobbyData *lobby_data.LobbyData) {
 	// Implement the logic for handling lobby data changes
 }
 
+// OnGameEvents is called before NextMove with the events that happened since the previous game state,
+// such as tanks moving or being destroyed, bullets being fired, items being picked up or zones being captured.
+// This method is only called when the bot is started with the --events flag.
+//
+// Parameters:
+//   - events: The events extracted by comparing the current game state with the previous one.
+//     Use a type switch on the concrete event types from the game_events package to read the details.
+//
+// Default Behavior:
+// By default, this method performs no action. To react to game events,
+// override this method in your implementation.
+func (b *Bot) OnGameEvents(events []game_events.Event) {
+	// Implement the logic for handling game events
+}
+
+// OnTickTiming is called before NextMove with the time budget of the tick.
+// Keep the timing to check how much time is left while computing the next move.
+//
+// Parameters:
+//   - tickTiming: The estimated start of the tick, the interval between ticks and the deadline
+//     for the action, based on the broadcast interval, the arrival times of the game states and
+//     the ping of the bot. Call tickTiming.RemainingBudget() to get the time left to respond.
+//
+// Default Behavior:
+// By default, this method only passes the timing to the search of the mcts strategy.
+// To budget the time spent in NextMove, override this method in your implementation.
+func (b *Bot) OnTickTiming(tickTiming timing.Timing) {
+	// The search spends a share of the time left until the deadline
+	if b.Search != nil {
+		b.Search.OnTickTiming(tickTiming)
+	}
+}
+
 // NextMove is called after each game tick, when new game state data is received from the server.
 // This method is responsible for determining the bot's next move based on the current game state.
 //
@@ -63,6 +146,14 @@ func (b *Bot) OnLobbyDataChanged(lobbyData *lobby_data.LobbyData) {
 // - BotResponse: The action or decision made by the bot, which will be communicated back to the game server.
 func (b *Bot) NextMove(gameState *game_state.GameState) *bot_response.BotResponse {
 
+	// Learn from what the other bots did since the previous game state
+	b.Opponents.Observe(gameState)
+
+	// Let the search observe the game state and choose the move
+	if b.Search != nil {
+		return b.Search.NextMove(gameState)
+	}
+
 	// Print map as ascii
 	row_number := len(gameState.Visibility)
 	col_number := len(gameState.Visibility[0])
@@ -263,6 +354,24 @@ func (b *Bot) OnWarningReceived(warn warning.Warning, message *string) {
 	}
 }
 
+// OnActionFailed is called for each action that did not reach the server.
+// Only the action for the newest game state waits to be sent, so an action still waiting when the
+// next one is chosen is dropped in favour of it. Such actions are reported before the next NextMove. Actions refused
+// by the client-side validation in reject mode are reported right after the NextMove that returned them.
+//
+// Parameters:
+//   - gameStateID: The ID of the game state the action was a response to.
+//   - err: Why the action was not sent, outbound_queue.ErrSuperseded when an action for a newer
+//     game state replaced it, one of the action_validator errors when the action was rejected,
+//     or the error returned by the connection when writing it failed.
+//
+// Default Behavior:
+// By default, this method performs no action. To react to lost actions,
+// override this method in your implementation.
+func (b *Bot) OnActionFailed(gameStateID string, err error) {
+	// Implement the logic for handling failed actions
+}
+
 // OnGameEnded is called when the game has concluded, providing the final game results.
 // This method is triggered when the game ends, which is when a defined number of ticks in LobbyData has passed.
 //
@@ -290,4 +399,12 @@ func (b *Bot) OnGameEnded(gameEnd *game_end.GameEnd) {
 	for _, player := range gameEnd.Players {
 		fmt.Printf("Player: %s - Score: %d\n", player.Nickname, player.Score)
 	}
+
+	// Keep what was learned about the other bots for the next match
+	b.Opponents.EndMatch()
+	if b.OpponentsPath != "" {
+		if err := b.Opponents.Save(b.OpponentsPath); err != nil {
+			fmt.Printf("[System] ⚠️ Opponent profiles not saved -> %v\n", err)
+		}
+	}
 }
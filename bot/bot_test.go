@@ -0,0 +1,46 @@
+package bot
+
+import (
+	"hackarena2-0-mono-tanks-go/packet/packets/bot_response"
+	"hackarena2-0-mono-tanks-go/scenario"
+	"hackarena2-0-mono-tanks-go/strategies"
+	"testing"
+)
+
+func TestBotPassesWhenDead(t *testing.T) {
+	s := scenario.MustParse(`
+		. . .
+		. T .
+		. . .
+	`)
+
+	b := OnJoiningLobby(s.LobbyData())
+	response := b.NextMove(s.GameState)
+
+	if response.Type != bot_response.Pass {
+		t.Errorf("expected the bot to pass without a tank, got %v", response.Type)
+	}
+}
+
+func TestBotSearchesWithMCTSStrategy(t *testing.T) {
+	s := scenario.MustParse(`
+		# # # # #
+		# > . T #
+		# # # # #
+	`)
+
+	b := OnJoiningLobby(s.LobbyData())
+	b.Configure(Options{Strategy: strategies.MCTS})
+	if b.Search == nil {
+		t.Fatalf("expected the bot to search with the mcts strategy")
+	}
+	if response := b.NextMove(s.GameState); response == nil || b.Search.Stats().Iterations == 0 {
+		t.Errorf("expected the bot to search for its move, got %+v after %d iterations", response, b.Search.Stats().Iterations)
+	}
+
+	b = OnJoiningLobby(s.LobbyData())
+	b.Configure(Options{Strategy: strategies.Random})
+	if b.Search != nil {
+		t.Errorf("expected the random strategy not to search")
+	}
+}
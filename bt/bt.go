@@ -0,0 +1,457 @@
+// Package bt builds bot logic out of behaviour trees instead of one big switch.
+//
+// A Tree is ticked once per game state. Every node returns Success, Failure
+// or Running. Leaves check conditions on the Blackboard or choose a bot
+// response, composites decide which children run, and decorators change
+// what a single child returns. Composites start over from their first
+// child every tick, so the tree reacts at once when the game changes.
+// Decorators counting ticks use the tick of the game state, and keep their
+// counts in the tree ticking them, so a node can be shared between trees.
+//
+// Example:
+//
+//	tree := bt.NewTree(myID, bt.Selector("root",
+//		bt.Sequence("attack",
+//			bt.Condition("enemy in line", enemyInLine),
+//			bt.Cooldown(5, bt.Action("fire", fire)),
+//		),
+//		bt.Action("wander", wander),
+//	))
+//	response := tree.NextMove(gameState)
+package bt
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"hackarena2-0-mono-tanks-go/packet/packets/bot_response"
+	"hackarena2-0-mono-tanks-go/packet/packets/game_state"
+)
+
+// Status is the result of ticking a node.
+type Status int
+
+const (
+	Success Status = iota
+	Failure
+	Running
+)
+
+func (s Status) String() string {
+	switch s {
+	case Success:
+		return "success"
+	case Failure:
+		return "failure"
+	case Running:
+		return "running"
+	default:
+		return fmt.Sprintf("Status(%d)", int(s))
+	}
+}
+
+// Node is a node of a behaviour tree. Nodes with children tick them with
+// Blackboard.Run, so they show up in the trace.
+type Node interface {
+	// Name names the node in the trace.
+	Name() string
+
+	// Tick runs the node for the game state on the blackboard.
+	Tick(blackboard *Blackboard) Status
+}
+
+// Blackboard is shared by the nodes of a tree.
+type Blackboard struct {
+	// GameState is the game state of the current tick.
+	GameState *game_state.GameState
+
+	// PlayerID is the ID of our player.
+	PlayerID string
+
+	// Memory keeps values across ticks. Nodes may store anything in it.
+	Memory map[string]interface{}
+
+	// Response is the response chosen by the first Action to succeed this tick.
+	Response *bot_response.BotResponse
+
+	// nodes keeps the state of the decorators across ticks, by node.
+	nodes map[Node]interface{}
+
+	trace  []TraceEntry
+	depth  int
+	chosen bool
+}
+
+// Tick returns the tick of the current game state.
+func (b *Blackboard) Tick() uint64 {
+	return b.GameState.Tick
+}
+
+// MyTank returns our tank, or nil if it is destroyed.
+func (b *Blackboard) MyTank() *game_state.Tank {
+	return b.GameState.Tank(b.PlayerID)
+}
+
+// nodeState returns the state the node kept from earlier ticks, made with
+// create on its first tick.
+func (b *Blackboard) nodeState(node Node, create func() interface{}) interface{} {
+	if b.nodes == nil {
+		b.nodes = make(map[Node]interface{})
+	}
+	state, ok := b.nodes[node]
+	if !ok {
+		state = create()
+		b.nodes[node] = state
+	}
+	return state
+}
+
+// Run ticks the node and records it in the trace.
+func (b *Blackboard) Run(node Node) Status {
+	entry := len(b.trace)
+	b.trace = append(b.trace, TraceEntry{Name: node.Name(), Depth: b.depth})
+
+	chose := b.Response == nil
+	b.depth++
+	status := node.Tick(b)
+	b.depth--
+
+	b.trace[entry].Status = status
+	if chose && b.Response != nil && !b.chosen {
+		b.trace[entry].Chose = true
+		b.chosen = true
+	}
+	return status
+}
+
+// TraceEntry is a node ticked during a tick, in the order the nodes started.
+type TraceEntry struct {
+	Name   string
+	Depth  int
+	Status Status
+
+	// Chose is set on the leaf that chose the response.
+	Chose bool
+}
+
+// Trace lists the nodes ticked during a tick.
+type Trace []TraceEntry
+
+// String draws the trace as an indented tree.
+func (t Trace) String() string {
+	var builder strings.Builder
+	for _, entry := range t {
+		fmt.Fprintf(&builder, "%s%s: %s", strings.Repeat("  ", entry.Depth), entry.Name, entry.Status)
+		if entry.Chose {
+			builder.WriteString(" (chose the response)")
+		}
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+// Fired returns the names of the nodes on the way to the action that chose
+// the response, or nil if no action did.
+func (t Trace) Fired() []string {
+	for i := len(t) - 1; i >= 0; i-- {
+		if t[i].Chose {
+			return t.ancestors(i)
+		}
+	}
+	return nil
+}
+
+// ancestors returns the names from the root down to the entry.
+func (t Trace) ancestors(i int) []string {
+	names := []string{t[i].Name}
+	depth := t[i].Depth
+	for j := i - 1; j >= 0 && depth > 0; j-- {
+		if t[j].Depth == depth-1 {
+			names = append([]string{t[j].Name}, names...)
+			depth--
+		}
+	}
+	return names
+}
+
+// Tree is a behaviour tree with its memory.
+type Tree struct {
+	// Root is the root node.
+	Root Node
+
+	// Tracing records the nodes ticked, see LastTrace.
+	Tracing bool
+
+	// TraceOutput receives the trace of every tick when Tracing is on, nil for none.
+	TraceOutput io.Writer
+
+	playerID  string
+	memory    map[string]interface{}
+	nodes     map[Node]interface{}
+	lastTick  uint64
+	lastTrace Trace
+}
+
+func NewTree(playerID string, root Node) *Tree {
+	return &Tree{
+		Root:     root,
+		playerID: playerID,
+		memory:   make(map[string]interface{}),
+		nodes:    make(map[Node]interface{}),
+	}
+}
+
+// NextMove ticks the tree and returns the response chosen by its actions,
+// or a pass if none succeeded. The decorators start over when the tick goes
+// back, as it does when a new game starts.
+func (t *Tree) NextMove(gameState *game_state.GameState) *bot_response.BotResponse {
+	if gameState.Tick < t.lastTick {
+		t.nodes = make(map[Node]interface{})
+	}
+	t.lastTick = gameState.Tick
+
+	blackboard := &Blackboard{
+		GameState: gameState,
+		PlayerID:  t.playerID,
+		Memory:    t.memory,
+		nodes:     t.nodes,
+	}
+	blackboard.Run(t.Root)
+
+	t.lastTrace = nil
+	if t.Tracing {
+		t.lastTrace = blackboard.trace
+		if t.TraceOutput != nil {
+			fmt.Fprintf(t.TraceOutput, "[System] 🌳 Tick %d fired %s\n%s", gameState.Tick, strings.Join(t.lastTrace.Fired(), " > "), t.lastTrace)
+		}
+	}
+
+	if blackboard.Response == nil {
+		return bot_response.NewPass()
+	}
+	return blackboard.Response
+}
+
+// LastTrace returns the nodes ticked by the last NextMove, nil unless Tracing is on.
+func (t *Tree) LastTrace() Trace {
+	return t.lastTrace
+}
+
+// Memory returns the memory kept across ticks.
+func (t *Tree) Memory() map[string]interface{} {
+	return t.memory
+}
+
+type leaf struct {
+	name string
+	tick func(blackboard *Blackboard) Status
+}
+
+func (l *leaf) Name() string                       { return l.name }
+func (l *leaf) Tick(blackboard *Blackboard) Status { return l.tick(blackboard) }
+
+// Condition succeeds when check returns true, and fails otherwise.
+func Condition(name string, check func(blackboard *Blackboard) bool) Node {
+	return &leaf{name: name, tick: func(blackboard *Blackboard) Status {
+		if check(blackboard) {
+			return Success
+		}
+		return Failure
+	}}
+}
+
+// Action succeeds when choose returns a response, which becomes the response
+// of the tick unless an earlier action already chose one. It fails when
+// choose returns nil.
+func Action(name string, choose func(blackboard *Blackboard) *bot_response.BotResponse) Node {
+	return &leaf{name: name, tick: func(blackboard *Blackboard) Status {
+		response := choose(blackboard)
+		if response == nil {
+			return Failure
+		}
+		if blackboard.Response == nil {
+			blackboard.Response = response
+		}
+		return Success
+	}}
+}
+
+// Leaf runs tick as is, for leaves that need to return Running.
+func Leaf(name string, tick func(blackboard *Blackboard) Status) Node {
+	return &leaf{name: name, tick: tick}
+}
+
+type composite struct {
+	name     string
+	children []Node
+	tick     func(blackboard *Blackboard, children []Node) Status
+}
+
+func (c *composite) Name() string { return c.name }
+func (c *composite) Tick(blackboard *Blackboard) Status {
+	return c.tick(blackboard, c.children)
+}
+
+// Sequence ticks its children in order until one does not succeed, and
+// returns what that child returned. It succeeds when all children succeed.
+func Sequence(name string, children ...Node) Node {
+	return &composite{name: name, children: children, tick: func(blackboard *Blackboard, children []Node) Status {
+		for _, child := range children {
+			if status := blackboard.Run(child); status != Success {
+				return status
+			}
+		}
+		return Success
+	}}
+}
+
+// Selector ticks its children in order until one does not fail, and returns
+// what that child returned. It fails when all children fail.
+func Selector(name string, children ...Node) Node {
+	return &composite{name: name, children: children, tick: func(blackboard *Blackboard, children []Node) Status {
+		for _, child := range children {
+			if status := blackboard.Run(child); status != Failure {
+				return status
+			}
+		}
+		return Failure
+	}}
+}
+
+// Parallel ticks all of its children. It succeeds when at least required
+// children succeed, fails when so many fail that required can't be reached,
+// and is running otherwise.
+func Parallel(name string, required int, children ...Node) Node {
+	return &composite{name: name, children: children, tick: func(blackboard *Blackboard, children []Node) Status {
+		succeeded, failed := 0, 0
+		for _, child := range children {
+			switch blackboard.Run(child) {
+			case Success:
+				succeeded++
+			case Failure:
+				failed++
+			}
+		}
+
+		switch {
+		case succeeded >= required:
+			return Success
+		case len(children)-failed < required:
+			return Failure
+		default:
+			return Running
+		}
+	}}
+}
+
+type decorator struct {
+	name  string
+	child Node
+	tick  func(blackboard *Blackboard, child Node) Status
+}
+
+func (d *decorator) Name() string { return d.name }
+func (d *decorator) Tick(blackboard *Blackboard) Status {
+	return d.tick(blackboard, d.child)
+}
+
+// Inverter swaps the success and failure of its child.
+func Inverter(child Node) Node {
+	return &decorator{name: "not " + child.Name(), child: child, tick: func(blackboard *Blackboard, child Node) Status {
+		switch status := blackboard.Run(child); status {
+		case Success:
+			return Failure
+		case Failure:
+			return Success
+		default:
+			return status
+		}
+	}}
+}
+
+// cooldownState is the state of a Cooldown.
+type cooldownState struct {
+	succeeded bool
+	tick      uint64
+}
+
+// Cooldown fails without ticking its child for the given number of ticks
+// after the child succeeded.
+func Cooldown(ticks uint64, child Node) Node {
+	node := &decorator{name: fmt.Sprintf("cooldown %d", ticks), child: child}
+	node.tick = func(blackboard *Blackboard, child Node) Status {
+		state := blackboard.nodeState(node, func() interface{} { return &cooldownState{} }).(*cooldownState)
+		tick := blackboard.Tick()
+		if state.succeeded && tick >= state.tick && tick-state.tick <= ticks {
+			return Failure
+		}
+
+		status := blackboard.Run(child)
+		if status == Success {
+			state.succeeded = true
+			state.tick = tick
+		}
+		return status
+	}
+	return node
+}
+
+// Repeat is running until its child has succeeded the given number of times,
+// once per tick, and then succeeds and starts counting again. It fails as
+// soon as the child fails.
+func Repeat(times int, child Node) Node {
+	node := &decorator{name: fmt.Sprintf("repeat %d", times), child: child}
+	node.tick = func(blackboard *Blackboard, child Node) Status {
+		count := blackboard.nodeState(node, func() interface{} { return new(int) }).(*int)
+		switch blackboard.Run(child) {
+		case Failure:
+			*count = 0
+			return Failure
+		case Success:
+			*count++
+			if *count >= times {
+				*count = 0
+				return Success
+			}
+		}
+		return Running
+	}
+	return node
+}
+
+// timeoutState is the state of a Timeout.
+type timeoutState struct {
+	running bool
+	started uint64
+}
+
+// Timeout fails once its child has been running for more than the given
+// number of ticks in a row. A tick before the one the child started running
+// on starts the count over.
+func Timeout(ticks uint64, child Node) Node {
+	node := &decorator{name: fmt.Sprintf("timeout %d", ticks), child: child}
+	node.tick = func(blackboard *Blackboard, child Node) Status {
+		state := blackboard.nodeState(node, func() interface{} { return &timeoutState{} }).(*timeoutState)
+		tick := blackboard.Tick()
+		if state.running && tick < state.started {
+			state.running = false
+		}
+		if state.running && tick-state.started >= ticks {
+			state.running = false
+			return Failure
+		}
+
+		status := blackboard.Run(child)
+		if status != Running {
+			state.running = false
+			return status
+		}
+		if !state.running {
+			state.running = true
+			state.started = tick
+		}
+		return Running
+	}
+	return node
+}
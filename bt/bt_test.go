@@ -0,0 +1,263 @@
+package bt
+
+import (
+	"bytes"
+	"hackarena2-0-mono-tanks-go/packet/packets/bot_response"
+	"hackarena2-0-mono-tanks-go/packet/packets/bot_response/movement"
+	"hackarena2-0-mono-tanks-go/scenario"
+	"strings"
+	"testing"
+)
+
+func fixed(response *bot_response.BotResponse) func(*Blackboard) *bot_response.BotResponse {
+	return func(*Blackboard) *bot_response.BotResponse { return response }
+}
+
+func is(result bool) func(*Blackboard) bool {
+	return func(*Blackboard) bool { return result }
+}
+
+func status(result Status) func(*Blackboard) Status {
+	return func(*Blackboard) Status { return result }
+}
+
+// ticker returns a function ticking the node on the given game tick, with
+// the decorators keeping their state between calls.
+func ticker(node Node) func(gameTick uint64) Status {
+	nodes := make(map[Node]interface{})
+	return func(gameTick uint64) Status {
+		s := scenario.MustParse(`> .`)
+		s.GameState.Tick = gameTick
+		blackboard := &Blackboard{GameState: s.GameState, PlayerID: scenario.MyID, Memory: map[string]interface{}{}, nodes: nodes}
+		return blackboard.Run(node)
+	}
+}
+
+func tick(node Node, gameTick uint64) Status {
+	return ticker(node)(gameTick)
+}
+
+func TestTreeChoosesFirstMatchingBranch(t *testing.T) {
+	s := scenario.MustParse(`
+		# # # # #
+		# > . T #
+		# # # # #
+	`)
+	fire := bot_response.NewAbilityUse("fireBullet")
+	tree := NewTree(scenario.MyID, Selector("root",
+		Sequence("attack",
+			Condition("enemy in line", func(blackboard *Blackboard) bool {
+				return blackboard.MyTank() != nil && len(blackboard.GameState.Tanks) > 1
+			}),
+			Action("fire", fixed(fire)),
+		),
+		Action("wander", fixed(bot_response.NewMovement(movement.Forward))),
+	))
+
+	if response := tree.NextMove(s.GameState); *response != *fire {
+		t.Errorf("Expected to fire, got %+v", response)
+	}
+
+	s.GameState.Tanks = s.GameState.Tanks[:1]
+	if response := tree.NextMove(s.GameState); *response != *bot_response.NewMovement(movement.Forward) {
+		t.Errorf("Expected to wander without an enemy, got %+v", response)
+	}
+}
+
+func TestTreePassesWhenNoActionSucceeds(t *testing.T) {
+	s := scenario.MustParse(`> .`)
+	tree := NewTree(scenario.MyID, Action("nothing", fixed(nil)))
+
+	if response := tree.NextMove(s.GameState); *response != *bot_response.NewPass() {
+		t.Errorf("Expected a pass, got %+v", response)
+	}
+}
+
+func TestTreeKeepsMemory(t *testing.T) {
+	s := scenario.MustParse(`> .`)
+	tree := NewTree(scenario.MyID, Condition("count", func(blackboard *Blackboard) bool {
+		count, _ := blackboard.Memory["count"].(int)
+		blackboard.Memory["count"] = count + 1
+		return true
+	}))
+
+	tree.NextMove(s.GameState)
+	tree.NextMove(s.GameState)
+	if count := tree.Memory()["count"]; count != 2 {
+		t.Errorf("Expected the memory to count 2 ticks, got %v", count)
+	}
+}
+
+func TestComposites(t *testing.T) {
+	tests := []struct {
+		name     string
+		node     Node
+		expected Status
+	}{
+		{"sequence of successes", Sequence("s", Condition("a", is(true)), Condition("b", is(true))), Success},
+		{"sequence stops at failure", Sequence("s", Condition("a", is(false)), Leaf("b", status(Running))), Failure},
+		{"sequence stops at running", Sequence("s", Leaf("a", status(Running)), Condition("b", is(false))), Running},
+		{"selector of failures", Selector("s", Condition("a", is(false)), Condition("b", is(false))), Failure},
+		{"selector stops at success", Selector("s", Condition("a", is(false)), Condition("b", is(true))), Success},
+		{"parallel reaches required", Parallel("p", 2, Condition("a", is(true)), Condition("b", is(false)), Condition("c", is(true))), Success},
+		{"parallel can't reach required", Parallel("p", 2, Condition("a", is(false)), Condition("b", is(false)), Leaf("c", status(Running))), Failure},
+		{"parallel waits", Parallel("p", 2, Condition("a", is(true)), Leaf("b", status(Running))), Running},
+		{"inverter", Inverter(Condition("a", is(true))), Failure},
+		{"inverter keeps running", Inverter(Leaf("a", status(Running))), Running},
+	}
+
+	for _, test := range tests {
+		if result := tick(test.node, 1); result != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, result)
+		}
+	}
+}
+
+func TestCooldown(t *testing.T) {
+	calls := 0
+	tick := ticker(Cooldown(2, Condition("fire", func(*Blackboard) bool {
+		calls++
+		return true
+	})))
+
+	expected := []Status{Success, Failure, Failure, Success}
+	for i, want := range expected {
+		if result := tick(uint64(10 + i)); result != want {
+			t.Errorf("Expected %v on tick %d, got %v", want, 10+i, result)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("Expected the child to run twice, got %d", calls)
+	}
+}
+
+func TestRepeat(t *testing.T) {
+	tick := ticker(Repeat(3, Condition("step", is(true))))
+
+	expected := []Status{Running, Running, Success, Running}
+	for i, want := range expected {
+		if result := tick(uint64(i)); result != want {
+			t.Errorf("Expected %v on tick %d, got %v", want, i, result)
+		}
+	}
+
+	if result := ticker(Repeat(3, Condition("step", is(false))))(0); result != Failure {
+		t.Errorf("Expected a failing child to fail the repeat, got %v", result)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	done := false
+	tick := ticker(Timeout(3, Leaf("travel", func(*Blackboard) Status {
+		if done {
+			return Success
+		}
+		return Running
+	})))
+
+	expected := []Status{Running, Running, Running, Failure}
+	for i, want := range expected {
+		if result := tick(uint64(5 + i)); result != want {
+			t.Errorf("Expected %v on tick %d, got %v", want, 5+i, result)
+		}
+	}
+
+	if result := tick(9); result != Running {
+		t.Errorf("Expected the timeout to start over, got %v", result)
+	}
+	if result := tick(0); result != Running {
+		t.Errorf("Expected an earlier tick to start the timeout over, got %v", result)
+	}
+	done = true
+	if result := tick(1); result != Success {
+		t.Errorf("Expected the child to finish in time, got %v", result)
+	}
+}
+
+func TestDecoratorStateIsPerTree(t *testing.T) {
+	s := scenario.MustParse(`> .`)
+	fire := bot_response.NewAbilityUse("fireBullet")
+	root := Cooldown(5, Action("fire", fixed(fire)))
+	first, second := NewTree(scenario.MyID, root), NewTree(scenario.MyID, root)
+
+	s.GameState.Tick = 10
+	if response := first.NextMove(s.GameState); *response != *fire {
+		t.Errorf("Expected the first tree to fire, got %+v", response)
+	}
+	s.GameState.Tick = 11
+	if response := first.NextMove(s.GameState); *response != *bot_response.NewPass() {
+		t.Errorf("Expected the first tree to cool down, got %+v", response)
+	}
+	if response := second.NextMove(s.GameState); *response != *fire {
+		t.Errorf("Expected the second tree to fire, got %+v", response)
+	}
+}
+
+func TestDecoratorsStartOverInANewGame(t *testing.T) {
+	s := scenario.MustParse(`> .`)
+	fire := bot_response.NewAbilityUse("fireBullet")
+	tree := NewTree(scenario.MyID, Selector("root",
+		Cooldown(100, Action("fire", fixed(fire))),
+		Timeout(1, Leaf("travel", status(Running))),
+	))
+	tree.Tracing = true
+
+	s.GameState.Tick = 50
+	tree.NextMove(s.GameState)
+	s.GameState.Tick = 51
+	tree.NextMove(s.GameState)
+	s.GameState.Tick = 52
+	tree.NextMove(s.GameState)
+	if status := tree.LastTrace()[0].Status; status != Failure {
+		t.Errorf("Expected the timeout to run out, got %v", status)
+	}
+
+	s.GameState.Tick = 0
+	if response := tree.NextMove(s.GameState); *response != *fire {
+		t.Errorf("Expected the cooldown to start over in a new game, got %+v", response)
+	}
+	s.GameState.Tick = 1
+	tree.NextMove(s.GameState)
+	if status := tree.LastTrace()[0].Status; status != Running {
+		t.Errorf("Expected the timeout to start over in a new game, got %v", status)
+	}
+}
+
+func TestTracing(t *testing.T) {
+	s := scenario.MustParse(`> .`)
+	s.GameState.Tick = 7
+	var output bytes.Buffer
+	tree := NewTree(scenario.MyID, Selector("root",
+		Sequence("attack", Condition("enemy in line", is(false)), Action("fire", fixed(bot_response.NewAbilityUse("fireBullet")))),
+		Sequence("roam", Inverter(Condition("stuck", is(false))), Action("wander", fixed(bot_response.NewMovement(movement.Forward)))),
+	))
+
+	tree.NextMove(s.GameState)
+	if tree.LastTrace() != nil {
+		t.Errorf("Expected no trace while tracing is off, got %v", tree.LastTrace())
+	}
+
+	tree.Tracing = true
+	tree.TraceOutput = &output
+	tree.NextMove(s.GameState)
+
+	trace := tree.LastTrace()
+	if len(trace) != 7 {
+		t.Fatalf("Expected 7 nodes in the trace, got %d:\n%s", len(trace), trace)
+	}
+	if fired := strings.Join(trace.Fired(), " > "); fired != "root > roam > wander" {
+		t.Errorf("Expected the roam branch to fire, got %q", fired)
+	}
+	if trace[1].Name != "attack" || trace[1].Status != Failure || trace[1].Depth != 1 {
+		t.Errorf("Expected the attack branch to fail, got %+v", trace[1])
+	}
+	if trace[4].Name != "not stuck" || trace[4].Status != Success {
+		t.Errorf("Expected the inverter to succeed, got %+v", trace[4])
+	}
+	if !strings.Contains(output.String(), "Tick 7 fired root > roam > wander") {
+		t.Errorf("Expected the trace to be printed, got %q", output.String())
+	}
+	if !strings.Contains(output.String(), "    wander: success (chose the response)") {
+		t.Errorf("Expected the chosen action to be marked, got %q", output.String())
+	}
+}
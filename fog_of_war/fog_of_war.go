@@ -0,0 +1,492 @@
+// Package fog_of_war guesses where the enemy tanks hidden by the fog of war are.
+//
+// For every enemy the Tracker keeps a probability for each pose, a tile
+// together with a body direction. Every tick it spreads the probabilities
+// along the moves a tank can make, and then weighs them with what the game
+// state tells about the hidden tanks:
+//   - a visible tank is exactly where it is seen,
+//   - a hidden tank is on none of the visible tiles,
+//   - a bullet coming out of the fog was fired from the dark tiles behind it,
+//     by one of the hidden tanks that could be there,
+//   - a zone being captured or retaken by a player holds that player's tank,
+//   - a player scoring while our tank takes damage had our tank in its sights.
+package fog_of_war
+
+import (
+	"sort"
+
+	"hackarena2-0-mono-tanks-go/packet/packets/game_state"
+	"hackarena2-0-mono-tanks-go/pathing"
+)
+
+const (
+	// moves is the number of actions a tank chooses from each tick, each
+	// assumed to be equally likely: forward, backward, two rotations and
+	// doing neither.
+	moves = 5
+
+	// evidenceWeight is how much likelier the tiles pointed at by a bullet
+	// or a hit on our tank become.
+	evidenceWeight = 5.0
+
+	// bulletTrace is the number of dark tiles behind a new bullet its
+	// shooter may be on.
+	bulletTrace = 4
+)
+
+// Estimate is a pose an enemy tank may be in.
+type Estimate struct {
+	X           int
+	Y           int
+	Direction   string
+	Probability float64
+}
+
+// belief holds the probabilities of the poses of a tank, indexed like pathing poses.
+type belief struct {
+	poses []float64
+	dead  bool
+}
+
+// Tracker follows the enemies of a player through the fog of war. It is
+// not safe for concurrent use.
+type Tracker struct {
+	playerID string
+	width    int
+	height   int
+	walls    []bool
+	beliefs  map[string]*belief
+	previous *game_state.GameState
+}
+
+func NewTracker(playerID string) *Tracker {
+	return &Tracker{
+		playerID: playerID,
+		beliefs:  make(map[string]*belief),
+	}
+}
+
+// Observe updates the beliefs with the next game state.
+func (t *Tracker) Observe(gameState *game_state.GameState) {
+	t.resize(gameState)
+	for _, wall := range gameState.Walls {
+		t.walls[t.tile(wall.X, wall.Y)] = true
+	}
+
+	previous := t.previous
+	t.previous = gameState
+
+	var hidden []game_state.Player
+	for _, player := range gameState.Players {
+		if player.ID == t.playerID {
+			continue
+		}
+
+		b, ok := t.beliefs[player.ID]
+		if !ok {
+			b = &belief{poses: make([]float64, t.width*t.height*4)}
+			t.beliefs[player.ID] = b
+			t.spread(b, gameState)
+		}
+
+		if player.TicksToRegen != nil {
+			b.dead = true
+			continue
+		}
+		if b.dead {
+			// Respawned somewhere we can't see
+			b.dead = false
+			t.spread(b, gameState)
+		} else {
+			t.move(b)
+		}
+
+		if tank := gameState.Tank(player.ID); tank != nil {
+			t.locate(b, tank)
+			continue
+		}
+
+		t.hide(b, gameState)
+		hidden = append(hidden, player)
+	}
+
+	// A bullet is weighed against all the hidden tanks that may have fired it
+	if previous != nil {
+		t.weighBullets(hidden, gameState, previous)
+	}
+	for _, player := range hidden {
+		b := t.beliefs[player.ID]
+		if previous != nil {
+			t.weighScore(b, player, gameState, previous)
+		}
+		t.weighZones(b, player.ID, gameState)
+		t.normalize(b, gameState)
+	}
+}
+
+// MostLikely returns up to n of the likeliest poses of the enemy, likeliest
+// first. It is empty while the enemy is destroyed or unknown.
+func (t *Tracker) MostLikely(ownerID string, n int) []Estimate {
+	b, ok := t.beliefs[ownerID]
+	if !ok || b.dead {
+		return nil
+	}
+
+	var estimates []Estimate
+	for i, probability := range b.poses {
+		if probability > 0 {
+			tile := i / 4
+			estimates = append(estimates, Estimate{
+				X:           tile % t.width,
+				Y:           tile / t.width,
+				Direction:   pathing.Directions[i%4],
+				Probability: probability,
+			})
+		}
+	}
+	sort.SliceStable(estimates, func(i, j int) bool {
+		return estimates[i].Probability > estimates[j].Probability
+	})
+
+	if len(estimates) > n {
+		estimates = estimates[:n]
+	}
+	return estimates
+}
+
+// HeatMap returns, indexed by [y][x], the chance of each tile holding the tank of the enemy.
+func (t *Tracker) HeatMap(ownerID string) [][]float64 {
+	heat := t.emptyHeatMap()
+	if b, ok := t.beliefs[ownerID]; ok && !b.dead {
+		t.addHeat(heat, b)
+	}
+	return heat
+}
+
+// TotalHeatMap returns, indexed by [y][x], the expected number of enemy tanks on each tile.
+func (t *Tracker) TotalHeatMap() [][]float64 {
+	heat := t.emptyHeatMap()
+	for _, b := range t.beliefs {
+		if !b.dead {
+			t.addHeat(heat, b)
+		}
+	}
+	return heat
+}
+
+func (t *Tracker) emptyHeatMap() [][]float64 {
+	heat := make([][]float64, t.height)
+	for y := range heat {
+		heat[y] = make([]float64, t.width)
+	}
+	return heat
+}
+
+func (t *Tracker) addHeat(heat [][]float64, b *belief) {
+	for i, probability := range b.poses {
+		tile := i / 4
+		heat[tile/t.width][tile%t.width] += probability
+	}
+}
+
+// resize starts over when the size of the map changes, which only happens
+// before the first game state.
+func (t *Tracker) resize(gameState *game_state.GameState) {
+	height := len(gameState.Visibility)
+	width := 0
+	if height > 0 {
+		width = len(gameState.Visibility[0])
+	}
+	if width == t.width && height == t.height {
+		return
+	}
+
+	t.width, t.height = width, height
+	t.walls = make([]bool, width*height)
+	t.beliefs = make(map[string]*belief)
+	t.previous = nil
+}
+
+func (t *Tracker) tile(x, y int) int {
+	return y*t.width + x
+}
+
+func (t *Tracker) inside(x, y int) bool {
+	return x >= 0 && y >= 0 && x < t.width && y < t.height
+}
+
+func (t *Tracker) free(x, y int) bool {
+	return t.inside(x, y) && !t.walls[t.tile(x, y)]
+}
+
+// spread gives every pose on a dark tile without a wall the same probability.
+func (t *Tracker) spread(b *belief, gameState *game_state.GameState) {
+	for i := range b.poses {
+		b.poses[i] = 0
+	}
+	for y := 0; y < t.height; y++ {
+		for x := 0; x < t.width; x++ {
+			if t.free(x, y) && !visible(gameState, x, y) {
+				for d := 0; d < 4; d++ {
+					b.poses[t.tile(x, y)*4+d] = 1
+				}
+			}
+		}
+	}
+	t.normalize(b, gameState)
+}
+
+// move spreads every pose over the poses reachable in one tick. A move into
+// a wall or off the map leaves the tank where it was.
+func (t *Tracker) move(b *belief) {
+	next := make([]float64, len(b.poses))
+	for i, probability := range b.poses {
+		if probability == 0 {
+			continue
+		}
+
+		tile, d := i/4, i%4
+		x, y := tile%t.width, tile/t.width
+		share := probability / moves
+
+		next[i] += share
+		next[tile*4+(d+1)%4] += share
+		next[tile*4+(d+3)%4] += share
+
+		forwardX, forwardY := pathing.Step(x, y, pathing.Directions[d])
+		for _, target := range [][2]int{{forwardX, forwardY}, {2*x - forwardX, 2*y - forwardY}} {
+			if t.free(target[0], target[1]) {
+				next[t.tile(target[0], target[1])*4+d] += share
+			} else {
+				next[i] += share
+			}
+		}
+	}
+	b.poses = next
+}
+
+// locate puts all of the probability on the pose of a visible tank.
+func (t *Tracker) locate(b *belief, tank *game_state.Tank) {
+	for i := range b.poses {
+		b.poses[i] = 0
+	}
+	d := pathing.DirectionIndex(tank.Direction)
+	if t.inside(tank.X, tank.Y) && d >= 0 {
+		b.poses[t.tile(tank.X, tank.Y)*4+d] = 1
+	}
+}
+
+// hide removes the probability of the visible tiles, where the tank would be seen.
+func (t *Tracker) hide(b *belief, gameState *game_state.GameState) {
+	for y := 0; y < t.height; y++ {
+		for x := 0; x < t.width; x++ {
+			if visible(gameState, x, y) {
+				for d := 0; d < 4; d++ {
+					b.poses[t.tile(x, y)*4+d] = 0
+				}
+			}
+		}
+	}
+}
+
+// weighBullets favours the dark tiles behind the bullets that came out of the
+// fog. Only the hidden players whose tank can be on those tiles this tick may
+// have fired a bullet, and each of them gets a share of the evidence as large
+// as its chance of being there.
+func (t *Tracker) weighBullets(hidden []game_state.Player, gameState *game_state.GameState, previous *game_state.GameState) {
+	for _, bullet := range gameState.Bullets {
+		if previous.Bullet(bullet.ID) != nil {
+			continue
+		}
+		trace := t.bulletTrace(bullet, gameState)
+		if len(trace) == 0 {
+			continue
+		}
+
+		chances := make([]float64, len(hidden))
+		total := 0.0
+		for i, player := range hidden {
+			chances[i] = t.chanceOn(t.beliefs[player.ID], trace)
+			total += chances[i]
+		}
+		if total == 0 {
+			continue
+		}
+		for i, player := range hidden {
+			if chances[i] > 0 {
+				t.weigh(t.beliefs[player.ID], trace, chances[i]/total)
+			}
+		}
+	}
+}
+
+// bulletTrace returns the dark tiles behind a bullet its shooter may be on,
+// none if it was fired by a tank in plain sight.
+func (t *Tracker) bulletTrace(bullet game_state.Bullet, gameState *game_state.GameState) []int {
+
+	d := pathing.DirectionIndex(bullet.Direction)
+	if d < 0 {
+		return nil
+	}
+	behind := pathing.Directions[(d+2)%4]
+
+	var trace []int
+	x, y := bullet.X, bullet.Y
+	for i := 0; i < bulletTrace; i++ {
+		x, y = pathing.Step(x, y, behind)
+		if !t.free(x, y) {
+			break
+		}
+		// Fired by a tank in plain sight
+		if tankAt(gameState, x, y) {
+			return nil
+		}
+		if !visible(gameState, x, y) {
+			trace = append(trace, t.tile(x, y))
+		}
+	}
+	return trace
+}
+
+// chanceOn returns the probability of the tank being on one of the tiles.
+func (t *Tracker) chanceOn(b *belief, tiles []int) float64 {
+	total := sum(b.poses)
+	if total == 0 {
+		return 0
+	}
+
+	chance := 0.0
+	for _, tile := range tiles {
+		for d := 0; d < 4; d++ {
+			chance += b.poses[tile*4+d]
+		}
+	}
+	return chance / total
+}
+
+// weighScore favours the dark tiles in line with our tank when the player
+// scored while our tank lost health.
+func (t *Tracker) weighScore(b *belief, player game_state.Player, gameState *game_state.GameState, previous *game_state.GameState) {
+	before := previous.Player(player.ID)
+	if before == nil || before.Score == nil || player.Score == nil || *player.Score <= *before.Score {
+		return
+	}
+
+	myTank, myTankBefore := gameState.Tank(t.playerID), previous.Tank(t.playerID)
+	if myTank == nil || myTankBefore == nil || myTank.Health == nil || myTankBefore.Health == nil || *myTank.Health >= *myTankBefore.Health {
+		return
+	}
+
+	var lines []int
+	for _, dir := range pathing.Directions {
+		x, y := myTank.X, myTank.Y
+		for {
+			x, y = pathing.Step(x, y, dir)
+			if !t.free(x, y) {
+				break
+			}
+			if !visible(gameState, x, y) {
+				lines = append(lines, t.tile(x, y))
+			}
+		}
+	}
+	t.weigh(b, lines, 1)
+}
+
+// weighZones keeps only the tiles of a zone the player is capturing or retaking.
+func (t *Tracker) weighZones(b *belief, playerID string, gameState *game_state.GameState) {
+	for _, zone := range gameState.Zones {
+		capturing := (zone.Status.BeingCaptured != nil && zone.Status.BeingCaptured.PlayerID == playerID) ||
+			(zone.Status.BeingRetaken != nil && zone.Status.BeingRetaken.RetakenByID == playerID)
+		if !capturing {
+			continue
+		}
+
+		for i := range b.poses {
+			tile := i / 4
+			x, y := tile%t.width, tile/t.width
+			inZone := x >= int(zone.X) && x < int(zone.X+zone.Width) && y >= int(zone.Y) && y < int(zone.Y+zone.Height)
+			if !inZone {
+				b.poses[i] = 0
+			}
+		}
+		// Fall back to the tiles of the zone if the tank was believed elsewhere
+		if sum(b.poses) == 0 {
+			for y := int(zone.Y); y < int(zone.Y+zone.Height); y++ {
+				for x := int(zone.X); x < int(zone.X+zone.Width); x++ {
+					if t.free(x, y) && !visible(gameState, x, y) {
+						for d := 0; d < 4; d++ {
+							b.poses[t.tile(x, y)*4+d] = 1
+						}
+					}
+				}
+			}
+		}
+		return
+	}
+}
+
+// weigh makes the poses on the tiles evidenceWeight times likelier when the
+// evidence is certain to be about this tank, and less so for a smaller share
+// of it. Tiles the tank was believed not to be on get a share of the
+// probability as well, since the evidence may be wrong.
+func (t *Tracker) weigh(b *belief, tiles []int, share float64) {
+	if len(tiles) == 0 {
+		return
+	}
+
+	weight := 1 + (evidenceWeight-1)*share
+	total := sum(b.poses)
+	for _, tile := range tiles {
+		for d := 0; d < 4; d++ {
+			i := tile*4 + d
+			b.poses[i] = b.poses[i]*weight + share*total/float64(len(tiles)*4)
+		}
+	}
+}
+
+// normalize scales the probabilities to sum to one, or spreads them again
+// when the observations ruled out every pose.
+func (t *Tracker) normalize(b *belief, gameState *game_state.GameState) {
+	total := sum(b.poses)
+	if total == 0 {
+		for y := 0; y < t.height; y++ {
+			for x := 0; x < t.width; x++ {
+				if t.free(x, y) && !visible(gameState, x, y) {
+					for d := 0; d < 4; d++ {
+						b.poses[t.tile(x, y)*4+d] = 1
+					}
+				}
+			}
+		}
+		total = sum(b.poses)
+		if total == 0 {
+			return
+		}
+	}
+
+	for i := range b.poses {
+		b.poses[i] /= total
+	}
+}
+
+func sum(values []float64) float64 {
+	total := 0.0
+	for _, value := range values {
+		total += value
+	}
+	return total
+}
+
+func visible(gameState *game_state.GameState, x, y int) bool {
+	return y >= 0 && y < len(gameState.Visibility) && x >= 0 && x < len(gameState.Visibility[y]) && gameState.Visibility[y][x]
+}
+
+func tankAt(gameState *game_state.GameState, x, y int) bool {
+	for _, tank := range gameState.Tanks {
+		if tank.X == x && tank.Y == y {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,227 @@
+package fog_of_war
+
+import (
+	"hackarena2-0-mono-tanks-go/packet/packets/game_state"
+	"hackarena2-0-mono-tanks-go/packet/packets/game_state/direction"
+	"hackarena2-0-mono-tanks-go/scenario"
+	"math"
+	"testing"
+)
+
+func tick(number uint64, picture string) *game_state.GameState {
+	gameState := scenario.MustParse(picture).GameState
+	gameState.Tick = number
+	return gameState
+}
+
+// totalOf sums a heat map.
+func totalOf(heat [][]float64) float64 {
+	total := 0.0
+	for _, row := range heat {
+		for _, value := range row {
+			total += value
+		}
+	}
+	return total
+}
+
+func TestTrackerFollowsAHiddenTank(t *testing.T) {
+	tracker := NewTracker(scenario.MyID)
+	tracker.Observe(tick(1, `
+		# # # # # # #
+		# > . . . . #
+		# . . T . . #
+		# # # # # # #
+	`))
+
+	if estimates := tracker.MostLikely("enemy-1", 1); len(estimates) != 1 || estimates[0].X != 3 || estimates[0].Y != 2 || estimates[0].Probability != 1 {
+		t.Fatalf("Expected the visible tank to be certain, got %+v", estimates)
+	}
+
+	// The fog covers the enemy, which faced up
+	hidden := tick(2, `
+		# # # # # # #
+		# > . . ~ ~ #
+		# . . ~ ~ ~ #
+		# # # # # # #
+	`)
+	hidden.Players = append(hidden.Players, game_state.Player{ID: "enemy-1", Nickname: "Enemy 1"})
+	tracker.Observe(hidden)
+
+	heat := tracker.HeatMap("enemy-1")
+	if math.Abs(totalOf(heat)-1) > 1e-9 {
+		t.Errorf("Expected the heat map to sum to 1, got %v", totalOf(heat))
+	}
+	// Up leads to the visible (3, 1), backward into the wall, so the tank stayed
+	if heat[1][3] != 0 || heat[2][3] != 1 {
+		t.Errorf("Expected the tank to be at (3, 2), got %v", heat)
+	}
+
+	hidden.Tick = 3
+	tracker.Observe(hidden)
+	heat = tracker.HeatMap("enemy-1")
+	if heat[2][3] <= heat[2][4] || heat[2][4] == 0 || heat[2][5] != 0 {
+		t.Errorf("Expected the tank to spread from (3, 2) to (4, 2) only, got %v", heat)
+	}
+	if total := totalOf(tracker.TotalHeatMap()); math.Abs(total-1) > 1e-9 {
+		t.Errorf("Expected one enemy tank in total, got %v", total)
+	}
+}
+
+func TestTrackerUsesZoneCaptures(t *testing.T) {
+	tracker := NewTracker(scenario.MyID)
+	gameState := tick(1, `
+		# # # # # #
+		# > . ~ ~ #
+		# . . ~ ~ #
+		# ~ ~ a a #
+		# ~ ~ a a #
+		# # # # # #
+	`)
+	gameState.Players = append(gameState.Players, game_state.Player{ID: "enemy-1"})
+	// Zone tiles are drawn visible
+	for y := 3; y <= 4; y++ {
+		gameState.Visibility[y][3] = false
+		gameState.Visibility[y][4] = false
+	}
+	gameState.Zones[0].Status = game_state.ZoneStatus{
+		Type:          "beingCaptured",
+		BeingCaptured: &game_state.BeingCapturedStatus{PlayerID: "enemy-1", RemainingTicks: 10},
+	}
+	tracker.Observe(gameState)
+
+	heat := tracker.HeatMap("enemy-1")
+	for y, row := range heat {
+		for x, value := range row {
+			inZone := x >= 3 && y >= 3 && x <= 4 && y <= 4
+			if inZone && math.Abs(value-0.25) > 1e-9 || !inZone && value != 0 {
+				t.Errorf("Expected the tank spread over the zone, got %v at (%d, %d)", value, x, y)
+			}
+		}
+	}
+}
+
+func TestTrackerUsesBulletsFromTheDark(t *testing.T) {
+	tracker := NewTracker(scenario.MyID)
+	dark := `
+		# # # # # # #
+		# > . . . . #
+		# . ~ ~ ~ ~ #
+		# . ~ ~ ~ ~ #
+		# # # # # # #
+	`
+	first := tick(1, dark)
+	first.Players = append(first.Players, game_state.Player{ID: "enemy-1"})
+	tracker.Observe(first)
+
+	second := tick(2, `
+		# # # # # # #
+		# > . . ↑ . #
+		# . ~ ~ ~ ~ #
+		# . ~ ~ ~ ~ #
+		# # # # # # #
+	`)
+	second.Players = first.Players
+	tracker.Observe(second)
+
+	estimates := tracker.MostLikely("enemy-1", 8)
+	if len(estimates) != 8 {
+		t.Fatalf("Expected 8 estimates, got %+v", estimates)
+	}
+	for _, estimate := range estimates {
+		if estimate.X != 4 {
+			t.Errorf("Expected the shooter below the bullet, got %+v", estimate)
+		}
+	}
+}
+
+func TestTrackerWeighsBulletsForTanksThatCanFireThem(t *testing.T) {
+	tracker := NewTracker(scenario.MyID)
+	first := tick(1, `
+		# # # # # # # # #
+		# > . . . . . . #
+		# T . . . . . T #
+		# . . . . . T . #
+		# # # # # # # # #
+	`)
+	// enemy-1 is far from the bullet, enemy-2 right behind it and enemy-3
+	// can only get behind it by moving right
+	first.Tank("enemy-3").Direction = direction.Right
+	tracker.Observe(first)
+
+	second := tick(2, `
+		# # # # # # # # #
+		# > . . . . . ↑ #
+		# ~ ~ ~ ~ ~ ~ ~ #
+		# ~ ~ ~ ~ ~ ~ ~ #
+		# # # # # # # # #
+	`)
+	second.Players = first.Players
+	tracker.Observe(second)
+
+	heat := tracker.HeatMap("enemy-1")
+	if heat[2][7] != 0 || heat[3][7] != 0 {
+		t.Errorf("Expected the far tank not to be behind the bullet, got %v", heat)
+	}
+
+	heat = tracker.HeatMap("enemy-2")
+	if math.Abs(heat[2][7]+heat[3][7]-1) > 1e-9 {
+		t.Errorf("Expected the near tank behind the bullet, got %v", heat)
+	}
+
+	// enemy-3 is behind the bullet with a chance of 1/5 against enemy-2's 1,
+	// so it gets 1/6 of the evidence
+	heat = tracker.HeatMap("enemy-3")
+	if behind := heat[2][7] + heat[3][7]; math.Abs(behind-5.0/13) > 1e-9 {
+		t.Errorf("Expected a chance of 5/13 of the tank being behind the bullet, got %v", behind)
+	}
+}
+
+func TestTrackerForgetsDestroyedTanks(t *testing.T) {
+	tracker := NewTracker(scenario.MyID)
+	gameState := tick(1, `
+		# # # #
+		# > ~ #
+		# ~ ~ #
+		# # # #
+	`)
+	ticksToRegen := uint64(5)
+	gameState.Players = append(gameState.Players, game_state.Player{ID: "enemy-1", TicksToRegen: &ticksToRegen})
+	tracker.Observe(gameState)
+
+	if estimates := tracker.MostLikely("enemy-1", 3); estimates != nil {
+		t.Errorf("Expected no estimates for a destroyed tank, got %+v", estimates)
+	}
+
+	gameState.Tick = 2
+	gameState.Players[1].TicksToRegen = nil
+	tracker.Observe(gameState)
+	if estimates := tracker.MostLikely("enemy-1", 20); len(estimates) != 12 {
+		t.Errorf("Expected the respawned tank anywhere in the dark, got %+v", estimates)
+	}
+}
+
+func TestTrackerUsesHitsOnOurTank(t *testing.T) {
+	tracker := NewTracker(scenario.MyID)
+	picture := `
+		# # # # # #
+		# > . ~ ~ #
+		# ~ ~ ~ ~ #
+		# # # # # #
+	`
+	first := tick(1, picture)
+	score := uint64(0)
+	first.Players = append(first.Players, game_state.Player{ID: "enemy-1", Score: &score})
+	tracker.Observe(first)
+
+	second := tick(2, picture)
+	*second.Tanks[0].Health -= 10
+	newScore := uint64(10)
+	second.Players = append(second.Players, game_state.Player{ID: "enemy-1", Score: &newScore})
+	tracker.Observe(second)
+
+	heat := tracker.HeatMap("enemy-1")
+	if heat[1][3] <= heat[2][3] || heat[2][1] <= heat[2][2] {
+		t.Errorf("Expected the tiles in line with our tank to be likelier, got %v", heat)
+	}
+}
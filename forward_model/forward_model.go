@@ -0,0 +1,659 @@
+// Package forward_model plays ticks of the game ahead of time, for bots
+// that search over future moves.
+//
+// The wrapper has no copy of the server's simulation, so the model carries
+// its own, simplified version of the rules. The numbers of the game, such
+// as damage and regeneration times, are in Rules and can be tuned to match
+// the server.
+//
+// A State only knows what the game state it was built from shows. Tanks
+// hidden by the fog of war can be added with AddTank, for example at the
+// poses guessed by the fog_of_war package, before searching.
+//
+// States are built for speed: the walls are shared between copies, and
+// CopyTo and Step reuse the memory of the states they write to, so a search
+// can keep a few states and replay millions of ticks without allocating.
+package forward_model
+
+import (
+	"fmt"
+
+	"hackarena2-0-mono-tanks-go/packet/packets/bot_response"
+	"hackarena2-0-mono-tanks-go/packet/packets/bot_response/ability"
+	"hackarena2-0-mono-tanks-go/packet/packets/bot_response/movement"
+	"hackarena2-0-mono-tanks-go/packet/packets/bot_response/rotation"
+	"hackarena2-0-mono-tanks-go/packet/packets/game_state"
+	"hackarena2-0-mono-tanks-go/pathing"
+)
+
+// Action is a response of a bot, packed into a number.
+type Action uint8
+
+const (
+	Pass Action = iota
+	MoveForward
+	MoveBackward
+	RotateLeft
+	RotateRight
+	TurretLeft
+	TurretRight
+	RotateLeftTurretLeft
+	RotateLeftTurretRight
+	RotateRightTurretLeft
+	RotateRightTurretRight
+	FireBullet
+	FireDoubleBullet
+	UseLaser
+	UseRadar
+	DropMine
+)
+
+// Actions lists every action.
+var Actions = [...]Action{
+	Pass, MoveForward, MoveBackward,
+	RotateLeft, RotateRight, TurretLeft, TurretRight,
+	RotateLeftTurretLeft, RotateLeftTurretRight, RotateRightTurretLeft, RotateRightTurretRight,
+	FireBullet, FireDoubleBullet, UseLaser, UseRadar, DropMine,
+}
+
+// rotations are the quarter turns of the tank and the turret of each
+// action, clockwise. Arrays indexed by action keep lookups out of maps in
+// the hot loop.
+var rotations = [len(Actions)][2]int{
+	RotateLeft:             {-1, 0},
+	RotateRight:            {1, 0},
+	TurretLeft:             {0, -1},
+	TurretRight:            {0, 1},
+	RotateLeftTurretLeft:   {-1, -1},
+	RotateLeftTurretRight:  {-1, 1},
+	RotateRightTurretLeft:  {1, -1},
+	RotateRightTurretRight: {1, 1},
+}
+
+var abilities = [len(Actions)]string{
+	FireBullet:       ability.FireBullet,
+	FireDoubleBullet: ability.FireDoubleBullet,
+	UseLaser:         ability.UseLaser,
+	UseRadar:         ability.UseRadar,
+	DropMine:         ability.DropMine,
+}
+
+// itemAbilities holds the secondary item each ability uses up.
+var itemAbilities = [len(Actions)]string{
+	FireDoubleBullet: "doubleBullet",
+	UseLaser:         "laser",
+	UseRadar:         "radar",
+	DropMine:         "mine",
+}
+
+// IsRotation reports whether the action rotates the tank or its turret.
+func (a Action) IsRotation() bool {
+	return a >= RotateLeft && a <= RotateRightTurretRight
+}
+
+// IsAbility reports whether the action uses an ability.
+func (a Action) IsAbility() bool {
+	return a >= FireBullet && a <= DropMine
+}
+
+// Response returns the bot response of the action.
+func (a Action) Response() *bot_response.BotResponse {
+	switch a {
+	case MoveForward:
+		return bot_response.NewMovement(movement.Forward)
+	case MoveBackward:
+		return bot_response.NewMovement(movement.Backward)
+	case Pass:
+		return bot_response.NewPass()
+	}
+	if a.IsAbility() {
+		return bot_response.NewAbilityUse(abilities[a])
+	}
+	turns := rotations[a]
+	return bot_response.NewRotation(rotationName(turns[0]), rotationName(turns[1]))
+}
+
+// ActionOf returns the action of a bot response, and false if the response is invalid.
+func ActionOf(response *bot_response.BotResponse) (Action, bool) {
+	for _, action := range Actions {
+		if *action.Response() == *response {
+			return action, true
+		}
+	}
+	return Pass, false
+}
+
+func rotationName(turn int) string {
+	switch turn {
+	case -1:
+		return rotation.Left
+	case 1:
+		return rotation.Right
+	}
+	return ""
+}
+
+// Rules are the numbers of the game the model plays by.
+type Rules struct {
+	// Health is the health of a tank, also assumed for enemy tanks whose health is hidden.
+	Health int
+
+	// MaxBullets is the number of bullets a turret holds, also assumed for enemy turrets.
+	MaxBullets int
+
+	// BulletRegenTicks is the number of ticks a turret takes to regenerate a bullet.
+	BulletRegenTicks int
+
+	// BulletSpeed and DoubleBulletSpeed are the tiles travelled per tick by fired bullets.
+	BulletSpeed       float64
+	DoubleBulletSpeed float64
+
+	// Damage dealt by each kind of hit.
+	BulletDamage       int
+	DoubleBulletDamage int
+	LaserDamage        int
+	MineDamage         int
+}
+
+// DefaultRules are unverified guesses: only the bullet speeds come from the
+// game states the server sends, and the rest have not been checked against
+// a running server, since the wrapper has no simulator to compare with.
+// Measure them on the server the bot plays on before relying on them.
+var DefaultRules = Rules{
+	Health:             100,
+	MaxBullets:         3,
+	BulletRegenTicks:   10,
+	BulletSpeed:        1,
+	DoubleBulletSpeed:  1.5,
+	BulletDamage:       20,
+	DoubleBulletDamage: 40,
+	LaserDamage:        80,
+	MineDamage:         50,
+}
+
+// Tank is a tank in the model.
+type Tank struct {
+	OwnerID string
+	X       int
+	Y       int
+
+	// Direction and TurretDirection are indices into pathing.Directions.
+	Direction       int
+	TurretDirection int
+
+	// Health is zero or less once the tank is destroyed.
+	Health int
+
+	Bullets         int
+	TicksToBullet   int
+	Item            string
+	UsingRadar      bool
+	Score           int
+	DestroyedOnTick uint64
+}
+
+// Alive reports whether the tank has not been destroyed.
+func (t *Tank) Alive() bool {
+	return t.Health > 0
+}
+
+// Bullet is a bullet in the model.
+type Bullet struct {
+	X         int
+	Y         int
+	Direction int
+	Speed     float64
+	Damage    int
+
+	// Owner is the index of the tank that fired the bullet, or -1 if unknown.
+	Owner int
+
+	// progress is the part of a tile travelled but not yet moved.
+	progress float64
+}
+
+// Mine is a mine in the model.
+type Mine struct {
+	X int
+	Y int
+
+	// Owner is the index of the tank that dropped the mine, or -1 if unknown.
+	Owner int
+}
+
+// Item is an item lying on the map.
+type Item struct {
+	X    int
+	Y    int
+	Type string
+}
+
+// State is a game state the model can step.
+type State struct {
+	Rules  *Rules
+	Width  int
+	Height int
+	Tick   uint64
+
+	Tanks   []Tank
+	Bullets []Bullet
+	Mines   []Mine
+	Items   []Item
+
+	// walls is shared between copies and never changed.
+	walls []bool
+}
+
+// FromGameState builds a state from what the game state shows. It fails if
+// a tank, turret or bullet faces a direction the model doesn't know.
+func FromGameState(gameState *game_state.GameState, rules *Rules) (*State, error) {
+	height := len(gameState.Visibility)
+	width := 0
+	if height > 0 {
+		width = len(gameState.Visibility[0])
+	}
+
+	s := &State{
+		Rules:  rules,
+		Width:  width,
+		Height: height,
+		Tick:   gameState.Tick,
+		walls:  make([]bool, width*height),
+	}
+	for _, wall := range gameState.Walls {
+		if s.inside(wall.X, wall.Y) {
+			s.walls[wall.Y*width+wall.X] = true
+		}
+	}
+
+	for _, tank := range gameState.Tanks {
+		direction, err := directionIndex(tank.Direction)
+		if err != nil {
+			return nil, fmt.Errorf("tank of %s: %w", tank.OwnerID, err)
+		}
+		turretDirection, err := directionIndex(tank.Turret.Direction)
+		if err != nil {
+			return nil, fmt.Errorf("turret of %s: %w", tank.OwnerID, err)
+		}
+		modelTank := Tank{
+			OwnerID:         tank.OwnerID,
+			X:               tank.X,
+			Y:               tank.Y,
+			Direction:       direction,
+			TurretDirection: turretDirection,
+			Health:          rules.Health,
+			Bullets:         rules.MaxBullets,
+			TicksToBullet:   rules.BulletRegenTicks,
+		}
+		if tank.Health != nil {
+			modelTank.Health = *tank.Health
+		}
+		if tank.Turret.BulletCount != nil {
+			modelTank.Bullets = *tank.Turret.BulletCount
+		}
+		if tank.Turret.TicksToRegenBullet != nil {
+			modelTank.TicksToBullet = *tank.Turret.TicksToRegenBullet
+		}
+		if tank.SecondaryItem != nil {
+			modelTank.Item = *tank.SecondaryItem
+		}
+		for _, player := range gameState.Players {
+			if player.ID == tank.OwnerID && player.Score != nil {
+				modelTank.Score = int(*player.Score)
+			}
+		}
+		s.Tanks = append(s.Tanks, modelTank)
+	}
+
+	for _, bullet := range gameState.Bullets {
+		direction, err := directionIndex(bullet.Direction)
+		if err != nil {
+			return nil, fmt.Errorf("bullet %d: %w", bullet.ID, err)
+		}
+		damage := rules.BulletDamage
+		if bullet.Type == "double" {
+			damage = rules.DoubleBulletDamage
+		}
+		s.Bullets = append(s.Bullets, Bullet{
+			X:         bullet.X,
+			Y:         bullet.Y,
+			Direction: direction,
+			Speed:     bullet.Speed,
+			Damage:    damage,
+			Owner:     -1,
+		})
+	}
+	for _, mine := range gameState.Mines {
+		s.Mines = append(s.Mines, Mine{X: mine.X, Y: mine.Y, Owner: -1})
+	}
+	for _, item := range gameState.Items {
+		s.Items = append(s.Items, Item{X: item.X, Y: item.Y, Type: item.Type})
+	}
+
+	return s, nil
+}
+
+// directionIndex returns the index of the direction in pathing.Directions.
+func directionIndex(dir string) (int, error) {
+	i := pathing.DirectionIndex(dir)
+	if i < 0 {
+		return 0, fmt.Errorf("unknown direction %q", dir)
+	}
+	return i, nil
+}
+
+// AddTank adds a tank the game state did not show, and returns its index.
+func (s *State) AddTank(tank Tank) int {
+	s.Tanks = append(s.Tanks, tank)
+	return len(s.Tanks) - 1
+}
+
+// TankIndex returns the index of the tank of the player, or -1 if the state has none.
+func (s *State) TankIndex(ownerID string) int {
+	for i := range s.Tanks {
+		if s.Tanks[i].OwnerID == ownerID {
+			return i
+		}
+	}
+	return -1
+}
+
+// Wall reports whether the tile holds a wall. Tiles outside of the map count as walls.
+func (s *State) Wall(x, y int) bool {
+	return !s.inside(x, y) || s.walls[y*s.Width+x]
+}
+
+// Clone returns a copy of the state.
+func (s *State) Clone() *State {
+	clone := &State{}
+	s.CopyTo(clone)
+	return clone
+}
+
+// CopyTo makes dst a copy of the state, reusing the memory of dst.
+func (s *State) CopyTo(dst *State) {
+	dst.Rules = s.Rules
+	dst.Width = s.Width
+	dst.Height = s.Height
+	dst.Tick = s.Tick
+	dst.walls = s.walls
+	dst.Tanks = append(dst.Tanks[:0], s.Tanks...)
+	dst.Bullets = append(dst.Bullets[:0], s.Bullets...)
+	dst.Mines = append(dst.Mines[:0], s.Mines...)
+	dst.Items = append(dst.Items[:0], s.Items...)
+}
+
+// Legal reports whether the tank with the index can usefully take the
+// action. Passing is always legal, anything else needs a living tank, and
+// abilities need a bullet or the item they use up.
+func (s *State) Legal(tank int, action Action) bool {
+	if action == Pass {
+		return true
+	}
+	t := &s.Tanks[tank]
+	if !t.Alive() {
+		return false
+	}
+	if action == FireBullet {
+		return t.Bullets > 0
+	}
+	if item := itemAbilities[action]; item != "" {
+		return t.Item == item
+	}
+	return true
+}
+
+// Step plays one tick. actions holds the action of each tank, by index,
+// and tanks without an action pass. The tick is played in phases: tanks
+// rotate, then move one by one, then use their abilities, then bullets
+// fly and turrets regenerate.
+func (s *State) Step(actions []Action) {
+	action := func(i int) Action {
+		if i < len(actions) && s.Legal(i, actions[i]) {
+			return actions[i]
+		}
+		return Pass
+	}
+
+	for i := range s.Tanks {
+		if a := action(i); a.IsRotation() {
+			turns := rotations[a]
+			t := &s.Tanks[i]
+			t.Direction = (t.Direction + turns[0] + 4) % 4
+			t.TurretDirection = (t.TurretDirection + turns[1] + 4) % 4
+		}
+	}
+
+	for i := range s.Tanks {
+		a := action(i)
+		if a != MoveForward && a != MoveBackward {
+			continue
+		}
+
+		t := &s.Tanks[i]
+		dir := t.Direction
+		if a == MoveBackward {
+			dir = (dir + 2) % 4
+		}
+		x, y := Neighbour(t.X, t.Y, dir)
+		if s.Wall(x, y) || s.tankAt(x, y) >= 0 {
+			continue
+		}
+		t.X, t.Y = x, y
+		s.enterTile(i)
+	}
+
+	for i := range s.Tanks {
+		a := action(i)
+		t := &s.Tanks[i]
+		switch a {
+		case FireBullet:
+			t.Bullets--
+			s.fire(i, s.Rules.BulletSpeed, s.Rules.BulletDamage)
+		case FireDoubleBullet:
+			t.Item = ""
+			s.fire(i, s.Rules.DoubleBulletSpeed, s.Rules.DoubleBulletDamage)
+		case UseLaser:
+			t.Item = ""
+			s.laser(i)
+		case UseRadar:
+			t.Item = ""
+			t.UsingRadar = true
+		case DropMine:
+			t.Item = ""
+			s.Mines = append(s.Mines, Mine{X: t.X, Y: t.Y, Owner: i})
+		}
+	}
+
+	s.moveBullets()
+
+	for i := range s.Tanks {
+		t := &s.Tanks[i]
+		if t.Bullets >= s.Rules.MaxBullets {
+			continue
+		}
+		t.TicksToBullet--
+		if t.TicksToBullet <= 0 {
+			t.Bullets++
+			t.TicksToBullet = s.Rules.BulletRegenTicks
+		}
+	}
+
+	s.Tick++
+}
+
+// enterTile sets off mines and picks up items on the tile the tank moved onto.
+func (s *State) enterTile(tank int) {
+	t := &s.Tanks[tank]
+
+	for i := 0; i < len(s.Mines); i++ {
+		if s.Mines[i].X == t.X && s.Mines[i].Y == t.Y {
+			s.damage(tank, s.Mines[i].Owner, s.Rules.MineDamage)
+			s.Mines = removeMine(s.Mines, i)
+			i--
+		}
+	}
+
+	if t.Item != "" {
+		return
+	}
+	for i := range s.Items {
+		if s.Items[i].X == t.X && s.Items[i].Y == t.Y {
+			t.Item = s.Items[i].Type
+			s.Items = append(s.Items[:i], s.Items[i+1:]...)
+			return
+		}
+	}
+}
+
+// fire puts a bullet on the tile the turret faces. It hits at once if that
+// tile holds a tank.
+func (s *State) fire(tank int, speed float64, damage int) {
+	t := &s.Tanks[tank]
+	x, y := Neighbour(t.X, t.Y, t.TurretDirection)
+	if s.Wall(x, y) {
+		return
+	}
+	if target := s.tankAt(x, y); target >= 0 {
+		s.damage(target, tank, damage)
+		return
+	}
+	s.Bullets = append(s.Bullets, Bullet{
+		X:         x,
+		Y:         y,
+		Direction: t.TurretDirection,
+		Speed:     speed,
+		Damage:    damage,
+		Owner:     tank,
+	})
+}
+
+// laser damages every tank in line with the turret up to the first wall.
+func (s *State) laser(tank int) {
+	t := &s.Tanks[tank]
+	x, y := Neighbour(t.X, t.Y, t.TurretDirection)
+	for !s.Wall(x, y) {
+		if target := s.tankAt(x, y); target >= 0 {
+			s.damage(target, tank, s.Rules.LaserDamage)
+		}
+		x, y = Neighbour(x, y, t.TurretDirection)
+	}
+}
+
+// moveBullets moves the bullets a tile at a time, so they can't jump over
+// tanks or each other. Bullets meeting on a tile destroy each other.
+func (s *State) moveBullets() {
+	for i := range s.Bullets {
+		s.Bullets[i].progress += s.Bullets[i].Speed
+	}
+
+	for moved := true; moved; {
+		moved = false
+		for i := 0; i < len(s.Bullets); i++ {
+			b := &s.Bullets[i]
+			if b.progress < 1 {
+				continue
+			}
+			b.progress--
+			moved = true
+
+			x, y := Neighbour(b.X, b.Y, b.Direction)
+			hit := false
+			if s.Wall(x, y) {
+				hit = true
+			} else if target := s.tankAt(x, y); target >= 0 {
+				s.damage(target, b.Owner, b.Damage)
+				hit = true
+			} else if other := s.bulletAt(x, y, i); other >= 0 {
+				s.Bullets = removeBullet(s.Bullets, max(i, other))
+				s.Bullets = removeBullet(s.Bullets, min(i, other))
+				i = min(i, other) - 1
+				continue
+			}
+
+			if hit {
+				s.Bullets = removeBullet(s.Bullets, i)
+				i--
+				continue
+			}
+			b.X, b.Y = x, y
+		}
+	}
+
+	for i := range s.Bullets {
+		s.Bullets[i].progress = 0
+	}
+}
+
+// damage lowers the health of a tank, and credits the damage to the attacker.
+func (s *State) damage(tank int, attacker int, amount int) {
+	t := &s.Tanks[tank]
+	if !t.Alive() {
+		return
+	}
+
+	t.Health -= amount
+	if !t.Alive() {
+		t.DestroyedOnTick = s.Tick
+	}
+	if attacker >= 0 && attacker != tank {
+		s.Tanks[attacker].Score += amount
+	}
+}
+
+func (s *State) inside(x, y int) bool {
+	return x >= 0 && y >= 0 && x < s.Width && y < s.Height
+}
+
+// tankAt returns the index of the living tank on the tile, or -1.
+func (s *State) tankAt(x, y int) int {
+	for i := range s.Tanks {
+		if s.Tanks[i].X == x && s.Tanks[i].Y == y && s.Tanks[i].Alive() {
+			return i
+		}
+	}
+	return -1
+}
+
+// LineOfFire returns the index of the first living tank in line from the
+// tile (x, y) in the direction, up to the first wall, or -1. The tile
+// itself is not checked, so a tank can look down the line of its turret.
+func (s *State) LineOfFire(x, y, dir int) int {
+	for {
+		x, y = Neighbour(x, y, dir)
+		if s.Wall(x, y) {
+			return -1
+		}
+		if target := s.tankAt(x, y); target >= 0 {
+			return target
+		}
+	}
+}
+
+// bulletAt returns the index of another bullet on the tile, or -1.
+func (s *State) bulletAt(x, y int, except int) int {
+	for i := range s.Bullets {
+		if i != except && s.Bullets[i].X == x && s.Bullets[i].Y == y {
+			return i
+		}
+	}
+	return -1
+}
+
+var (
+	deltaX = [4]int{0, 1, 0, -1}
+	deltaY = [4]int{-1, 0, 1, 0}
+)
+
+// Neighbour returns the tile next to (x, y) in the direction, an index into pathing.Directions.
+func Neighbour(x, y, dir int) (int, int) {
+	return x + deltaX[dir], y + deltaY[dir]
+}
+
+func removeBullet(bullets []Bullet, i int) []Bullet {
+	return append(bullets[:i], bullets[i+1:]...)
+}
+
+func removeMine(mines []Mine, i int) []Mine {
+	return append(mines[:i], mines[i+1:]...)
+}
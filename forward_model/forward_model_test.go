@@ -0,0 +1,254 @@
+package forward_model
+
+import (
+	"hackarena2-0-mono-tanks-go/packet/packets/bot_response"
+	"hackarena2-0-mono-tanks-go/scenario"
+	"math/rand"
+	"testing"
+)
+
+func state(t testing.TB, picture string) *State {
+	t.Helper()
+	s, err := FromGameState(scenario.MustParse(picture).GameState, &DefaultRules)
+	if err != nil {
+		t.Fatalf("Expected a state, got %v", err)
+	}
+	return s
+}
+
+// mine returns the actions with only our tank taking the action.
+func mine(s *State, action Action) []Action {
+	actions := make([]Action, len(s.Tanks))
+	actions[s.TankIndex(scenario.MyID)] = action
+	return actions
+}
+
+func TestFromGameState(t *testing.T) {
+	s := state(t, `
+		# # # # #
+		# > . ↓ #
+		# . X T #
+		# D . . #
+	`)
+
+	if s.Width != 5 || s.Height != 4 || !s.Wall(0, 0) || s.Wall(1, 1) || !s.Wall(-1, 2) {
+		t.Errorf("Expected a 5x4 map with walls, got %dx%d", s.Width, s.Height)
+	}
+	me := s.Tanks[s.TankIndex(scenario.MyID)]
+	if me.Direction != 1 || me.Health != 100 || me.Bullets != 3 {
+		t.Errorf("Expected our tank facing right with full health, got %+v", me)
+	}
+	if len(s.Bullets) != 1 || len(s.Mines) != 1 || len(s.Items) != 1 {
+		t.Errorf("Expected a bullet, a mine and an item, got %+v", s)
+	}
+	if s.TankIndex("nobody") != -1 {
+		t.Errorf("Expected no tank of an unknown player")
+	}
+}
+
+func TestFromGameStateRejectsUnknownDirections(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(s *scenario.Scenario)
+	}{
+		{"tank", func(s *scenario.Scenario) { s.GameState.Tanks[0].Direction = "sideways" }},
+		{"turret", func(s *scenario.Scenario) { s.GameState.Tanks[0].Turret.Direction = "" }},
+		{"bullet", func(s *scenario.Scenario) { s.GameState.Bullets[0].Direction = "north" }},
+	}
+
+	for _, test := range tests {
+		s := scenario.MustParse(`> . ↓`)
+		test.corrupt(s)
+		if _, err := FromGameState(s.GameState, &DefaultRules); err == nil {
+			t.Errorf("%s: expected an error for an unknown direction", test.name)
+		}
+	}
+}
+
+func TestStepMovesAndRotates(t *testing.T) {
+	s := state(t, `
+		# # # # # #
+		# > . . T #
+		# D . . . #
+		# # # # # #
+	`)
+	me := s.TankIndex(scenario.MyID)
+
+	s.Step(mine(s, MoveForward))
+	if tank := s.Tanks[me]; tank.X != 2 || tank.Y != 1 {
+		t.Errorf("Expected our tank at (2, 1), got (%d, %d)", tank.X, tank.Y)
+	}
+
+	s.Step(mine(s, RotateRightTurretLeft))
+	if tank := s.Tanks[me]; tank.Direction != 2 || tank.TurretDirection != 0 {
+		t.Errorf("Expected our tank facing down with the turret up, got %d and %d", tank.Direction, tank.TurretDirection)
+	}
+
+	s.Step(mine(s, MoveForward))
+	s.Step(mine(s, RotateRight))
+	s.Step(mine(s, MoveForward))
+	if tank := s.Tanks[me]; tank.X != 1 || tank.Y != 2 || tank.Item != "doubleBullet" || len(s.Items) != 0 {
+		t.Errorf("Expected our tank to pick up the item at (1, 2), got %+v", tank)
+	}
+
+	// Walls stop the tank
+	s.Step(mine(s, MoveForward))
+	if tank := s.Tanks[me]; tank.X != 1 {
+		t.Errorf("Expected the wall to stop our tank, got (%d, %d)", tank.X, tank.Y)
+	}
+	if s.Tick != 6 {
+		t.Errorf("Expected tick 6, got %d", s.Tick)
+	}
+}
+
+func TestStepFiresBullets(t *testing.T) {
+	s := state(t, `
+		# # # # # # #
+		# > . . . T #
+		# # # # # # #
+	`)
+	me, enemy := s.TankIndex(scenario.MyID), s.TankIndex("enemy-1")
+
+	s.Step(mine(s, FireBullet))
+	if len(s.Bullets) != 1 || s.Bullets[0].X != 3 || s.Tanks[me].Bullets != 2 {
+		t.Fatalf("Expected a bullet at (3, 1) and 2 bullets left, got %+v and %d", s.Bullets, s.Tanks[me].Bullets)
+	}
+
+	s.Step(nil)
+	s.Step(nil)
+	if len(s.Bullets) != 0 || s.Tanks[enemy].Health != 80 || s.Tanks[me].Score != 20 {
+		t.Errorf("Expected the bullet to hit the enemy, got %+v, health %d", s.Bullets, s.Tanks[enemy].Health)
+	}
+
+	// Without a double bullet the ability is ignored
+	s.Step(mine(s, FireDoubleBullet))
+	if len(s.Bullets) != 0 {
+		t.Errorf("Expected no double bullet without the item")
+	}
+
+	s.Tanks[me].Item = "laser"
+	s.Step(mine(s, UseLaser))
+	if s.Tanks[enemy].Alive() || s.Tanks[me].Item != "" {
+		t.Errorf("Expected the laser to destroy the enemy, got health %d", s.Tanks[enemy].Health)
+	}
+	if s.Legal(enemy, MoveForward) {
+		t.Errorf("Expected a destroyed tank to have no legal moves")
+	}
+}
+
+func TestStepCollidesBullets(t *testing.T) {
+	s := state(t, `
+		# # # # # # #
+		# → . . ← . #
+		# # # # # # #
+	`)
+	s.Step(nil)
+	if len(s.Bullets) != 2 {
+		t.Fatalf("Expected the bullets to fly towards each other, got %+v", s.Bullets)
+	}
+	s.Step(nil)
+	if len(s.Bullets) != 0 {
+		t.Errorf("Expected the bullets to destroy each other, got %+v", s.Bullets)
+	}
+}
+
+func TestStepSetsOffMines(t *testing.T) {
+	s := state(t, `
+		# # # # #
+		# > X . #
+		# # # # #
+	`)
+	me := s.TankIndex(scenario.MyID)
+
+	s.Step(mine(s, MoveForward))
+	if s.Tanks[me].Health != 50 || len(s.Mines) != 0 {
+		t.Errorf("Expected the mine to explode, got health %d and %+v", s.Tanks[me].Health, s.Mines)
+	}
+}
+
+func TestLineOfFire(t *testing.T) {
+	s := state(t, `
+		# # # # # #
+		# > . T T #
+		# . # . . #
+		# T . . . #
+	`)
+	me := s.TankIndex(scenario.MyID)
+
+	if target := s.LineOfFire(1, 1, 1); target < 0 || s.Tanks[target].X != 3 {
+		t.Errorf("Expected the nearest enemy to the right, got %d", target)
+	}
+	if target := s.LineOfFire(3, 1, 3); target != me {
+		t.Errorf("Expected our tank to the left of the enemy, got %d", target)
+	}
+	if target := s.LineOfFire(1, 1, 0); target != -1 {
+		t.Errorf("Expected a wall above, got %d", target)
+	}
+	if target := s.LineOfFire(1, 1, 2); target < 0 || s.Tanks[target].Y != 3 {
+		t.Errorf("Expected the enemy below, got %d", target)
+	}
+	if target := s.LineOfFire(1, 3, 1); target != -1 {
+		t.Errorf("Expected nothing to the right of the lower enemy, got %d", target)
+	}
+}
+
+func TestCopyTo(t *testing.T) {
+	s := state(t, `
+		# # # #
+		# > . #
+		# # # #
+	`)
+	var clone State
+	s.CopyTo(&clone)
+	clone.Step([]Action{MoveForward})
+
+	if s.Tanks[0].X != 1 || clone.Tanks[0].X != 2 {
+		t.Errorf("Expected the copy to move alone, got %d and %d", s.Tanks[0].X, clone.Tanks[0].X)
+	}
+}
+
+func TestActionResponses(t *testing.T) {
+	for _, action := range Actions {
+		parsed, ok := ActionOf(action.Response())
+		if !ok || parsed != action {
+			t.Errorf("Expected %d to survive a round trip, got %d", action, parsed)
+		}
+	}
+	if _, ok := ActionOf(&bot_response.BotResponse{Type: bot_response.Rotation}); ok {
+		t.Errorf("Expected an empty rotation to have no action")
+	}
+}
+
+// BenchmarkStep plays random ticks on a small map, copying the state back
+// every 20 ticks like a rollout of a tree search.
+func BenchmarkStep(b *testing.B) {
+	root := state(b, `
+		# # # # # # # # # #
+		# > . . . . . . . #
+		# . # # . . # # . #
+		# . # . . . . # . #
+		# . . . D . . . . #
+		# . . . . X . . . #
+		# . # . . . . # . #
+		# . # # . . # # . #
+		# . . . . . . . T #
+		# # # # # # # # # #
+	`)
+
+	random := rand.New(rand.NewSource(1))
+	actions := make([]Action, len(root.Tanks))
+	var s State
+	root.CopyTo(&s)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if i%20 == 0 {
+			root.CopyTo(&s)
+		}
+		for tank := range actions {
+			actions[tank] = Actions[random.Intn(len(Actions))]
+		}
+		s.Step(actions)
+	}
+}
@@ -0,0 +1,222 @@
+package game_events
+
+import (
+	"hackarena2-0-mono-tanks-go/packet/packets/game_state"
+)
+
+// Diff compares two consecutive game states and returns the events that
+// happened between them. Only what is visible in the game states can be
+// compared, so tanks, bullets and mines entering or leaving our visibility
+// are reported the same way as ones appearing or disappearing on the map.
+//
+// Events are returned grouped by kind in a stable order: players, tanks,
+// bullets, items, mines and zones.
+func Diff(previous, current *game_state.GameState) []Event {
+	var events []Event
+
+	events = diffPlayers(events, previous, current)
+	events = diffTanks(events, previous, current)
+	events = diffBullets(events, previous, current)
+	events = diffItems(events, previous, current)
+	events = diffMines(events, previous, current)
+	events = diffZones(events, previous, current)
+
+	return events
+}
+
+func diffPlayers(events []Event, previous, current *game_state.GameState) []Event {
+	previousPlayers := make(map[string]game_state.Player, len(previous.Players))
+	for _, player := range previous.Players {
+		previousPlayers[player.ID] = player
+	}
+
+	for _, player := range current.Players {
+		before, ok := previousPlayers[player.ID]
+		if !ok {
+			continue
+		}
+
+		if before.Score != nil && player.Score != nil && *before.Score != *player.Score {
+			events = append(events, ScoreChanged{
+				PlayerID: player.ID,
+				From:     *before.Score,
+				To:       *player.Score,
+			})
+		}
+
+		wasDead := before.TicksToRegen != nil
+		isDead := player.TicksToRegen != nil
+
+		if !wasDead && isDead {
+			x, y := -1, -1
+			if tank := previous.Tank(player.ID); tank != nil {
+				x, y = tank.X, tank.Y
+			}
+			events = append(events, TankDestroyed{OwnerID: player.ID, X: x, Y: y})
+		}
+
+		if wasDead && !isDead {
+			x, y := -1, -1
+			if tank := current.Tank(player.ID); tank != nil {
+				x, y = tank.X, tank.Y
+			}
+			events = append(events, PlayerRespawned{PlayerID: player.ID, X: x, Y: y})
+		}
+	}
+
+	return events
+}
+
+func diffTanks(events []Event, previous, current *game_state.GameState) []Event {
+	for _, tank := range current.Tanks {
+		before := previous.Tank(tank.OwnerID)
+		if before == nil {
+			continue
+		}
+
+		// A tank that respawned did not move there
+		if player := previous.Player(tank.OwnerID); player != nil && player.TicksToRegen != nil {
+			continue
+		}
+
+		if before.X != tank.X || before.Y != tank.Y {
+			events = append(events, TankMoved{
+				OwnerID: tank.OwnerID,
+				FromX:   before.X,
+				FromY:   before.Y,
+				ToX:     tank.X,
+				ToY:     tank.Y,
+			})
+		}
+
+		if before.Direction != tank.Direction || before.Turret.Direction != tank.Turret.Direction {
+			events = append(events, TankRotated{
+				OwnerID:             tank.OwnerID,
+				FromDirection:       before.Direction,
+				ToDirection:         tank.Direction,
+				FromTurretDirection: before.Turret.Direction,
+				ToTurretDirection:   tank.Turret.Direction,
+			})
+		}
+	}
+
+	return events
+}
+
+func diffBullets(events []Event, previous, current *game_state.GameState) []Event {
+	previousBullets := make(map[int]bool, len(previous.Bullets))
+	for _, bullet := range previous.Bullets {
+		previousBullets[bullet.ID] = true
+	}
+
+	currentBullets := make(map[int]bool, len(current.Bullets))
+	for _, bullet := range current.Bullets {
+		currentBullets[bullet.ID] = true
+		if !previousBullets[bullet.ID] {
+			events = append(events, BulletFired{
+				BulletID:   bullet.ID,
+				X:          bullet.X,
+				Y:          bullet.Y,
+				Direction:  bullet.Direction,
+				BulletType: bullet.Type,
+			})
+		}
+	}
+
+	for _, bullet := range previous.Bullets {
+		if !currentBullets[bullet.ID] {
+			events = append(events, BulletGone{BulletID: bullet.ID, X: bullet.X, Y: bullet.Y})
+		}
+	}
+
+	return events
+}
+
+func diffItems(events []Event, previous, current *game_state.GameState) []Event {
+	for _, item := range previous.Items {
+		if findItem(current, item.X, item.Y) != nil {
+			continue
+		}
+
+		for _, tank := range current.Tanks {
+			if tank.X == item.X && tank.Y == item.Y {
+				events = append(events, ItemPickedUp{
+					PlayerID: tank.OwnerID,
+					X:        item.X,
+					Y:        item.Y,
+					ItemType: item.Type,
+				})
+				break
+			}
+		}
+	}
+
+	return events
+}
+
+func diffMines(events []Event, previous, current *game_state.GameState) []Event {
+	previousMines := make(map[int]bool, len(previous.Mines))
+	for _, mine := range previous.Mines {
+		previousMines[mine.ID] = true
+	}
+
+	for _, mine := range current.Mines {
+		if !previousMines[mine.ID] {
+			events = append(events, MinePlaced{MineID: mine.ID, X: mine.X, Y: mine.Y})
+		}
+	}
+
+	return events
+}
+
+func diffZones(events []Event, previous, current *game_state.GameState) []Event {
+	for _, zone := range current.Zones {
+		if zone.Status.Captured == nil {
+			continue
+		}
+
+		var previousOwnerID string
+		for _, before := range previous.Zones {
+			if before.Index == zone.Index {
+				previousOwnerID = zoneOwner(before.Status)
+				break
+			}
+		}
+
+		if previousOwnerID == zone.Status.Captured.PlayerID {
+			continue
+		}
+
+		events = append(events, ZoneCaptured{
+			ZoneIndex:       zone.Index,
+			PlayerID:        zone.Status.Captured.PlayerID,
+			PreviousOwnerID: previousOwnerID,
+		})
+	}
+
+	return events
+}
+
+// zoneOwner returns the ID of the player owning the zone, even while it is
+// contested or being retaken, or an empty string if the zone is not owned.
+func zoneOwner(status game_state.ZoneStatus) string {
+	switch {
+	case status.Captured != nil:
+		return status.Captured.PlayerID
+	case status.BeingContested != nil && status.BeingContested.CapturedByID != nil:
+		return *status.BeingContested.CapturedByID
+	case status.BeingRetaken != nil:
+		return status.BeingRetaken.CapturedByID
+	default:
+		return ""
+	}
+}
+
+func findItem(gameState *game_state.GameState, x, y int) *game_state.Item {
+	for i := range gameState.Items {
+		if gameState.Items[i].X == x && gameState.Items[i].Y == y {
+			return &gameState.Items[i]
+		}
+	}
+	return nil
+}
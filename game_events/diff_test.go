@@ -0,0 +1,198 @@
+package game_events
+
+import (
+	"hackarena2-0-mono-tanks-go/packet/packets/game_state"
+	"hackarena2-0-mono-tanks-go/packet/packets/game_state/direction"
+	"hackarena2-0-mono-tanks-go/scenario"
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous string
+		current  string
+		adjust   func(previous, current *game_state.GameState)
+		expected []Event
+	}{
+		{
+			name: "No Changes",
+			previous: `
+				^ . .
+				. . T
+			`,
+			current: `
+				^ . .
+				. . T
+			`,
+			expected: nil,
+		},
+		{
+			name: "Tank Moved And Rotated",
+			previous: `
+				. . .
+				^ . T
+			`,
+			current: `
+				^ . .
+				. T .
+			`,
+			adjust: func(previous, current *game_state.GameState) {
+				current.Tanks[1].Direction = direction.Left
+			},
+			expected: []Event{
+				TankMoved{OwnerID: scenario.MyID, FromX: 0, FromY: 1, ToX: 0, ToY: 0},
+				TankMoved{OwnerID: "enemy-1", FromX: 2, FromY: 1, ToX: 1, ToY: 1},
+				TankRotated{OwnerID: "enemy-1", FromDirection: direction.Up, ToDirection: direction.Left, FromTurretDirection: direction.Up, ToTurretDirection: direction.Up},
+			},
+		},
+		{
+			name: "Bullets",
+			previous: `
+				^ . ↓
+				. . .
+			`,
+			current: `
+				^ → .
+				. . .
+			`,
+			adjust: func(previous, current *game_state.GameState) {
+				current.Bullets[0].ID = 7
+			},
+			expected: []Event{
+				BulletFired{BulletID: 7, X: 1, Y: 0, Direction: direction.Right, BulletType: "basic"},
+				BulletGone{BulletID: 1, X: 2, Y: 0},
+			},
+		},
+		{
+			name: "Item Picked Up",
+			previous: `
+				^ . .
+				R . L
+			`,
+			current: `
+				. . .
+				^ . ~
+			`,
+			expected: []Event{
+				TankMoved{OwnerID: scenario.MyID, FromX: 0, FromY: 0, ToX: 0, ToY: 1},
+				ItemPickedUp{PlayerID: scenario.MyID, X: 0, Y: 1, ItemType: "radar"},
+			},
+		},
+		{
+			name: "Mine Placed",
+			previous: `
+				^ . .
+			`,
+			current: `
+				X ^ .
+			`,
+			expected: []Event{
+				TankMoved{OwnerID: scenario.MyID, FromX: 0, FromY: 0, ToX: 1, ToY: 0},
+				MinePlaced{MineID: 1, X: 0, Y: 0},
+			},
+		},
+		{
+			name: "Destroyed And Respawned",
+			previous: `
+				^ . T
+			`,
+			current: `
+				. . .
+			`,
+			adjust: func(previous, current *game_state.GameState) {
+				current.Players = append(current.Players, game_state.Player{ID: "enemy-1"})
+				current.Players[0].TicksToRegen = uint64Ptr(10)
+				current.Players[1].TicksToRegen = uint64Ptr(10)
+			},
+			expected: []Event{
+				TankDestroyed{OwnerID: scenario.MyID, X: 0, Y: 0},
+				TankDestroyed{OwnerID: "enemy-1", X: 2, Y: 0},
+			},
+		},
+		{
+			name: "Respawned",
+			previous: `
+				. . .
+			`,
+			current: `
+				. . ^
+			`,
+			adjust: func(previous, current *game_state.GameState) {
+				previous.Players[0].TicksToRegen = uint64Ptr(1)
+			},
+			expected: []Event{
+				PlayerRespawned{PlayerID: scenario.MyID, X: 2, Y: 0},
+			},
+		},
+		{
+			name: "Zone Captured And Score Changed",
+			previous: `
+				^ a
+				. a
+			`,
+			current: `
+				. a
+				^ a
+			`,
+			adjust: func(previous, current *game_state.GameState) {
+				previous.Zones[0].Status = game_state.ZoneStatus{
+					Type:          "beingCaptured",
+					BeingCaptured: &game_state.BeingCapturedStatus{RemainingTicks: 1, PlayerID: scenario.MyID},
+				}
+				current.Zones[0].Status = game_state.ZoneStatus{
+					Type:     "captured",
+					Captured: &game_state.CapturedStatus{PlayerID: scenario.MyID},
+				}
+				current.Players[0].Score = uint64Ptr(5)
+			},
+			expected: []Event{
+				ScoreChanged{PlayerID: scenario.MyID, From: 0, To: 5},
+				TankMoved{OwnerID: scenario.MyID, FromX: 0, FromY: 0, ToX: 0, ToY: 1},
+				ZoneCaptured{ZoneIndex: 'A', PlayerID: scenario.MyID},
+			},
+		},
+		{
+			name: "Zone Retaken",
+			previous: `
+				a
+			`,
+			current: `
+				a
+			`,
+			adjust: func(previous, current *game_state.GameState) {
+				previous.Zones[0].Status = game_state.ZoneStatus{
+					Type:         "beingRetaken",
+					BeingRetaken: &game_state.BeingRetakenStatus{RemainingTicks: 1, CapturedByID: "enemy-1", RetakenByID: scenario.MyID},
+				}
+				current.Zones[0].Status = game_state.ZoneStatus{
+					Type:     "captured",
+					Captured: &game_state.CapturedStatus{PlayerID: scenario.MyID},
+				}
+			},
+			expected: []Event{
+				ZoneCaptured{ZoneIndex: 'A', PlayerID: scenario.MyID, PreviousOwnerID: "enemy-1"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := scenario.MustParse(tt.previous).GameState
+			current := scenario.MustParse(tt.current).GameState
+			if tt.adjust != nil {
+				tt.adjust(previous, current)
+			}
+
+			events := Diff(previous, current)
+			if !reflect.DeepEqual(events, tt.expected) {
+				t.Errorf("expected events:\n%#v\ngot:\n%#v", tt.expected, events)
+			}
+		})
+	}
+}
+
+func uint64Ptr(i uint64) *uint64 {
+	return &i
+}
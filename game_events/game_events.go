@@ -0,0 +1,145 @@
+package game_events
+
+// EventType is an enumeration of the events extracted from consecutive game states.
+type EventType string
+
+const (
+	TankMovedEvent       EventType = "tankMoved"
+	TankRotatedEvent     EventType = "tankRotated"
+	TankDestroyedEvent   EventType = "tankDestroyed"
+	BulletFiredEvent     EventType = "bulletFired"
+	BulletGoneEvent      EventType = "bulletGone"
+	ItemPickedUpEvent    EventType = "itemPickedUp"
+	MinePlacedEvent      EventType = "minePlaced"
+	ZoneCapturedEvent    EventType = "zoneCaptured"
+	ScoreChangedEvent    EventType = "scoreChanged"
+	PlayerRespawnedEvent EventType = "playerRespawned"
+)
+
+// Event is something that happened between two consecutive game states.
+// Use a type switch on the concrete event types to read the details.
+type Event interface {
+	// Type returns the kind of the event.
+	Type() EventType
+}
+
+// TankMoved is emitted when a tank visible in both game states changed its position.
+type TankMoved struct {
+	// The ID of the player who owns the tank.
+	OwnerID string
+
+	// The previous coordinates of the tank.
+	FromX, FromY int
+
+	// The current coordinates of the tank.
+	ToX, ToY int
+}
+
+// TankRotated is emitted when a tank visible in both game states rotated its body or turret.
+type TankRotated struct {
+	// The ID of the player who owns the tank.
+	OwnerID string
+
+	// The previous and current direction of the tank body.
+	FromDirection, ToDirection string
+
+	// The previous and current direction of the turret.
+	FromTurretDirection, ToTurretDirection string
+}
+
+// TankDestroyed is emitted when a player starts waiting for a respawn.
+type TankDestroyed struct {
+	// The ID of the player who owns the tank.
+	OwnerID string
+
+	// The last coordinates of the tank, or -1 if it was not visible in the previous game state.
+	X, Y int
+}
+
+// PlayerRespawned is emitted when a player stops waiting for a respawn.
+type PlayerRespawned struct {
+	// The ID of the respawned player.
+	PlayerID string
+
+	// The coordinates of the respawned tank, or -1 if it is not visible.
+	X, Y int
+}
+
+// BulletFired is emitted when a bullet is seen for the first time. A bullet
+// coming out of the fog is reported the same way as a freshly fired one.
+type BulletFired struct {
+	// The unique identifier for the bullet.
+	BulletID int
+
+	// The coordinates of the bullet.
+	X, Y int
+
+	// The direction the bullet is traveling. "up", "right", "down", or "left".
+	Direction string
+
+	// The type of the bullet. Can be "basic" or "double".
+	BulletType string
+}
+
+// BulletGone is emitted when a bullet from the previous game state is no longer present,
+// because it hit something or left our visibility.
+type BulletGone struct {
+	// The unique identifier for the bullet.
+	BulletID int
+
+	// The last coordinates of the bullet.
+	X, Y int
+}
+
+// ItemPickedUp is emitted when an item disappears from a tile now occupied by a tank.
+type ItemPickedUp struct {
+	// The ID of the player whose tank picked up the item.
+	PlayerID string
+
+	// The coordinates of the item.
+	X, Y int
+
+	// The type of the item. Can be "unknown", "doubleBullet", "laser", "radar", or "mine".
+	ItemType string
+}
+
+// MinePlaced is emitted when a mine is seen for the first time.
+type MinePlaced struct {
+	// The unique identifier for the mine.
+	MineID int
+
+	// The coordinates of the mine.
+	X, Y int
+}
+
+// ZoneCaptured is emitted when a zone becomes captured by a player.
+type ZoneCaptured struct {
+	// The index of the zone.
+	ZoneIndex uint8
+
+	// The ID of the player who captured the zone.
+	PlayerID string
+
+	// The ID of the player who owned the zone before, empty if it was neutral.
+	PreviousOwnerID string
+}
+
+// ScoreChanged is emitted when a player's score changes.
+type ScoreChanged struct {
+	// The ID of the player.
+	PlayerID string
+
+	// The previous and current score of the player.
+	From, To uint64
+}
+
+func (TankMoved) Type() EventType       { return TankMovedEvent }
+func (TankRotated) Type() EventType     { return TankRotatedEvent }
+func (TankDestroyed) Type() EventType   { return TankDestroyedEvent }
+func (PlayerRespawned) Type() EventType { return PlayerRespawnedEvent }
+func (BulletFired) Type() EventType     { return BulletFiredEvent }
+func (BulletGone) Type() EventType      { return BulletGoneEvent }
+func (ItemPickedUp) Type() EventType    { return ItemPickedUpEvent }
+func (MinePlaced) Type() EventType      { return MinePlacedEvent }
+func (ZoneCaptured) Type() EventType    { return ZoneCapturedEvent }
+func (ScoreChanged) Type() EventType    { return ScoreChangedEvent }
@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"fmt"
+	"hackarena2-0-mono-tanks-go/bot"
+	"hackarena2-0-mono-tanks-go/ws_client/outbound_queue"
+)
+
+func HandleActionFailures(botInstance *bot.Bot, failures []outbound_queue.Failure) error {
+	if botInstance == nil {
+		return fmt.Errorf("bot not initialized")
+	}
+
+	for _, failure := range failures {
+		botInstance.OnActionFailed(failure.GameStateID, failure.Err)
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"fmt"
+	"hackarena2-0-mono-tanks-go/bot"
+	"hackarena2-0-mono-tanks-go/game_events"
+	"hackarena2-0-mono-tanks-go/packet/packets/game_state"
+)
+
+func HandleGameEvents(botInstance *bot.Bot, previous *game_state.GameState, current *game_state.GameState) error {
+	if botInstance == nil {
+		return fmt.Errorf("bot not initialized")
+	}
+
+	// Nothing to compare against on the first tick or after a late, out of order game state
+	if previous == nil || previous.Tick >= current.Tick {
+		return nil
+	}
+
+	botInstance.OnGameEvents(game_events.Diff(previous, current))
+	return nil
+}
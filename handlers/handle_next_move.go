@@ -1,13 +1,14 @@
 package handlers
 
 import (
-	"encoding/json"
 	"fmt"
+	"hackarena2-0-mono-tanks-go/action_validator"
 	"hackarena2-0-mono-tanks-go/bot"
+	"hackarena2-0-mono-tanks-go/packet/packets/bot_response"
 	"hackarena2-0-mono-tanks-go/packet/packets/game_state"
 )
 
-func HandleNextMove(tx chan []byte, botInstance *bot.Bot, gameState game_state.GameState) error {
+func HandleNextMove(sender Sender, botInstance *bot.Bot, gameState game_state.GameState, validation action_validator.Mode) error {
 	gameStateID := gameState.ID
 
 	if botInstance == nil {
@@ -16,18 +17,29 @@ func HandleNextMove(tx chan []byte, botInstance *bot.Bot, gameState game_state.G
 
 	botResponse := botInstance.NextMove(&gameState)
 
+	// Check the response before the server silently ignores it
+	if validation != "" && validation != action_validator.Off {
+		if err := action_validator.Validate(*botResponse, &gameState, botInstance.MyID); err != nil {
+			switch validation {
+			case action_validator.Warn:
+				fmt.Printf("[System] ⚠️ Invalid action for game state %s -> %v\n", gameStateID, err)
+			case action_validator.Correct:
+				fmt.Printf("[System] ⚠️ Invalid action for game state %s, passing instead -> %v\n", gameStateID, err)
+				botResponse = bot_response.NewPass()
+			case action_validator.Reject:
+				fmt.Printf("[System] ⚠️ Invalid action for game state %s not sent -> %v\n", gameStateID, err)
+				botInstance.OnActionFailed(gameStateID, err)
+				return nil
+			}
+		}
+	}
+
 	// Convert bot response to packet
 	responsePacket := botResponse.ToPacket(gameStateID)
-	responseString, err := json.Marshal(responsePacket)
-	if err != nil {
-		return fmt.Errorf("failed to serialize response packet: %v", err)
-	}
 
 	// Send the response
-	select {
-	case tx <- responseString:
-		return nil
-	default:
-		return fmt.Errorf("failed to send message")
+	if err := sender.Send(responsePacket); err != nil {
+		return fmt.Errorf("failed to send response packet: %w", err)
 	}
+	return nil
 }
@@ -1,19 +1,19 @@
 package handlers
 
 import (
-	"encoding/json"
 	"fmt"
 	"hackarena2-0-mono-tanks-go/bot"
 	"hackarena2-0-mono-tanks-go/packet"
 	"hackarena2-0-mono-tanks-go/packet/packets/lobby_data"
 )
 
-func HandlePrepareToGame(tx chan []byte, botInstance **bot.Bot, lobbyData *lobby_data.LobbyData) error {
+func HandlePrepareToGame(sender Sender, botInstance **bot.Bot, lobbyData *lobby_data.LobbyData, options bot.Options) error {
 	if *botInstance != nil {
 		(*botInstance).OnLobbyDataChanged(lobbyData)
 	} else {
 		fmt.Println("[System] 🤖 Creating bot")
 		*botInstance = bot.OnJoiningLobby(lobbyData)
+		(*botInstance).Configure(options)
 		fmt.Println("[System] 🤖 Created bot")
 
 		if lobbyData.ServerSettings.SandboxMode {
@@ -23,22 +23,18 @@ func HandlePrepareToGame(tx chan []byte, botInstance **bot.Bot, lobbyData *lobby
 				Type:    packet.ReadyToReceiveGameState,
 				Payload: nil,
 			}
-			readyToReceiveGameStateBytes, err := json.Marshal(readyToReceiveGameState)
-			if err != nil {
-				return fmt.Errorf("error marshalling ReadyToReceiveGameState: %w", err)
+			if err := sender.Send(readyToReceiveGameState); err != nil {
+				return fmt.Errorf("error sending ReadyToReceiveGameState: %w", err)
 			}
-			tx <- readyToReceiveGameStateBytes
 			fmt.Println("[System] 🎳 Ready to receive game state sent")
 
 			gameStatusRequest := packet.Packet{
 				Type:    packet.GameStatusRequest,
 				Payload: nil,
 			}
-			gameStatusRequestBytes, err := json.Marshal(gameStatusRequest)
-			if err != nil {
-				return fmt.Errorf("error marshalling GameStatusRequest: %w", err)
+			if err := sender.Send(gameStatusRequest); err != nil {
+				return fmt.Errorf("error sending GameStatusRequest: %w", err)
 			}
-			tx <- gameStatusRequestBytes
 		}
 	}
 
@@ -0,0 +1,16 @@
+package handlers
+
+import (
+	"fmt"
+	"hackarena2-0-mono-tanks-go/bot"
+	"hackarena2-0-mono-tanks-go/timing"
+)
+
+func HandleTiming(botInstance *bot.Bot, tickTiming timing.Timing) error {
+	if botInstance == nil {
+		return fmt.Errorf("bot not initialized")
+	}
+
+	botInstance.OnTickTiming(tickTiming)
+	return nil
+}
@@ -0,0 +1,8 @@
+package handlers
+
+import "hackarena2-0-mono-tanks-go/packet"
+
+// Sender queues packets to be sent to the server.
+type Sender interface {
+	Send(p packet.Packet) error
+}
@@ -0,0 +1,217 @@
+// Package item_planner chooses which item the bot should drive to next.
+//
+// Items are only sent for tiles the bot can see, and far away ones only as
+// "unknown". The Planner remembers the items it has seen, so it can still
+// send the bot to an item that went out of sight, and forgets them once
+// their tile is visible and empty again.
+package item_planner
+
+import (
+	"sort"
+
+	"hackarena2-0-mono-tanks-go/packet/packets/game_state"
+	"hackarena2-0-mono-tanks-go/pathing"
+)
+
+// UnknownItem is the type of items too far away to be recognized.
+const UnknownItem = "unknown"
+
+// Options weigh the parts of an item's score.
+type Options struct {
+	// Values are the worth of each item type, by the type names of game_state.Item.
+	Values map[string]float64
+
+	// TickCost is subtracted from the score for every tick needed to reach the item.
+	TickCost float64
+
+	// ContestWindow is the difference in ticks between our arrival and an
+	// enemy's over which the chance of losing the item to the enemy goes from
+	// certain to none. It is even when both arrive on the same tick.
+	ContestWindow int
+
+	// ForgetAfter is the number of ticks after which an item out of sight is forgotten.
+	ForgetAfter uint64
+}
+
+// DefaultOptions favour the items that help to destroy other tanks.
+var DefaultOptions = Options{
+	Values: map[string]float64{
+		"laser":        10,
+		"doubleBullet": 8,
+		"mine":         5,
+		"radar":        4,
+		UnknownItem:    5,
+	},
+	TickCost:      0.5,
+	ContestWindow: 3,
+	ForgetAfter:   200,
+}
+
+// Candidate is an item the bot could drive to.
+type Candidate struct {
+	// Item is the item as last seen.
+	Item game_state.Item
+
+	// Remembered is true when the item is out of sight.
+	Remembered bool
+
+	// LastSeen is the tick the item was last seen on.
+	LastSeen uint64
+
+	// Value is the worth of the item given the item our tank carries.
+	Value float64
+
+	// ETA is the number of ticks our tank needs to reach the item.
+	ETA int
+
+	// EnemyETA is the number of ticks the nearest visible enemy tank needs to
+	// reach the item, or -1 if no visible enemy can.
+	EnemyETA int
+
+	// ContestChance is the estimated chance of an enemy taking the item first, from 0 to 1.
+	ContestChance float64
+
+	// Score ranks the candidates, higher is better.
+	Score float64
+}
+
+type rememberedItem struct {
+	item     game_state.Item
+	lastSeen uint64
+}
+
+// Planner ranks items across ticks. Call Observe with every game state
+// before asking for a plan.
+type Planner struct {
+	options Options
+	items   map[pathing.Point]rememberedItem
+	tick    uint64
+}
+
+func NewPlanner(options Options) *Planner {
+	return &Planner{
+		options: options,
+		items:   make(map[pathing.Point]rememberedItem),
+	}
+}
+
+// Observe updates the remembered items with a game state.
+func (p *Planner) Observe(gameState *game_state.GameState) {
+	p.tick = gameState.Tick
+
+	seen := make(map[pathing.Point]bool)
+	for _, item := range gameState.Items {
+		point := pathing.Point{X: item.X, Y: item.Y}
+		seen[point] = true
+
+		// An item seen up close is not replaced by its unknown far view
+		if known, ok := p.items[point]; ok && item.Type == UnknownItem && known.item.Type != UnknownItem {
+			item.Type = known.item.Type
+		}
+		p.items[point] = rememberedItem{item: item, lastSeen: gameState.Tick}
+	}
+
+	for point, remembered := range p.items {
+		if seen[point] {
+			continue
+		}
+		if visible(gameState, point.X, point.Y) || gameState.Tick-remembered.lastSeen > p.options.ForgetAfter {
+			delete(p.items, point)
+		}
+	}
+}
+
+// Rank returns the items our tank can reach and is better off carrying,
+// best first. It is empty while our tank is destroyed.
+func (p *Planner) Rank(gameState *game_state.GameState, playerID string) []Candidate {
+	myTank := gameState.Tank(playerID)
+	if myTank == nil {
+		return nil
+	}
+
+	// A tank holds a single item, so an item is only worth the gain over the carried one
+	carried := 0.0
+	if myTank.SecondaryItem != nil {
+		carried = p.options.Values[*myTank.SecondaryItem]
+	}
+
+	search := pathing.NewMap(gameState, playerID).Search(pathing.PoseOf(myTank))
+	var enemySearches []*pathing.Search
+	for i := range gameState.Tanks {
+		if tank := &gameState.Tanks[i]; tank.OwnerID != playerID {
+			enemySearches = append(enemySearches, pathing.NewMap(gameState, tank.OwnerID).Search(pathing.PoseOf(tank)))
+		}
+	}
+
+	var candidates []Candidate
+	for point, remembered := range p.items {
+		value := p.options.Values[remembered.item.Type] - carried
+		if value <= 0 {
+			continue
+		}
+
+		eta, ok := search.Ticks(point.X, point.Y)
+		if !ok {
+			continue
+		}
+
+		candidate := Candidate{
+			Item:       remembered.item,
+			Remembered: remembered.lastSeen != p.tick,
+			LastSeen:   remembered.lastSeen,
+			Value:      value,
+			ETA:        eta,
+			EnemyETA:   -1,
+		}
+		for _, enemySearch := range enemySearches {
+			if ticks, ok := enemySearch.Ticks(point.X, point.Y); ok && (candidate.EnemyETA < 0 || ticks < candidate.EnemyETA) {
+				candidate.EnemyETA = ticks
+			}
+		}
+		if candidate.EnemyETA >= 0 {
+			candidate.ContestChance = p.contestChance(eta, candidate.EnemyETA)
+		}
+
+		candidate.Score = value*(1-candidate.ContestChance) - p.options.TickCost*float64(eta)
+		candidates = append(candidates, candidate)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		// Keep the order stable across ticks
+		if candidates[i].Item.Y != candidates[j].Item.Y {
+			return candidates[i].Item.Y < candidates[j].Item.Y
+		}
+		return candidates[i].Item.X < candidates[j].Item.X
+	})
+	return candidates
+}
+
+// Plan returns the best item to drive to, if any is worth it.
+func (p *Planner) Plan(gameState *game_state.GameState, playerID string) (Candidate, bool) {
+	candidates := p.Rank(gameState, playerID)
+	if len(candidates) == 0 || candidates[0].Score <= 0 {
+		return Candidate{}, false
+	}
+	return candidates[0], true
+}
+
+// contestChance grows linearly from 0 when we arrive ContestWindow ticks
+// before the enemy to 1 when we arrive ContestWindow ticks after it.
+func (p *Planner) contestChance(eta int, enemyETA int) float64 {
+	if p.options.ContestWindow <= 0 {
+		if enemyETA <= eta {
+			return 1
+		}
+		return 0
+	}
+
+	chance := 0.5 + float64(eta-enemyETA)/float64(2*p.options.ContestWindow)
+	return min(max(chance, 0), 1)
+}
+
+func visible(gameState *game_state.GameState, x, y int) bool {
+	return y >= 0 && y < len(gameState.Visibility) && x >= 0 && x < len(gameState.Visibility[y]) && gameState.Visibility[y][x]
+}
@@ -0,0 +1,127 @@
+package item_planner
+
+import (
+	"hackarena2-0-mono-tanks-go/scenario"
+	"testing"
+)
+
+func TestRankPrefersCloseUncontestedItems(t *testing.T) {
+	s := scenario.MustParse(`
+		# # # # # # # #
+		# > . D . . L #
+		# . # # # # . #
+		# M . . . . T #
+		# # # # # # # #
+	`)
+
+	planner := NewPlanner(DefaultOptions)
+	planner.Observe(s.GameState)
+	candidates := planner.Rank(s.GameState, scenario.MyID)
+	if len(candidates) != 3 {
+		t.Fatalf("Expected 3 candidates, got %d", len(candidates))
+	}
+
+	// The laser is worth more, but the enemy is next to it
+	best := candidates[0]
+	if best.Item.Type != "doubleBullet" || best.ETA != 2 || best.ContestChance != 0 {
+		t.Errorf("Expected the uncontested double bullet in 2 ticks first, got %+v", best)
+	}
+
+	for _, candidate := range candidates {
+		if candidate.Item.Type == "laser" && (candidate.EnemyETA != 2 || candidate.ContestChance != 1) {
+			t.Errorf("Expected the laser to be lost to the enemy, got %+v", candidate)
+		}
+	}
+
+	target, ok := planner.Plan(s.GameState, scenario.MyID)
+	if !ok || target.Item != best.Item {
+		t.Errorf("Expected the plan to target the best candidate, got %+v, %v", target, ok)
+	}
+}
+
+func TestRankAccountsForTheCarriedItem(t *testing.T) {
+	s := scenario.MustParse(`
+		# # # # # #
+		# > R . L #
+		# # # # # #
+	`)
+	laser := "laser"
+	s.MyTank().SecondaryItem = &laser
+
+	planner := NewPlanner(DefaultOptions)
+	planner.Observe(s.GameState)
+	if candidates := planner.Rank(s.GameState, scenario.MyID); len(candidates) != 0 {
+		t.Errorf("Expected no item to beat the carried laser, got %+v", candidates)
+	}
+	if _, ok := planner.Plan(s.GameState, scenario.MyID); ok {
+		t.Errorf("Expected no plan")
+	}
+}
+
+func TestObserveRemembersItems(t *testing.T) {
+	s := scenario.MustParse(`
+		# # # # # #
+		# > . . L #
+		# # # # # #
+	`)
+
+	planner := NewPlanner(Options{Values: DefaultOptions.Values, ForgetAfter: 10})
+	planner.Observe(s.GameState)
+
+	// The laser goes out of sight
+	s.GameState.Tick = 5
+	s.GameState.Items = nil
+	s.GameState.Visibility[1][4] = false
+	planner.Observe(s.GameState)
+
+	candidates := planner.Rank(s.GameState, scenario.MyID)
+	if len(candidates) != 1 || !candidates[0].Remembered || candidates[0].LastSeen != 0 {
+		t.Fatalf("Expected the remembered laser, got %+v", candidates)
+	}
+
+	// Far away, the laser is only seen as unknown
+	s.GameState.Tick = 6
+	s.GameState.Items = append(s.GameState.Items, candidates[0].Item)
+	s.GameState.Items[0].Type = UnknownItem
+	planner.Observe(s.GameState)
+	if candidates := planner.Rank(s.GameState, scenario.MyID); len(candidates) != 1 || candidates[0].Item.Type != "laser" || candidates[0].Remembered {
+		t.Errorf("Expected the laser to keep its type, got %+v", candidates)
+	}
+
+	s.GameState.Tick = 20
+	s.GameState.Items = nil
+	planner.Observe(s.GameState)
+	if candidates := planner.Rank(s.GameState, scenario.MyID); len(candidates) != 0 {
+		t.Errorf("Expected the laser to be forgotten, got %+v", candidates)
+	}
+
+	// Seen again and then picked up
+	s.GameState.Items = append(s.GameState.Items, candidates[0].Item)
+	planner.Observe(s.GameState)
+	s.GameState.Visibility[1][4] = true
+	s.GameState.Items = nil
+	planner.Observe(s.GameState)
+	if candidates := planner.Rank(s.GameState, scenario.MyID); len(candidates) != 0 {
+		t.Errorf("Expected the picked up laser to be forgotten, got %+v", candidates)
+	}
+}
+
+func TestContestChance(t *testing.T) {
+	planner := NewPlanner(Options{ContestWindow: 2})
+
+	tests := []struct {
+		eta, enemyETA int
+		chance        float64
+	}{
+		{1, 5, 0},
+		{3, 4, 0.25},
+		{4, 4, 0.5},
+		{5, 4, 0.75},
+		{9, 4, 1},
+	}
+	for _, test := range tests {
+		if chance := planner.contestChance(test.eta, test.enemyETA); chance != test.chance {
+			t.Errorf("Expected chance %v for %d against %d ticks, got %v", test.chance, test.eta, test.enemyETA, chance)
+		}
+	}
+}
@@ -2,13 +2,18 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
+	"hackarena2-0-mono-tanks-go/action_validator"
 	"hackarena2-0-mono-tanks-go/args"
+	"hackarena2-0-mono-tanks-go/bot"
 	"hackarena2-0-mono-tanks-go/ws_client"
 )
 
@@ -25,20 +30,54 @@ func main() {
 		log.Fatal("[System] 🌋 Error: Failed to retrieve parsed arguments")
 	}
 
-	fmt.Println("[System] 🚀 Starting bot...")
-	if err := startWebSocketClient(parsedArgs); err != nil {
+	role := "Bot"
+	if parsedArgs.Spectate {
+		role = "Spectator"
+	}
+
+	fmt.Printf("[System] 🚀 Starting %s...\n", strings.ToLower(role))
+	err = startWebSocketClient(parsedArgs)
+	if err != nil {
 		log.Printf("[System] 🌋 Error: %v", err)
 	}
-	fmt.Println("[System] 🏁 Bot stopped")
+	fmt.Printf("[System] 🏁 %s stopped\n", role)
+	os.Exit(exitCode(err))
 }
 
-func startWebSocketClient(parsedArgs *args.Args) error {
-	websocketClient := ws_client.NewWebSocketClient()
-	err := websocketClient.Connect(parsedArgs.Host, int(parsedArgs.Port), parsedArgs.Code, parsedArgs.Nickname)
-	if err != nil {
-		return fmt.Errorf("connecting to the server: %w", err)
+// Exit codes let scripts running the bot tell the reasons it stopped apart.
+const (
+	exitOK              = 0
+	exitError           = 1
+	exitRejected        = 3
+	exitNicknameTaken   = 4
+	exitInvalidJoinCode = 5
+	exitGameInProgress  = 6
+	exitLobbyFull       = 7
+)
+
+func exitCode(err error) int {
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, ws_client.ErrNicknameTaken):
+		return exitNicknameTaken
+	case errors.Is(err, ws_client.ErrInvalidJoinCode):
+		return exitInvalidJoinCode
+	case errors.Is(err, ws_client.ErrGameInProgress):
+		return exitGameInProgress
+	case errors.Is(err, ws_client.ErrLobbyFull):
+		return exitLobbyFull
+	case errors.Is(err, ws_client.ErrConnectionRejected):
+		return exitRejected
+	default:
+		return exitError
 	}
+}
+
+// maxNicknameRetries bounds the reconnections with a suffixed nickname in --nickname-suffix auto mode.
+const maxNicknameRetries = 5
 
+func startWebSocketClient(parsedArgs *args.Args) error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -51,6 +90,64 @@ func startWebSocketClient(parsedArgs *args.Args) error {
 		cancel()
 	}()
 
+	var recording io.Writer
+	if parsedArgs.Record != "" {
+		file, err := os.Create(parsedArgs.Record)
+		if err != nil {
+			return fmt.Errorf("creating the recording: %w", err)
+		}
+		defer file.Close()
+		recording = file
+		fmt.Printf("[System] 📼 Recording the game to %s\n", parsedArgs.Record)
+	}
+
+	retries := 0
+	if parsedArgs.NicknameSuffix == args.NicknameSuffixAuto {
+		retries = maxNicknameRetries
+	}
+
+	return retryNicknameCollisions(parsedArgs.Nickname, retries, func(nickname string) error {
+		return runWebSocketClient(ctx, parsedArgs, nickname, recording)
+	})
+}
+
+// retryNicknameCollisions calls run with the nickname, and again with a
+// numbered suffix up to retries times while the nickname is taken.
+func retryNicknameCollisions(nickname string, retries int, run func(nickname string) error) error {
+	candidate := nickname
+	for attempt := 1; ; attempt++ {
+		err := run(candidate)
+		if !errors.Is(err, ws_client.ErrNicknameTaken) || attempt > retries {
+			return err
+		}
+
+		next := fmt.Sprintf("%s-%d", nickname, attempt+1)
+		fmt.Printf("[System] 🏷️ Nickname %s is taken, retrying as %s\n", candidate, next)
+		candidate = next
+	}
+}
+
+func runWebSocketClient(ctx context.Context, parsedArgs *args.Args, nickname string, recording io.Writer) error {
+	websocketClient := ws_client.NewWebSocketClient(ws_client.Options{
+		GameEvents:       parsedArgs.Events,
+		ActionValidation: action_validator.Mode(parsedArgs.Validate),
+		Spectate:         parsedArgs.Spectate,
+		Recording:        recording,
+		Bot: bot.Options{
+			OpponentsPath: parsedArgs.Opponents,
+			Strategy:      parsedArgs.Strategy,
+		},
+		OnStateChange: func(from ws_client.State, to ws_client.State) {
+			if to == ws_client.Accepted && nickname != parsedArgs.Nickname {
+				fmt.Printf("[System] 🏷️ Joined as %s\n", nickname)
+			}
+		},
+	})
+	err := websocketClient.Connect(parsedArgs.Host, int(parsedArgs.Port), parsedArgs.Code, nickname)
+	if err != nil {
+		return fmt.Errorf("connecting to the server: %w", err)
+	}
+
 	if err := websocketClient.Run(ctx); err != nil {
 		return fmt.Errorf("running WebSocket client: %w", err)
 	}
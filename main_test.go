@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"hackarena2-0-mono-tanks-go/ws_client"
+	"reflect"
+	"testing"
+)
+
+// nicknameTaken is a rejection of a nickname that is already taken.
+var nicknameTaken = fmt.Errorf("running WebSocket client: %w", &ws_client.RejectionError{
+	Reason: "Nickname already exists",
+	Kind:   ws_client.ErrNicknameTaken,
+})
+
+func TestRetryNicknameCollisions(t *testing.T) {
+	var attempts []string
+	err := retryNicknameCollisions("GO1", 5, func(nickname string) error {
+		attempts = append(attempts, nickname)
+		if len(attempts) < 3 {
+			return nicknameTaken
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("Expected the third nickname to succeed, got %v", err)
+	}
+	if expected := []string{"GO1", "GO1-2", "GO1-3"}; !reflect.DeepEqual(attempts, expected) {
+		t.Errorf("Expected attempts %v, got %v", expected, attempts)
+	}
+}
+
+func TestRetryNicknameCollisionsGivesUp(t *testing.T) {
+	attempts := 0
+	err := retryNicknameCollisions("GO1", 2, func(nickname string) error {
+		attempts++
+		return nicknameTaken
+	})
+
+	if !errors.Is(err, ws_client.ErrNicknameTaken) {
+		t.Fatalf("Expected the last rejection, got %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("Expected the nickname and 2 retries, got %d attempts", attempts)
+	}
+	if code := exitCode(err); code != exitNicknameTaken {
+		t.Errorf("Expected exit code %d, got %d", exitNicknameTaken, code)
+	}
+}
+
+func TestRetryNicknameCollisionsOnlyRetriesTakenNicknames(t *testing.T) {
+	other := errors.New("connection refused")
+
+	attempts := 0
+	err := retryNicknameCollisions("GO1", 5, func(nickname string) error {
+		attempts++
+		return other
+	})
+
+	if err != other || attempts != 1 {
+		t.Errorf("Expected a single attempt returning %v, got %d attempts returning %v", other, attempts, err)
+	}
+}
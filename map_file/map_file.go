@@ -0,0 +1,478 @@
+// Package map_file reads hand-made maps from plain-text files, so bots can
+// practice on situations like corridor fights or zone defence instead of
+// the maps generated from the server's seed.
+//
+// A map file starts with header lines of the form "key: value", followed by
+// the grid. Lines starting with "//" are comments and blank lines are
+// skipped. Spaces and tabs between cells are ignored, like in the scenario
+// package. Row 0 is the first grid line and column 0 the first cell of each
+// line.
+//
+// Headers:
+//
+//	name        name of the map, optional
+//	players     number of players the map is made for, 2 to 4
+//
+// Cells:
+//
+//	.           empty tile
+//	#           wall
+//	1-4         spawn point of the player with that number
+//	a-z         tile of the zone with the same uppercase index, the zone
+//	            must fill a rectangle
+//	D L R M     spawn hint for a doubleBullet, laser, radar or mine item
+//	*           spawn hint for any item
+//
+// Example:
+//
+//	// Two tanks meet in a single corridor
+//	name: Corridor
+//	players: 2
+//
+//	# # # # #
+//	# 1 . * #
+//	# # a # #
+//	# D . 2 #
+//	# # # # #
+package map_file
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"hackarena2-0-mono-tanks-go/packet/packets/game_state"
+	"hackarena2-0-mono-tanks-go/packet/packets/game_state/direction"
+	"hackarena2-0-mono-tanks-go/packet/packets/lobby_data"
+)
+
+const (
+	// MinPlayers is the smallest number of players the server supports.
+	MinPlayers = 2
+
+	// MaxPlayers is the largest number of players the server supports.
+	MaxPlayers = 4
+
+	// AnyItem is the type of a spawn hint that does not name an item.
+	AnyItem = "any"
+)
+
+var itemHints = map[rune]string{
+	'D': "doubleBullet",
+	'L': "laser",
+	'R': "radar",
+	'M': "mine",
+	'*': AnyItem,
+}
+
+// Point is a tile of the grid.
+type Point struct {
+	X int
+	Y int
+}
+
+// ItemHint marks a tile where an item should spawn.
+type ItemHint struct {
+	X int
+	Y int
+
+	// Type is the type of the item, or AnyItem.
+	Type string
+}
+
+// Zone is a rectangular zone of the map.
+type Zone struct {
+	Index  uint8
+	X      int
+	Y      int
+	Width  int
+	Height int
+}
+
+// Contains reports whether the tile is inside of the zone.
+func (z Zone) Contains(x, y int) bool {
+	return x >= z.X && x < z.X+z.Width && y >= z.Y && y < z.Y+z.Height
+}
+
+// Map is a hand-made map.
+type Map struct {
+	// Name is the name of the map, empty if the file does not set it.
+	Name string
+
+	// Players is the number of players the map is made for.
+	Players int
+
+	// Dimension is the side length of the square grid.
+	Dimension int
+
+	// Walls is indexed by [y][x] and true for walls.
+	Walls [][]bool
+
+	// Zones are sorted by index.
+	Zones []Zone
+
+	// Spawns are sorted by player number, so the spawn of player 1 comes first.
+	Spawns []Point
+
+	// ItemHints are in reading order.
+	ItemHints []ItemHint
+
+	// zoneTiles is the number of tiles drawn for each zone, to check that
+	// they fill its rectangle.
+	zoneTiles map[uint8]int
+
+	// spawnNumbers are the player numbers of Spawns.
+	spawnNumbers []int
+}
+
+// Load reads, parses and validates a map file.
+func Load(path string) (*Map, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := m.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return m, nil
+}
+
+// Parse reads a map from its text. It only reports syntax errors, use
+// Validate to check that the map is playable.
+func Parse(text string) (*Map, error) {
+	m := &Map{zoneTiles: make(map[uint8]int)}
+
+	var rows [][]rune
+	for i, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "//") {
+			continue
+		}
+
+		if key, value, ok := strings.Cut(trimmed, ":"); ok {
+			if len(rows) > 0 {
+				return nil, fmt.Errorf("line %d: header %q after the grid", i+1, strings.TrimSpace(key))
+			}
+			if err := m.setHeader(strings.TrimSpace(key), strings.TrimSpace(value)); err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			continue
+		}
+
+		rows = append(rows, []rune(strings.Map(func(r rune) rune {
+			if unicode.IsSpace(r) {
+				return -1
+			}
+			return r
+		}, trimmed)))
+	}
+
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("empty grid")
+	}
+	if m.Players == 0 {
+		return nil, fmt.Errorf("missing header \"players\"")
+	}
+
+	m.Dimension = len(rows)
+	m.Walls = make([][]bool, len(rows))
+	zones := make(map[uint8]*Zone)
+	spawns := make(map[int]Point)
+
+	for y, row := range rows {
+		if len(row) != m.Dimension {
+			return nil, fmt.Errorf("row %d has %d cells, expected %d for a square grid", y, len(row), m.Dimension)
+		}
+
+		m.Walls[y] = make([]bool, len(row))
+		for x, cell := range row {
+			if itemType, ok := itemHints[cell]; ok {
+				m.ItemHints = append(m.ItemHints, ItemHint{X: x, Y: y, Type: itemType})
+				continue
+			}
+
+			switch {
+			case cell == '.':
+			case cell == '#':
+				m.Walls[y][x] = true
+			case cell >= '1' && cell <= '9':
+				number := int(cell - '0')
+				if previous, ok := spawns[number]; ok {
+					return nil, fmt.Errorf("second spawn of player %d at (%d, %d), first at (%d, %d)", number, x, y, previous.X, previous.Y)
+				}
+				spawns[number] = Point{X: x, Y: y}
+			case cell >= 'a' && cell <= 'z':
+				index := uint8(unicode.ToUpper(cell))
+				zone, ok := zones[index]
+				if !ok {
+					zone = &Zone{Index: index, X: x, Y: y, Width: 1, Height: 1}
+					zones[index] = zone
+				}
+				right := max(zone.X+zone.Width, x+1)
+				bottom := max(zone.Y+zone.Height, y+1)
+				zone.X = min(zone.X, x)
+				zone.Y = min(zone.Y, y)
+				zone.Width = right - zone.X
+				zone.Height = bottom - zone.Y
+				m.zoneTiles[index]++
+			default:
+				return nil, fmt.Errorf("unknown cell %q at (%d, %d)", cell, x, y)
+			}
+		}
+	}
+
+	for _, zone := range zones {
+		m.Zones = append(m.Zones, *zone)
+	}
+	sort.Slice(m.Zones, func(i, j int) bool {
+		return m.Zones[i].Index < m.Zones[j].Index
+	})
+
+	for number := range spawns {
+		m.spawnNumbers = append(m.spawnNumbers, number)
+	}
+	sort.Ints(m.spawnNumbers)
+	for _, number := range m.spawnNumbers {
+		m.Spawns = append(m.Spawns, spawns[number])
+	}
+
+	return m, nil
+}
+
+// MustParse is like Parse but panics if the map is invalid, syntax or
+// otherwise. It is meant for maps written as literals.
+func MustParse(text string) *Map {
+	m, err := Parse(text)
+	if err == nil {
+		err = m.Validate()
+	}
+	if err != nil {
+		panic(fmt.Sprintf("map_file: %v", err))
+	}
+	return m
+}
+
+func (m *Map) setHeader(key string, value string) error {
+	switch strings.ToLower(key) {
+	case "name":
+		m.Name = value
+	case "players":
+		players, err := strconv.Atoi(value)
+		if err != nil || players < MinPlayers || players > MaxPlayers {
+			return fmt.Errorf("players must be between %d and %d, got %q", MinPlayers, MaxPlayers, value)
+		}
+		m.Players = players
+	default:
+		return fmt.Errorf("unknown header %q", key)
+	}
+	return nil
+}
+
+// Validate checks that the map is playable and reports every problem found:
+//   - there is one spawn for each player, numbered from 1,
+//   - every zone fills its rectangle and does not contain walls, spawns or
+//     tiles of other zones,
+//   - every tile that is not a wall can be reached from the first spawn.
+func (m *Map) Validate() error {
+	var problems []error
+
+	if len(m.Spawns) != m.Players {
+		problems = append(problems, fmt.Errorf("%d spawns for %d players", len(m.Spawns), m.Players))
+	}
+	for i, number := range m.spawnNumbers {
+		if number != i+1 {
+			problems = append(problems, fmt.Errorf("spawn of player %d without a spawn of player %d", number, i+1))
+			break
+		}
+	}
+
+	for _, zone := range m.Zones {
+		if tiles := m.zoneTiles[zone.Index]; tiles != zone.Width*zone.Height {
+			problems = append(problems, fmt.Errorf(
+				"zone %c at (%d, %d) of size %dx%d has %d other tiles inside of it",
+				zone.Index, zone.X, zone.Y, zone.Width, zone.Height, zone.Width*zone.Height-tiles,
+			))
+		}
+	}
+
+	if unreachable := m.unreachable(); len(unreachable) > 0 {
+		problems = append(problems, fmt.Errorf(
+			"%d tiles can't be reached from the first spawn, the first at (%d, %d)",
+			len(unreachable), unreachable[0].X, unreachable[0].Y,
+		))
+	}
+
+	return errors.Join(problems...)
+}
+
+// unreachable returns the tiles without walls that can't be reached from
+// the first spawn, in reading order.
+func (m *Map) unreachable() []Point {
+	if len(m.Spawns) == 0 {
+		return nil
+	}
+
+	reached := make([][]bool, m.Dimension)
+	for y := range reached {
+		reached[y] = make([]bool, m.Dimension)
+	}
+
+	start := m.Spawns[0]
+	reached[start.Y][start.X] = true
+	queue := []Point{start}
+	for len(queue) > 0 {
+		tile := queue[0]
+		queue = queue[1:]
+
+		for _, next := range []Point{{tile.X, tile.Y - 1}, {tile.X + 1, tile.Y}, {tile.X, tile.Y + 1}, {tile.X - 1, tile.Y}} {
+			if next.X < 0 || next.Y < 0 || next.X >= m.Dimension || next.Y >= m.Dimension {
+				continue
+			}
+			if reached[next.Y][next.X] || m.Walls[next.Y][next.X] {
+				continue
+			}
+			reached[next.Y][next.X] = true
+			queue = append(queue, next)
+		}
+	}
+
+	var unreachable []Point
+	for y, row := range m.Walls {
+		for x, wall := range row {
+			if !wall && !reached[y][x] {
+				unreachable = append(unreachable, Point{X: x, Y: y})
+			}
+		}
+	}
+	return unreachable
+}
+
+// ServerSettings returns server settings matching the map, for a local game
+// played on it instead of a seed-generated map.
+func (m *Map) ServerSettings() lobby_data.ServerSettings {
+	return lobby_data.ServerSettings{
+		GridDimension:   uint32(m.Dimension),
+		NumberOfPlayers: uint32(m.Players),
+	}
+}
+
+// GameState returns the game state of the first tick of a local game on the
+// map, as seen by the player with the first of playerIDs, with full
+// visibility. Each player's tank is placed on the spawn with the same
+// position in Spawns. Item hints are left out, since items only spawn
+// during the game.
+func (m *Map) GameState(playerIDs []string) (*game_state.GameState, error) {
+	if len(playerIDs) != len(m.Spawns) {
+		return nil, fmt.Errorf("%d players for %d spawns", len(playerIDs), len(m.Spawns))
+	}
+
+	gameState := &game_state.GameState{
+		Visibility: make([][]bool, m.Dimension),
+	}
+
+	for y, row := range m.Walls {
+		gameState.Visibility[y] = make([]bool, m.Dimension)
+		for x, wall := range row {
+			gameState.Visibility[y][x] = true
+			if wall {
+				gameState.Walls = append(gameState.Walls, game_state.Wall{X: x, Y: y})
+			}
+		}
+	}
+
+	for i, playerID := range playerIDs {
+		ping := uint64(0)
+		score := uint64(0)
+		gameState.Players = append(gameState.Players, game_state.Player{
+			ID:       playerID,
+			Nickname: fmt.Sprintf("Player %d", i+1),
+			Ping:     &ping,
+			Score:    &score,
+		})
+
+		tank := game_state.Tank{
+			X:         m.Spawns[i].X,
+			Y:         m.Spawns[i].Y,
+			Direction: direction.Up,
+			OwnerID:   playerID,
+			Turret:    game_state.Turret{Direction: direction.Up},
+		}
+		// Only our own tank shows its health and bullets
+		if i == 0 {
+			health := 100
+			bulletCount := 3
+			tank.Health = &health
+			tank.Turret.BulletCount = &bulletCount
+		}
+		gameState.Tanks = append(gameState.Tanks, tank)
+	}
+
+	for _, zone := range m.Zones {
+		gameState.Zones = append(gameState.Zones, game_state.Zone{
+			Index:  zone.Index,
+			X:      uint64(zone.X),
+			Y:      uint64(zone.Y),
+			Width:  uint64(zone.Width),
+			Height: uint64(zone.Height),
+			Status: game_state.ZoneStatus{Type: "neutral"},
+		})
+	}
+
+	return gameState, nil
+}
+
+// String returns the map in the file format, so maps changed in code can be
+// saved and edited further by hand.
+func (m *Map) String() string {
+	grid := make([][]rune, m.Dimension)
+	for y, row := range m.Walls {
+		grid[y] = make([]rune, m.Dimension)
+		for x, wall := range row {
+			grid[y][x] = '.'
+			if wall {
+				grid[y][x] = '#'
+			}
+		}
+	}
+	for _, zone := range m.Zones {
+		for y := zone.Y; y < zone.Y+zone.Height; y++ {
+			for x := zone.X; x < zone.X+zone.Width; x++ {
+				grid[y][x] = unicode.ToLower(rune(zone.Index))
+			}
+		}
+	}
+	for _, hint := range m.ItemHints {
+		for cell, itemType := range itemHints {
+			if itemType == hint.Type {
+				grid[hint.Y][hint.X] = cell
+			}
+		}
+	}
+	for i, spawn := range m.Spawns {
+		grid[spawn.Y][spawn.X] = rune('1' + i)
+	}
+
+	var builder strings.Builder
+	if m.Name != "" {
+		fmt.Fprintf(&builder, "name: %s\n", m.Name)
+	}
+	fmt.Fprintf(&builder, "players: %d\n\n", m.Players)
+	for _, row := range grid {
+		cells := make([]string, len(row))
+		for x, cell := range row {
+			cells[x] = string(cell)
+		}
+		builder.WriteString(strings.Join(cells, " "))
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
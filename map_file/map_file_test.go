@@ -0,0 +1,176 @@
+package map_file
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const corridor = `
+	// Two tanks meet in a single corridor
+	name: Corridor
+	players: 2
+
+	# # # # # #
+	# 1 . . * #
+	# # a a # #
+	# # a a # #
+	# D . . 2 #
+	# # # # # #
+`
+
+func TestParse(t *testing.T) {
+	m, err := Parse(corridor)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if m.Name != "Corridor" || m.Players != 2 || m.Dimension != 6 {
+		t.Errorf("Expected Corridor for 2 players on a 6x6 grid, got %q for %d players on %d", m.Name, m.Players, m.Dimension)
+	}
+	if !m.Walls[0][0] || m.Walls[1][1] {
+		t.Errorf("Expected a wall at (0, 0) and none at (1, 1)")
+	}
+
+	expectedSpawns := []Point{{1, 1}, {4, 4}}
+	if len(m.Spawns) != 2 || m.Spawns[0] != expectedSpawns[0] || m.Spawns[1] != expectedSpawns[1] {
+		t.Errorf("Expected spawns %v, got %v", expectedSpawns, m.Spawns)
+	}
+
+	expectedZone := Zone{Index: 'A', X: 2, Y: 2, Width: 2, Height: 2}
+	if len(m.Zones) != 1 || m.Zones[0] != expectedZone {
+		t.Errorf("Expected zone %+v, got %+v", expectedZone, m.Zones)
+	}
+
+	expectedHints := []ItemHint{{X: 4, Y: 1, Type: AnyItem}, {X: 1, Y: 4, Type: "doubleBullet"}}
+	if len(m.ItemHints) != 2 || m.ItemHints[0] != expectedHints[0] || m.ItemHints[1] != expectedHints[1] {
+		t.Errorf("Expected item hints %v, got %v", expectedHints, m.ItemHints)
+	}
+
+	if err := m.Validate(); err != nil {
+		t.Errorf("Expected a valid map, got %v", err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		error string
+	}{
+		{"missing players", ". .\n. .", "missing header"},
+		{"too many players", "players: 5\n. .\n. .", "players must be between"},
+		{"unknown header", "players: 2\nseed: 1\n. .\n. .", "unknown header"},
+		{"header after grid", "players: 2\n. .\nname: x\n. .", "after the grid"},
+		{"not square", "players: 2\n. . .\n. . .", "square grid"},
+		{"unknown cell", "players: 2\n1 x\n% 2", "unknown cell"},
+		{"duplicate spawn", "players: 2\n1 .\n. 1", "second spawn of player 1"},
+		{"empty", "players: 2\n// nothing\n", "empty grid"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse(test.text)
+			if err == nil || !strings.Contains(err.Error(), test.error) {
+				t.Errorf("Expected an error containing %q, got %v", test.error, err)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		errors []string
+	}{
+		{"missing spawn", "players: 3\n1 . .\n. . .\n. . 2", []string{"2 spawns for 3 players"}},
+		{"spawn gap", "players: 2\n1 . .\n. . .\n. . 3", []string{"spawn of player 3 without a spawn of player 2"}},
+		{"zone with a wall", "players: 2\n1 a a\n. # a\n. . 2", []string{"zone A at (1, 0) of size 2x2 has 1 other tiles"}},
+		{"split zones", "players: 2\n1 a .\nb a b\n. . 2", []string{"zone B at (0, 1) of size 3x1"}},
+		{"walled off", "players: 2\n1 . # .\n. # . .\n# . . .\n. . . 2", []string{"10 tiles can't be reached", "(3, 0)"}},
+		{"several problems", "players: 3\n1 # .\n# a .\na . 2", []string{"spawns for 3 players", "zone A", "tiles can't be reached"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m, err := Parse(test.text)
+			if err != nil {
+				t.Fatalf("Expected no syntax error, got %v", err)
+			}
+			err = m.Validate()
+			if err == nil {
+				t.Fatalf("Expected validation errors %v, got none", test.errors)
+			}
+			for _, expected := range test.errors {
+				if !strings.Contains(err.Error(), expected) {
+					t.Errorf("Expected an error containing %q, got %v", expected, err)
+				}
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "corridor.map")
+	if err := os.WriteFile(path, []byte(corridor), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err != nil {
+		t.Errorf("Expected the map to load, got %v", err)
+	}
+
+	invalidPath := filepath.Join(dir, "invalid.map")
+	if err := os.WriteFile(invalidPath, []byte("players: 2\n1 #\n# 2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(invalidPath); err == nil || !strings.Contains(err.Error(), "invalid.map") {
+		t.Errorf("Expected a validation error naming the file, got %v", err)
+	}
+}
+
+func TestString(t *testing.T) {
+	m := MustParse(corridor)
+
+	reparsed := MustParse(m.String())
+	if reparsed.String() != m.String() {
+		t.Errorf("Expected the map to survive a round trip, got\n%s\nand\n%s", m.String(), reparsed.String())
+	}
+	if !strings.Contains(m.String(), "# 1 . . * #") {
+		t.Errorf("Expected the grid to be written with spaces, got\n%s", m.String())
+	}
+}
+
+func TestGameState(t *testing.T) {
+	m := MustParse(corridor)
+
+	if _, err := m.GameState([]string{"me"}); err == nil {
+		t.Errorf("Expected an error for too few players")
+	}
+
+	gameState, err := m.GameState([]string{"me", "enemy"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(gameState.Walls) != 24 {
+		t.Errorf("Expected 24 walls, got %d", len(gameState.Walls))
+	}
+	if len(gameState.Tanks) != 2 || gameState.Tanks[1].OwnerID != "enemy" || gameState.Tanks[1].X != 4 || gameState.Tanks[1].Y != 4 {
+		t.Errorf("Expected the enemy tank on the second spawn, got %+v", gameState.Tanks)
+	}
+	if gameState.Tanks[0].Health == nil || gameState.Tanks[1].Health != nil {
+		t.Errorf("Expected only our tank to show its health")
+	}
+	if len(gameState.Zones) != 1 || gameState.Zones[0].Width != 2 {
+		t.Errorf("Expected the zone to be copied, got %+v", gameState.Zones)
+	}
+
+	settings := m.ServerSettings()
+	if settings.GridDimension != 6 || settings.NumberOfPlayers != 2 {
+		t.Errorf("Expected settings for a 6x6 grid and 2 players, got %+v", settings)
+	}
+}
@@ -0,0 +1,363 @@
+// Package mcts is a reference bot strategy choosing its moves with Monte
+// Carlo Tree Search over the forward_model.
+//
+// The tree only branches on our own actions. The other tanks act by the
+// rollout policy both inside the tree and in the rollouts, and every
+// iteration replays the actions from the root, so the tree copes with
+// enemies that don't act the same way twice.
+//
+// Enemies hidden by the fog of war are handled by determinization: each
+// search samples a few guesses of where they are from a fog_of_war.Tracker,
+// and the iterations take turns playing on each of them.
+package mcts
+
+import (
+	"log"
+	"math"
+	"math/rand"
+	"time"
+
+	"hackarena2-0-mono-tanks-go/fog_of_war"
+	"hackarena2-0-mono-tanks-go/forward_model"
+	"hackarena2-0-mono-tanks-go/packet/packets/bot_response"
+	"hackarena2-0-mono-tanks-go/packet/packets/game_state"
+	"hackarena2-0-mono-tanks-go/pathing"
+	"hackarena2-0-mono-tanks-go/timing"
+)
+
+// RolloutPolicy chooses the action of a tank in a simulated tick.
+type RolloutPolicy func(state *forward_model.State, tank int, random *rand.Rand) forward_model.Action
+
+// RandomRollout picks one of the legal actions of the tank at random.
+func RandomRollout(state *forward_model.State, tank int, random *rand.Rand) forward_model.Action {
+	for {
+		action := forward_model.Actions[random.Intn(len(forward_model.Actions))]
+		if state.Legal(tank, action) {
+			return action
+		}
+	}
+}
+
+// Evaluation scores a state for the tank, higher is better.
+type Evaluation func(state *forward_model.State, tank int) float64
+
+// DefaultEvaluation adds the score and health of the tank, and subtracts
+// the average health of the other tanks.
+func DefaultEvaluation(state *forward_model.State, tank int) float64 {
+	value := float64(state.Tanks[tank].Score + max(state.Tanks[tank].Health, 0))
+
+	others := 0
+	enemyHealth := 0
+	for i := range state.Tanks {
+		if i != tank {
+			others++
+			enemyHealth += max(state.Tanks[i].Health, 0)
+		}
+	}
+	if others > 0 {
+		value -= float64(enemyHealth) / float64(others)
+	}
+	return value
+}
+
+// Config tunes the search.
+type Config struct {
+	// Rules are the rules of the forward model.
+	Rules *forward_model.Rules
+
+	// Exploration is the UCT constant, higher explores more.
+	Exploration float64
+
+	// Depth is the number of ticks each iteration simulates.
+	Depth int
+
+	// Iterations caps the number of iterations, 0 for no cap.
+	Iterations int
+
+	// BudgetShare is the share of the time left until the tick deadline the search may use.
+	BudgetShare float64
+
+	// Budget is the time the search may use when the deadline is unknown.
+	Budget time.Duration
+
+	// Determinizations is the number of guesses of the hidden enemies' poses per search.
+	Determinizations int
+
+	// RewardScale is the change in evaluation that counts as a clear win of an iteration.
+	RewardScale float64
+
+	// Rollout chooses the actions of the tanks outside of the tree, and of the enemies inside of it.
+	Rollout RolloutPolicy
+
+	// Evaluate scores the states at the end of the iterations.
+	Evaluate Evaluation
+
+	// Seed seeds the random numbers of the search.
+	Seed int64
+}
+
+// DefaultConfig uses half of the time left in the tick.
+var DefaultConfig = Config{
+	Rules:            &forward_model.DefaultRules,
+	Exploration:      math.Sqrt2,
+	Depth:            12,
+	BudgetShare:      0.5,
+	Budget:           50 * time.Millisecond,
+	Determinizations: 4,
+	RewardScale:      40,
+	Rollout:          RandomRollout,
+	Evaluate:         DefaultEvaluation,
+	Seed:             1,
+}
+
+// Stats describe the last search.
+type Stats struct {
+	// Iterations is the number of iterations run.
+	Iterations int
+
+	// Visits is the number of iterations that started with each action.
+	Visits map[forward_model.Action]int
+
+	// Value is the average reward of each first action, from 0 to 1.
+	Value map[forward_model.Action]float64
+}
+
+type node struct {
+	children [len(forward_model.Actions)]*node
+	visits   int
+	reward   float64
+}
+
+// Strategy plays a tank with MCTS. Call Observe with every game state, also
+// while the tank is destroyed, so the fog of war tracker keeps up.
+type Strategy struct {
+	playerID string
+	config   Config
+	random   *rand.Rand
+	fog      *fog_of_war.Tracker
+	deadline time.Time
+	stats    Stats
+}
+
+func New(playerID string, config Config) *Strategy {
+	return &Strategy{
+		playerID: playerID,
+		config:   config,
+		random:   rand.New(rand.NewSource(config.Seed)),
+		fog:      fog_of_war.NewTracker(playerID),
+	}
+}
+
+// OnTickTiming sets the deadline of the next search, from the bot's OnTickTiming hook.
+func (s *Strategy) OnTickTiming(tickTiming timing.Timing) {
+	s.deadline = tickTiming.Deadline
+}
+
+// Observe updates the guesses of where the hidden enemies are.
+func (s *Strategy) Observe(gameState *game_state.GameState) {
+	s.fog.Observe(gameState)
+}
+
+// Stats returns the statistics of the last search.
+func (s *Strategy) Stats() Stats {
+	return s.stats
+}
+
+// NextMove observes the game state and searches for the best response to it.
+func (s *Strategy) NextMove(gameState *game_state.GameState) *bot_response.BotResponse {
+	s.Observe(gameState)
+	action, err := s.Search(gameState)
+	if err != nil {
+		log.Printf("[System] 🚨 Tick %d not searched, passing instead -> %v", gameState.Tick, err)
+	}
+	return action.Response()
+}
+
+// Search returns the action with the most visits, within the time budget.
+// It returns a pass and an error if the forward model can't read the game
+// state.
+func (s *Strategy) Search(gameState *game_state.GameState) (forward_model.Action, error) {
+	s.stats = Stats{
+		Visits: make(map[forward_model.Action]int),
+		Value:  make(map[forward_model.Action]float64),
+	}
+
+	roots, err := s.determinize(gameState)
+	if err != nil {
+		return forward_model.Pass, err
+	}
+	me := roots[0].TankIndex(s.playerID)
+	if me < 0 || !roots[0].Tanks[me].Alive() {
+		return forward_model.Pass, nil
+	}
+
+	budget := s.config.Budget
+	if !s.deadline.IsZero() {
+		budget = time.Duration(float64(time.Until(s.deadline)) * s.config.BudgetShare)
+	}
+	stop := time.Now().Add(budget)
+
+	root := &node{}
+	var state forward_model.State
+	actions := make([]forward_model.Action, len(roots[0].Tanks))
+	path := make([]*node, 0, s.config.Depth+1)
+
+	for iteration := 0; s.config.Iterations == 0 || iteration < s.config.Iterations; iteration++ {
+		// Checking the clock is slow compared to an iteration
+		if iteration%16 == 0 && iteration > 0 && time.Now().After(stop) {
+			break
+		}
+
+		start := roots[iteration%len(roots)]
+		start.CopyTo(&state)
+		if len(actions) < len(state.Tanks) {
+			actions = make([]forward_model.Action, len(state.Tanks))
+		}
+		before := s.config.Evaluate(&state, me)
+
+		current := root
+		path = append(path[:0], root)
+		expanded := false
+		for depth := 0; depth < s.config.Depth; depth++ {
+			s.rollout(&state, actions)
+			if !expanded {
+				action, child := s.selectAction(&state, me, current)
+				actions[me] = action
+				if child == nil {
+					child = &node{}
+					current.children[action] = child
+					expanded = true
+				}
+				current = child
+				path = append(path, current)
+			}
+			state.Step(actions[:len(state.Tanks)])
+		}
+
+		reward := 0.5 + 0.5*math.Tanh((s.config.Evaluate(&state, me)-before)/s.config.RewardScale)
+		for _, n := range path {
+			n.visits++
+			n.reward += reward
+		}
+		s.stats.Iterations++
+	}
+
+	best := forward_model.Pass
+	for _, action := range forward_model.Actions {
+		child := root.children[action]
+		if child == nil {
+			continue
+		}
+		s.stats.Visits[action] = child.visits
+		s.stats.Value[action] = child.reward / float64(child.visits)
+		if root.children[best] == nil || child.visits > root.children[best].visits {
+			best = action
+		}
+	}
+	return best, nil
+}
+
+// rollout chooses the actions of every tank by the rollout policy.
+func (s *Strategy) rollout(state *forward_model.State, actions []forward_model.Action) {
+	for i := range state.Tanks {
+		actions[i] = s.config.Rollout(state, i, s.random)
+	}
+}
+
+// selectAction returns a random untried legal action of the node without a
+// child, or the legal action with the best UCT value and its child.
+func (s *Strategy) selectAction(state *forward_model.State, me int, n *node) (forward_model.Action, *node) {
+	untried := 0
+	for _, action := range forward_model.Actions {
+		if n.children[action] == nil && state.Legal(me, action) {
+			untried++
+		}
+	}
+	if untried > 0 {
+		pick := s.random.Intn(untried)
+		for _, action := range forward_model.Actions {
+			if n.children[action] == nil && state.Legal(me, action) {
+				if pick == 0 {
+					return action, nil
+				}
+				pick--
+			}
+		}
+	}
+
+	best := forward_model.Pass
+	bestValue := math.Inf(-1)
+	logVisits := math.Log(float64(n.visits))
+	for _, action := range forward_model.Actions {
+		child := n.children[action]
+		if child == nil || !state.Legal(me, action) {
+			continue
+		}
+		value := child.reward/float64(child.visits) + s.config.Exploration*math.Sqrt(logVisits/float64(child.visits))
+		if value > bestValue {
+			best, bestValue = action, value
+		}
+	}
+	return best, n.children[best]
+}
+
+// determinize builds the states to search from, with the hidden enemies
+// placed at poses sampled from the fog of war tracker.
+func (s *Strategy) determinize(gameState *game_state.GameState) ([]*forward_model.State, error) {
+	base, err := forward_model.FromGameState(gameState, s.config.Rules)
+	if err != nil {
+		return nil, err
+	}
+
+	var hidden [][]fog_of_war.Estimate
+	var hiddenIDs []string
+	for _, player := range gameState.Players {
+		if player.ID == s.playerID || base.TankIndex(player.ID) >= 0 {
+			continue
+		}
+		if estimates := s.fog.MostLikely(player.ID, math.MaxInt); len(estimates) > 0 {
+			hidden = append(hidden, estimates)
+			hiddenIDs = append(hiddenIDs, player.ID)
+		}
+	}
+	if len(hidden) == 0 || s.config.Determinizations <= 1 {
+		return []*forward_model.State{base}, nil
+	}
+
+	states := make([]*forward_model.State, s.config.Determinizations)
+	for i := range states {
+		states[i] = base.Clone()
+		for j, estimates := range hidden {
+			estimate := s.sample(estimates)
+			dir := pathing.DirectionIndex(estimate.Direction)
+			states[i].AddTank(forward_model.Tank{
+				OwnerID:         hiddenIDs[j],
+				X:               estimate.X,
+				Y:               estimate.Y,
+				Direction:       dir,
+				TurretDirection: dir,
+				Health:          s.config.Rules.Health,
+				Bullets:         s.config.Rules.MaxBullets,
+				TicksToBullet:   s.config.Rules.BulletRegenTicks,
+			})
+		}
+	}
+	return states, nil
+}
+
+// sample picks an estimate with its probability.
+func (s *Strategy) sample(estimates []fog_of_war.Estimate) fog_of_war.Estimate {
+	total := 0.0
+	for _, estimate := range estimates {
+		total += estimate.Probability
+	}
+
+	pick := s.random.Float64() * total
+	for _, estimate := range estimates {
+		pick -= estimate.Probability
+		if pick <= 0 {
+			return estimate
+		}
+	}
+	return estimates[len(estimates)-1]
+}
@@ -0,0 +1,116 @@
+package mcts
+
+import (
+	"hackarena2-0-mono-tanks-go/forward_model"
+	"hackarena2-0-mono-tanks-go/packet/packets/bot_response"
+	"hackarena2-0-mono-tanks-go/packet/packets/game_state"
+	"hackarena2-0-mono-tanks-go/scenario"
+	"testing"
+	"time"
+)
+
+func config(iterations int) Config {
+	config := DefaultConfig
+	config.Iterations = iterations
+	config.Budget = time.Minute
+	return config
+}
+
+func TestSearchShootsAnEnemyInLine(t *testing.T) {
+	s := scenario.MustParse(`
+		# # # # # # #
+		# > . . T . #
+		# # # # # # #
+	`)
+	strategy := New(scenario.MyID, config(3000))
+
+	response := strategy.NextMove(s.GameState)
+	if *response != *bot_response.NewAbilityUse("fireBullet") {
+		t.Errorf("Expected to fire at the enemy, got %+v with %+v", response, strategy.Stats().Visits)
+	}
+
+	stats := strategy.Stats()
+	if stats.Iterations != 3000 {
+		t.Errorf("Expected 3000 iterations, got %d", stats.Iterations)
+	}
+	total := 0
+	for _, visits := range stats.Visits {
+		total += visits
+	}
+	if total != 3000 {
+		t.Errorf("Expected every iteration to visit a first action, got %d", total)
+	}
+}
+
+func TestSearchStopsAtTheDeadline(t *testing.T) {
+	s := scenario.MustParse(`
+		# # # # #
+		# > . T #
+		# # # # #
+	`)
+	config := config(0)
+	config.Budget = 20 * time.Millisecond
+	strategy := New(scenario.MyID, config)
+
+	started := time.Now()
+	strategy.Search(s.GameState)
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("Expected the search to stop after its budget, took %v", elapsed)
+	}
+	if strategy.Stats().Iterations == 0 {
+		t.Errorf("Expected some iterations within the budget")
+	}
+}
+
+func TestSearchPassesWhileDestroyed(t *testing.T) {
+	s := scenario.MustParse(`
+		# # # # #
+		# . . T #
+		# # # # #
+	`)
+	strategy := New(scenario.MyID, config(100))
+
+	if action, err := strategy.Search(s.GameState); err != nil || action != forward_model.Pass {
+		t.Errorf("Expected a pass without our tank, got %d, %v", action, err)
+	}
+}
+
+func TestSearchRejectsUnknownDirections(t *testing.T) {
+	s := scenario.MustParse(`> T`)
+	s.GameState.Tanks[1].Turret.Direction = "sideways"
+	strategy := New(scenario.MyID, config(100))
+
+	if action, err := strategy.Search(s.GameState); err == nil || action != forward_model.Pass {
+		t.Errorf("Expected a pass and an error, got %d, %v", action, err)
+	}
+}
+
+func TestDeterminizeSamplesHiddenEnemies(t *testing.T) {
+	s := scenario.MustParse(`
+		# # # # # #
+		# > . ~ ~ #
+		# . . ~ ~ #
+		# # # # # #
+	`)
+	s.GameState.Players = append(s.GameState.Players, game_state.Player{ID: "hidden"})
+
+	strategy := New(scenario.MyID, config(100))
+	strategy.Observe(s.GameState)
+	states, err := strategy.determinize(s.GameState)
+	if err != nil {
+		t.Fatalf("Expected states, got %v", err)
+	}
+
+	if len(states) != DefaultConfig.Determinizations {
+		t.Fatalf("Expected %d determinizations, got %d", DefaultConfig.Determinizations, len(states))
+	}
+	for _, state := range states {
+		i := state.TankIndex("hidden")
+		if i < 0 {
+			t.Fatalf("Expected the hidden enemy to be placed")
+		}
+		if tank := state.Tanks[i]; tank.X < 3 || tank.Y < 1 || tank.Y > 2 {
+			t.Errorf("Expected the hidden enemy in the dark, got (%d, %d)", tank.X, tank.Y)
+		}
+	}
+}
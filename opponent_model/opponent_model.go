@@ -0,0 +1,419 @@
+// Package opponent_model learns how other bots play by watching their tanks
+// across ticks, and keeps what it learned between matches.
+//
+// Profiles are kept per nickname, since player IDs change every match while
+// teams keep their bot's name through a tournament. Only ticks on which a
+// tank is visible in two consecutive game states teach the model anything.
+package opponent_model
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"hackarena2-0-mono-tanks-go/packet/packets/game_state"
+	"hackarena2-0-mono-tanks-go/pathing"
+)
+
+// DefaultPath is where the profiles are kept, inside of the data folder
+// that is copied into the Docker image.
+const DefaultPath = "data/opponents.json"
+
+// Actions observed between two game states.
+const (
+	Forward  = "forward"
+	Backward = "backward"
+	Rotate   = "rotate"
+	Stay     = "stay"
+)
+
+const (
+	// priorWeight is the number of imagined observations the predictions
+	// start from, so a few ticks don't make them extreme.
+	priorWeight = 10
+
+	// priorFireChance is the assumed chance of firing a bullet each tick
+	// before any bullet has been seen.
+	priorFireChance = 0.1
+)
+
+// Profile is what has been learned about a bot.
+type Profile struct {
+	// Nickname is the nickname of the bot.
+	Nickname string `json:"nickname"`
+
+	// Matches is the number of matches the bot was observed in.
+	Matches int `json:"matches"`
+
+	// Observations is the number of ticks the bot's tank was observed for.
+	Observations int `json:"observations"`
+
+	// Actions counts the Forward, Backward, Rotate and Stay actions observed.
+	Actions map[string]int `json:"actions"`
+
+	// AimObservations is the number of observations while our tank was visible,
+	// and AimedAtUs the number of those with the turret facing towards us.
+	AimObservations int `json:"aimObservations"`
+	AimedAtUs       int `json:"aimedAtUs"`
+
+	// ShotsFired is the number of bullets fired, double bullets included.
+	ShotsFired int `json:"shotsFired"`
+
+	// ItemUses counts the doubleBullet, laser and mine items used.
+	ItemUses map[string]int `json:"itemUses"`
+
+	// ZoneTicks counts the observations inside each zone, by zone index.
+	ZoneTicks map[string]int `json:"zoneTicks"`
+}
+
+func newProfile(nickname string) *Profile {
+	return &Profile{
+		Nickname:  nickname,
+		Actions:   make(map[string]int),
+		ItemUses:  make(map[string]int),
+		ZoneTicks: make(map[string]int),
+	}
+}
+
+// FireChance is the chance of the bot firing a bullet on a tick.
+func (p *Profile) FireChance() float64 {
+	return (float64(p.ShotsFired) + priorFireChance*priorWeight) / float64(p.Observations+priorWeight)
+}
+
+// ActionChance is the chance of the bot taking the action on a tick. Every
+// action starts from an even chance.
+func (p *Profile) ActionChance(action string) float64 {
+	prior := float64(priorWeight) / 4
+	return (float64(p.Actions[action]) + prior) / float64(p.Observations+priorWeight)
+}
+
+// AimChance is the share of the ticks the bot kept its turret facing towards us.
+func (p *Profile) AimChance() float64 {
+	if p.AimObservations == 0 {
+		return 0
+	}
+	return float64(p.AimedAtUs) / float64(p.AimObservations)
+}
+
+// FavouriteZone returns the index of the zone the bot was seen in the most,
+// and false if it was never seen in a zone.
+func (p *Profile) FavouriteZone() (string, bool) {
+	favourite := ""
+	for zone, ticks := range p.ZoneTicks {
+		if favourite == "" || ticks > p.ZoneTicks[favourite] || (ticks == p.ZoneTicks[favourite] && zone < favourite) {
+			favourite = zone
+		}
+	}
+	return favourite, favourite != ""
+}
+
+// Position is a tile an enemy tank may be on next tick.
+type Position struct {
+	X           int
+	Y           int
+	Probability float64
+}
+
+// Prediction is what an enemy tank is expected to do on the next tick.
+type Prediction struct {
+	// Positions lists the tiles the tank may be on, most likely first.
+	Positions []Position
+
+	// FireChance is the chance of the tank firing a bullet.
+	FireChance float64
+}
+
+// Model observes the enemies during a match. It is not safe for concurrent use.
+type Model struct {
+	playerID string
+	profiles map[string]*Profile
+	seen     map[string]bool
+	previous *game_state.GameState
+}
+
+// NewModel creates a model without any profiles, for the player with the given ID.
+func NewModel(playerID string) *Model {
+	return &Model{
+		playerID: playerID,
+		profiles: make(map[string]*Profile),
+		seen:     make(map[string]bool),
+	}
+}
+
+// Load creates a model with the profiles saved at path. A missing file
+// gives a model without any profiles.
+func Load(path string, playerID string) (*Model, error) {
+	m := NewModel(playerID)
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(content, &m.profiles); err != nil {
+		return nil, err
+	}
+	for nickname, profile := range m.profiles {
+		if profile == nil {
+			profile = newProfile(nickname)
+			m.profiles[nickname] = profile
+		}
+		profile.Nickname = nickname
+		if profile.Actions == nil {
+			profile.Actions = make(map[string]int)
+		}
+		if profile.ItemUses == nil {
+			profile.ItemUses = make(map[string]int)
+		}
+		if profile.ZoneTicks == nil {
+			profile.ZoneTicks = make(map[string]int)
+		}
+	}
+	return m, nil
+}
+
+// Save writes the profiles to path, creating its directory if needed.
+func (m *Model) Save(path string) error {
+	content, err := json.MarshalIndent(m.profiles, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0o644)
+}
+
+// EndMatch counts the match for every bot observed since the previous
+// EndMatch, and forgets the last game state.
+func (m *Model) EndMatch() {
+	for nickname := range m.seen {
+		m.profiles[nickname].Matches++
+	}
+	m.seen = make(map[string]bool)
+	m.previous = nil
+}
+
+// Profile returns the profile of the bot with the nickname, or nil if it has
+// never been observed.
+func (m *Model) Profile(nickname string) *Profile {
+	return m.profiles[nickname]
+}
+
+// ProfileOf returns the profile of the owner of a tank in the game state, or
+// nil if it has never been observed.
+func (m *Model) ProfileOf(gameState *game_state.GameState, ownerID string) *Profile {
+	return m.profiles[nickname(gameState, ownerID)]
+}
+
+// Observe learns from the difference between the game state and the previous one.
+func (m *Model) Observe(gameState *game_state.GameState) {
+	previous := m.previous
+	m.previous = gameState
+	if previous == nil || gameState.Tick != previous.Tick+1 {
+		return
+	}
+
+	myTank := gameState.Tank(m.playerID)
+	var newBullets []game_state.Bullet
+	for _, bullet := range gameState.Bullets {
+		if previous.Bullet(bullet.ID) == nil {
+			newBullets = append(newBullets, bullet)
+		}
+	}
+
+	for _, tank := range gameState.Tanks {
+		if tank.OwnerID == m.playerID {
+			continue
+		}
+		before := previous.Tank(tank.OwnerID)
+		if before == nil {
+			continue
+		}
+
+		name := nickname(gameState, tank.OwnerID)
+		profile, ok := m.profiles[name]
+		if !ok {
+			profile = newProfile(name)
+			m.profiles[name] = profile
+		}
+		m.seen[name] = true
+
+		profile.Observations++
+		profile.Actions[action(before, &tank)]++
+
+		if myTank != nil {
+			profile.AimObservations++
+			if facesTowards(before.X, before.Y, before.Turret.Direction, myTank.X, myTank.Y) {
+				profile.AimedAtUs++
+			}
+		}
+
+		for _, bullet := range newBullets {
+			if firedBy(before, bullet) {
+				profile.ShotsFired++
+				if bullet.Type == "double" {
+					profile.ItemUses["doubleBullet"]++
+				}
+			}
+		}
+		for _, laser := range gameState.Lasers {
+			if previous.Laser(laser.ID) == nil && firedLaser(before, laser) {
+				profile.ItemUses["laser"]++
+				break
+			}
+		}
+		for _, mine := range gameState.Mines {
+			if !containsMine(previous.Mines, mine.ID) && mine.X == before.X && mine.Y == before.Y {
+				profile.ItemUses["mine"]++
+			}
+		}
+
+		for _, zone := range gameState.Zones {
+			if tank.X >= int(zone.X) && tank.X < int(zone.X+zone.Width) && tank.Y >= int(zone.Y) && tank.Y < int(zone.Y+zone.Height) {
+				profile.ZoneTicks[string(rune(zone.Index))]++
+			}
+		}
+	}
+}
+
+// Predict returns where the tank of the player with ownerID may be on the
+// next tick, and how likely it is to fire. Bots never observed are expected
+// to take every action equally often. It returns false if the tank is not visible.
+func (m *Model) Predict(gameState *game_state.GameState, ownerID string) (Prediction, bool) {
+	tank := gameState.Tank(ownerID)
+	if tank == nil {
+		return Prediction{}, false
+	}
+
+	profile := m.ProfileOf(gameState, ownerID)
+	if profile == nil {
+		profile = newProfile("")
+	}
+
+	obstacles := pathing.NewMap(gameState, ownerID)
+	stay := profile.ActionChance(Stay) + profile.ActionChance(Rotate)
+	var positions []Position
+
+	forwardX, forwardY := pathing.Step(tank.X, tank.Y, tank.Direction)
+	backwardX, backwardY := 2*tank.X-forwardX, 2*tank.Y-forwardY
+	for _, move := range []Position{
+		{forwardX, forwardY, profile.ActionChance(Forward)},
+		{backwardX, backwardY, profile.ActionChance(Backward)},
+	} {
+		// A tank driving into an obstacle stays where it is
+		if obstacles.Blocked(move.X, move.Y) {
+			stay += move.Probability
+			continue
+		}
+		positions = append(positions, move)
+	}
+	positions = append(positions, Position{tank.X, tank.Y, stay})
+
+	for i := 1; i < len(positions); i++ {
+		for j := i; j > 0 && positions[j].Probability > positions[j-1].Probability; j-- {
+			positions[j], positions[j-1] = positions[j-1], positions[j]
+		}
+	}
+
+	return Prediction{Positions: positions, FireChance: profile.FireChance()}, true
+}
+
+// action names the action a tank took between two observations.
+func action(before *game_state.Tank, after *game_state.Tank) string {
+	forwardX, forwardY := pathing.Step(before.X, before.Y, before.Direction)
+	switch {
+	case after.X == forwardX && after.Y == forwardY:
+		return Forward
+	case after.X == 2*before.X-forwardX && after.Y == 2*before.Y-forwardY:
+		return Backward
+	case after.X == before.X && after.Y == before.Y && (after.Direction != before.Direction || after.Turret.Direction != before.Turret.Direction):
+		return Rotate
+	default:
+		return Stay
+	}
+}
+
+// facesTowards reports whether a turret at (x, y) facing dir points to the
+// side of the target that is further away.
+func facesTowards(x, y int, dir string, targetX, targetY int) bool {
+	dx, dy := targetX-x, targetY-y
+	if dx == 0 && dy == 0 {
+		return false
+	}
+
+	var towards string
+	switch {
+	case abs(dx) >= abs(dy) && dx > 0:
+		towards = "right"
+	case abs(dx) >= abs(dy):
+		towards = "left"
+	case dy > 0:
+		towards = "down"
+	default:
+		towards = "up"
+	}
+	return dir == towards
+}
+
+// firedBy reports whether a new bullet could have left the turret of the
+// tank, which is in line behind the bullet and facing the same way.
+func firedBy(tank *game_state.Tank, bullet game_state.Bullet) bool {
+	if tank.Turret.Direction != bullet.Direction {
+		return false
+	}
+
+	// A bullet may have travelled a tile or two by the time it is first seen
+	x, y := tank.X, tank.Y
+	for i := 0; i < 3; i++ {
+		if x == bullet.X && y == bullet.Y {
+			return true
+		}
+		x, y = pathing.Step(x, y, bullet.Direction)
+	}
+	return false
+}
+
+// firedLaser reports whether a new laser tile lies on the line the turret of the tank faces.
+func firedLaser(tank *game_state.Tank, laser game_state.Laser) bool {
+	switch tank.Turret.Direction {
+	case "up":
+		return laser.X == tank.X && laser.Y < tank.Y
+	case "down":
+		return laser.X == tank.X && laser.Y > tank.Y
+	case "left":
+		return laser.Y == tank.Y && laser.X < tank.X
+	case "right":
+		return laser.Y == tank.Y && laser.X > tank.X
+	}
+	return false
+}
+
+func nickname(gameState *game_state.GameState, playerID string) string {
+	for _, player := range gameState.Players {
+		if player.ID == playerID {
+			return player.Nickname
+		}
+	}
+	return playerID
+}
+
+func containsMine(mines []game_state.Mine, id int) bool {
+	for _, mine := range mines {
+		if mine.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
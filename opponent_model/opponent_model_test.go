@@ -0,0 +1,175 @@
+package opponent_model
+
+import (
+	"hackarena2-0-mono-tanks-go/packet/packets/game_state"
+	"hackarena2-0-mono-tanks-go/scenario"
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+// tick parses a picture as the game state of the given tick.
+func tick(t *testing.T, number uint64, picture string) *game_state.GameState {
+	t.Helper()
+	gameState := scenario.MustParse(picture).GameState
+	gameState.Tick = number
+	return gameState
+}
+
+func TestObserve(t *testing.T) {
+	model := NewModel(scenario.MyID)
+
+	model.Observe(tick(t, 1, `
+		# # # # # #
+		# > . . . #
+		# . . . . #
+		# . . T . #
+		# # # # # #
+	`))
+	second := tick(t, 2, `
+		# # # # # #
+		# > . . . #
+		# . . T . #
+		# . . a . #
+		# # # # # #
+	`)
+	model.Observe(second)
+	third := tick(t, 3, `
+		# # # # # #
+		# > . ↑ . #
+		# . . T . #
+		# . . . . #
+		# # # # # #
+	`)
+	model.Observe(third)
+
+	profile := model.ProfileOf(third, "enemy-1")
+	if profile == nil || profile.Nickname != "Enemy 1" {
+		t.Fatalf("Expected a profile of Enemy 1, got %+v", profile)
+	}
+	if profile.Observations != 2 || profile.Actions[Forward] != 1 || profile.Actions[Stay] != 1 {
+		t.Errorf("Expected a forward move and a stay, got %+v", profile)
+	}
+	if profile.ShotsFired != 1 {
+		t.Errorf("Expected 1 shot fired, got %d", profile.ShotsFired)
+	}
+	// Facing up, our tank is further to the left than above
+	if profile.AimObservations != 2 || profile.AimedAtUs != 0 {
+		t.Errorf("Expected 2 aim observations without aiming at us, got %d of %d", profile.AimedAtUs, profile.AimObservations)
+	}
+	if zone, ok := profile.FavouriteZone(); ok || zone != "" {
+		t.Errorf("Expected no favourite zone, the zone was only drawn after the tank left, got %q", zone)
+	}
+
+	// Ticks that are not consecutive are not compared
+	model.Observe(tick(t, 10, `
+		# # # # # #
+		# > . . . #
+		# . . . . #
+		# . . T . #
+		# # # # # #
+	`))
+	if profile.Observations != 2 {
+		t.Errorf("Expected a gap in ticks to be skipped, got %d observations", profile.Observations)
+	}
+}
+
+func TestObserveItemUses(t *testing.T) {
+	model := NewModel(scenario.MyID)
+	model.Observe(tick(t, 1, `
+		# # # # # #
+		# < . . T #
+		# . . . . #
+		# # # # # #
+	`))
+	next := tick(t, 2, `
+		# # # # # #
+		# < . . X #
+		# . . . T #
+		# # # # # #
+	`)
+	model.Observe(next)
+
+	profile := model.ProfileOf(next, "enemy-1")
+	if profile.ItemUses["mine"] != 1 || profile.Actions[Backward] != 1 {
+		t.Errorf("Expected a mine dropped while backing away, got %+v", profile)
+	}
+}
+
+func TestPredict(t *testing.T) {
+	model := NewModel(scenario.MyID)
+	gameState := tick(t, 1, `
+		# # # # #
+		# > . . #
+		# . . . #
+		# . . T #
+		# # # # #
+	`)
+
+	if _, ok := model.Predict(gameState, "nobody"); ok {
+		t.Errorf("Expected no prediction for a missing tank")
+	}
+
+	// Never observed, every action is even, and backward hits the wall
+	prediction, ok := model.Predict(gameState, "enemy-1")
+	if !ok || len(prediction.Positions) != 2 {
+		t.Fatalf("Expected 2 positions, got %+v", prediction)
+	}
+	if stay := prediction.Positions[0]; stay.X != 3 || stay.Y != 3 || stay.Probability != 0.75 {
+		t.Errorf("Expected to stay with 0.75, got %+v", stay)
+	}
+	if forward := prediction.Positions[1]; forward.X != 3 || forward.Y != 2 || forward.Probability != 0.25 {
+		t.Errorf("Expected to move up with 0.25, got %+v", forward)
+	}
+	if prediction.FireChance != priorFireChance {
+		t.Errorf("Expected the prior fire chance, got %v", prediction.FireChance)
+	}
+
+	profile := newProfile("Enemy 1")
+	profile.Observations = 30
+	profile.Actions[Forward] = 30
+	profile.ShotsFired = 15
+	model.profiles["Enemy 1"] = profile
+
+	prediction, _ = model.Predict(gameState, "enemy-1")
+	if forward := prediction.Positions[0]; forward.Y != 2 || math.Abs(forward.Probability-32.5/40) > 1e-9 {
+		t.Errorf("Expected to move up most likely, got %+v", prediction.Positions)
+	}
+	if math.Abs(prediction.FireChance-16.0/40) > 1e-9 {
+		t.Errorf("Expected fire chance 0.4, got %v", prediction.FireChance)
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "opponents.json")
+
+	model, err := Load(path, scenario.MyID)
+	if err != nil {
+		t.Fatalf("Expected a missing file to give an empty model, got %v", err)
+	}
+
+	profile := newProfile("Enemy 1")
+	profile.Observations = 3
+	profile.ZoneTicks["A"] = 2
+	model.profiles["Enemy 1"] = profile
+	model.seen["Enemy 1"] = true
+	model.EndMatch()
+
+	if err := model.Save(path); err != nil {
+		t.Fatalf("Expected to save, got %v", err)
+	}
+
+	loaded, err := Load(path, scenario.MyID)
+	if err != nil {
+		t.Fatalf("Expected to load, got %v", err)
+	}
+	saved := loaded.Profile("Enemy 1")
+	if saved == nil || saved.Matches != 1 || saved.Observations != 3 || saved.ZoneTicks["A"] != 2 {
+		t.Fatalf("Expected the profile to survive, got %+v", saved)
+	}
+	if zone, ok := saved.FavouriteZone(); !ok || zone != "A" {
+		t.Errorf("Expected favourite zone A, got %q", zone)
+	}
+	// Loaded profiles keep learning
+	saved.Actions[Forward]++
+}
@@ -0,0 +1,222 @@
+// Package codec converts packets to and from the JSON sent over the WebSocket,
+// with the payload of every packet type decoded into its concrete struct.
+//
+// Decoded packets carry a pointer to the payload struct registered for their
+// type, so a lobbyData packet always carries a *lobby_data.LobbyData and a
+// movement packet a *bot_response.Action. Packet types without a payload
+// carry nil.
+package codec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"hackarena2-0-mono-tanks-go/packet"
+	"hackarena2-0-mono-tanks-go/packet/packets/bot_response"
+	"hackarena2-0-mono-tanks-go/packet/packets/connection_rejected"
+	"hackarena2-0-mono-tanks-go/packet/packets/custom_warning"
+	"hackarena2-0-mono-tanks-go/packet/packets/game_end"
+	"hackarena2-0-mono-tanks-go/packet/packets/game_state"
+	"hackarena2-0-mono-tanks-go/packet/packets/lobby_data"
+)
+
+// ErrMissingPayload is wrapped by MalformedPacketError when a packet type
+// that requires a payload is received without one.
+var ErrMissingPayload = errors.New("missing payload")
+
+// UnknownPacketTypeError is returned for packet types the codec does not know.
+type UnknownPacketTypeError struct {
+	Type packet.PacketType
+}
+
+func (e *UnknownPacketTypeError) Error() string {
+	return fmt.Sprintf("unknown packet type %q", e.Type)
+}
+
+// MalformedPacketError is returned when a packet or its payload cannot be decoded or encoded.
+type MalformedPacketError struct {
+	// Type is the type of the packet, empty if the packet itself is not valid JSON.
+	Type packet.PacketType
+
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *MalformedPacketError) Error() string {
+	if e.Type == "" {
+		return fmt.Sprintf("malformed packet: %v", e.Err)
+	}
+	return fmt.Sprintf("malformed %s packet: %v", e.Type, e.Err)
+}
+
+func (e *MalformedPacketError) Unwrap() error {
+	return e.Err
+}
+
+// payloadDecoder decodes the raw payload of a packet of the given type.
+type payloadDecoder func(packetType packet.PacketType, data json.RawMessage) (interface{}, error)
+
+// payloadCodec describes the payload of a packet type.
+type payloadCodec struct {
+	// payloadType is the struct type of the payload, nil for packet types without a payload.
+	payloadType reflect.Type
+
+	// decode decodes the payload, nil for packet types without a payload.
+	decode payloadDecoder
+}
+
+var codecs = map[packet.PacketType]payloadCodec{
+	packet.Ping: {},
+	packet.Pong: {},
+
+	packet.ConnectionRejected: payloadOf[connection_rejected.ConnectionRejected](),
+	packet.ConnectionAccepted: {},
+
+	packet.LobbyDataPacket:  payloadOf[lobby_data.LobbyData](),
+	packet.LobbyDataRequest: {},
+
+	packet.GameNotStarted: {},
+	packet.GameStarting:   {},
+	packet.GameStarted:    {},
+	packet.GameInProgress: {},
+
+	packet.GameStatusRequest:       {},
+	packet.ReadyToReceiveGameState: {},
+
+	packet.GameStatePacket:  payloadOf[game_state.GameState](),
+	packet.MovementPacket:   actionPayload(),
+	packet.RotationPacket:   actionPayload(),
+	packet.AbilityUsePacket: actionPayload(),
+	packet.PassPacket:       actionPayload(),
+
+	packet.GameEndedPacket: payloadOf[game_end.GameEnd](),
+
+	packet.CustomWarning:                  payloadOf[custom_warning.CustomWarning](),
+	packet.PlayerAlreadyMadeActionWarning: {},
+	packet.ActionIgnoredDueToDeadWarning:  {},
+	packet.SlowResponseWarning:            {},
+
+	packet.InvalidPacketTypeError:  {},
+	packet.InvalidPacketUsageError: {},
+}
+
+// actionResponseTypes maps the action packet types to the response type of their payload.
+var actionResponseTypes = map[packet.PacketType]bot_response.ResponseType{
+	packet.MovementPacket:   bot_response.Movement,
+	packet.RotationPacket:   bot_response.Rotation,
+	packet.AbilityUsePacket: bot_response.AbilityUse,
+	packet.PassPacket:       bot_response.Pass,
+}
+
+// payloadOf returns the codec of a payload decoded straight into T.
+func payloadOf[T any]() payloadCodec {
+	return payloadCodec{
+		payloadType: reflect.TypeOf((*T)(nil)).Elem(),
+		decode: func(packetType packet.PacketType, data json.RawMessage) (interface{}, error) {
+			payload := new(T)
+			if err := json.Unmarshal(data, payload); err != nil {
+				return nil, err
+			}
+			return payload, nil
+		},
+	}
+}
+
+// actionPayload returns the codec of the action packets. The response type
+// is not part of the payload, it is taken from the packet type.
+func actionPayload() payloadCodec {
+	return payloadCodec{
+		payloadType: reflect.TypeOf(bot_response.Action{}),
+		decode: func(packetType packet.PacketType, data json.RawMessage) (interface{}, error) {
+			var raw struct {
+				GameStateID    string `json:"gameStateId"`
+				Direction      string `json:"direction"`
+				TankRotation   string `json:"tankRotation"`
+				TurretRotation string `json:"turretRotation"`
+				AbilityType    string `json:"abilityType"`
+			}
+			if err := json.Unmarshal(data, &raw); err != nil {
+				return nil, err
+			}
+			return &bot_response.Action{
+				GameStateID: raw.GameStateID,
+				Response: bot_response.BotResponse{
+					Type:           actionResponseTypes[packetType],
+					Direction:      raw.Direction,
+					TankRotation:   raw.TankRotation,
+					TurretRotation: raw.TurretRotation,
+					AbilityType:    raw.AbilityType,
+				},
+			}, nil
+		},
+	}
+}
+
+// Decode parses a message received over the WebSocket. The payload of the
+// returned packet is a pointer to the struct registered for its type.
+//
+// It returns a *MalformedPacketError if the message or its payload is not
+// valid, and an *UnknownPacketTypeError if the packet type is not known.
+func Decode(message []byte) (packet.Packet, error) {
+	var raw packet.RawPacket
+	if err := json.Unmarshal(message, &raw); err != nil {
+		return packet.Packet{}, &MalformedPacketError{Err: err}
+	}
+
+	codec, ok := codecs[raw.Type]
+	if !ok {
+		return packet.Packet{Type: raw.Type}, &UnknownPacketTypeError{Type: raw.Type}
+	}
+
+	if codec.decode == nil {
+		return packet.Packet{Type: raw.Type}, nil
+	}
+
+	if len(raw.Payload) == 0 || string(raw.Payload) == "null" {
+		return packet.Packet{Type: raw.Type}, &MalformedPacketError{Type: raw.Type, Err: ErrMissingPayload}
+	}
+
+	payload, err := codec.decode(raw.Type, raw.Payload)
+	if err != nil {
+		return packet.Packet{Type: raw.Type}, &MalformedPacketError{Type: raw.Type, Err: err}
+	}
+
+	return packet.Packet{Type: raw.Type, Payload: payload}, nil
+}
+
+// Encode serializes a packet to be sent over the WebSocket. The payload must be
+// the struct registered for the packet type, or a pointer to it, and nil for
+// packet types without a payload.
+//
+// It returns a *MalformedPacketError if the payload does not match the packet
+// type, and an *UnknownPacketTypeError if the packet type is not known.
+func Encode(p packet.Packet) ([]byte, error) {
+	codec, ok := codecs[p.Type]
+	if !ok {
+		return nil, &UnknownPacketTypeError{Type: p.Type}
+	}
+
+	if codec.payloadType == nil {
+		if p.Payload != nil {
+			return nil, &MalformedPacketError{Type: p.Type, Err: fmt.Errorf("unexpected %T payload", p.Payload)}
+		}
+	} else {
+		payloadType := reflect.TypeOf(p.Payload)
+		if payloadType == codec.payloadType {
+			// Payloads marshal through their pointer, as some MarshalJSON methods have pointer receivers
+			payload := reflect.New(payloadType)
+			payload.Elem().Set(reflect.ValueOf(p.Payload))
+			p.Payload = payload.Interface()
+		} else if payloadType == nil || payloadType != reflect.PointerTo(codec.payloadType) {
+			return nil, &MalformedPacketError{Type: p.Type, Err: fmt.Errorf("expected %v payload, got %T", codec.payloadType, p.Payload)}
+		}
+	}
+
+	message, err := json.Marshal(&p)
+	if err != nil {
+		return nil, &MalformedPacketError{Type: p.Type, Err: err}
+	}
+	return message, nil
+}
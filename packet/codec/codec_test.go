@@ -0,0 +1,250 @@
+package codec_test
+
+import (
+	"errors"
+	"hackarena2-0-mono-tanks-go/packet"
+	"hackarena2-0-mono-tanks-go/packet/codec"
+	"hackarena2-0-mono-tanks-go/packet/packets/bot_response"
+	"hackarena2-0-mono-tanks-go/packet/packets/bot_response/ability"
+	"hackarena2-0-mono-tanks-go/packet/packets/bot_response/movement"
+	"hackarena2-0-mono-tanks-go/packet/packets/bot_response/rotation"
+	"hackarena2-0-mono-tanks-go/packet/packets/connection_rejected"
+	"hackarena2-0-mono-tanks-go/packet/packets/custom_warning"
+	"hackarena2-0-mono-tanks-go/packet/packets/game_end"
+	"hackarena2-0-mono-tanks-go/packet/packets/game_state"
+	"hackarena2-0-mono-tanks-go/packet/packets/lobby_data"
+	"reflect"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected packet.Packet
+	}{
+		{
+			name:     "Ping",
+			input:    `{"type": "ping"}`,
+			expected: packet.Packet{Type: packet.Ping},
+		},
+		{
+			name:     "Payload Of Packet Without Payload Is Ignored",
+			input:    `{"type": "gameStarting", "payload": {"unexpected": true}}`,
+			expected: packet.Packet{Type: packet.GameStarting},
+		},
+		{
+			name:     "Connection Rejected",
+			input:    `{"type": "connectionRejected", "payload": {"reason": "Nickname already exists"}}`,
+			expected: packet.Packet{Type: packet.ConnectionRejected, Payload: &connection_rejected.ConnectionRejected{Reason: "Nickname already exists"}},
+		},
+		{
+			name:  "Lobby Data",
+			input: `{"type": "lobbyData", "payload": {"playerId": "p1", "players": [{"id": "p1", "nickname": "GO1", "color": 1}], "serverSettings": {"gridDimension": 24}}}`,
+			expected: packet.Packet{Type: packet.LobbyDataPacket, Payload: &lobby_data.LobbyData{
+				PlayerID:       "p1",
+				Players:        []lobby_data.LobbyPlayer{{ID: "p1", Nickname: "GO1", Color: 1}},
+				ServerSettings: lobby_data.ServerSettings{GridDimension: 24},
+			}},
+		},
+		{
+			name:  "Game State",
+			input: `{"type": "gameState", "payload": {"id": "s1", "tick": 3, "players": [], "map": {"tiles": [[[{"type": "wall"}]]], "zones": [], "visibility": ["1"]}}}`,
+			expected: packet.Packet{Type: packet.GameStatePacket, Payload: &game_state.GameState{
+				ID:         "s1",
+				Tick:       3,
+				Players:    []game_state.Player{},
+				Zones:      []game_state.Zone{},
+				Walls:      []game_state.Wall{{X: 0, Y: 0}},
+				Visibility: [][]bool{{true}},
+			}},
+		},
+		{
+			name:  "Game Ended",
+			input: `{"type": "gameEnded", "payload": {"players": [{"id": "p1", "score": 10}]}}`,
+			expected: packet.Packet{Type: packet.GameEndedPacket, Payload: &game_end.GameEnd{
+				Players: []game_end.GameEndPlayer{{ID: "p1", Score: 10}},
+			}},
+		},
+		{
+			name:     "Custom Warning",
+			input:    `{"type": "customWarning", "payload": {"message": "Be careful"}}`,
+			expected: packet.Packet{Type: packet.CustomWarning, Payload: &custom_warning.CustomWarning{Message: "Be careful"}},
+		},
+		{
+			name:  "Movement",
+			input: `{"type": "movement", "payload": {"gameStateId": "s1", "direction": "forward"}}`,
+			expected: packet.Packet{Type: packet.MovementPacket, Payload: &bot_response.Action{
+				GameStateID: "s1",
+				Response:    *bot_response.NewMovement(movement.Forward),
+			}},
+		},
+		{
+			name:  "Rotation",
+			input: `{"type": "rotation", "payload": {"gameStateId": "s1", "turretRotation": "left"}}`,
+			expected: packet.Packet{Type: packet.RotationPacket, Payload: &bot_response.Action{
+				GameStateID: "s1",
+				Response:    *bot_response.NewRotation("", rotation.Left),
+			}},
+		},
+		{
+			name:  "Ability Use",
+			input: `{"type": "abilityUse", "payload": {"gameStateId": "s1", "abilityType": "useLaser"}}`,
+			expected: packet.Packet{Type: packet.AbilityUsePacket, Payload: &bot_response.Action{
+				GameStateID: "s1",
+				Response:    *bot_response.NewAbilityUse(ability.UseLaser),
+			}},
+		},
+		{
+			name:  "Pass",
+			input: `{"type": "pass", "payload": {"gameStateId": "s1"}}`,
+			expected: packet.Packet{Type: packet.PassPacket, Payload: &bot_response.Action{
+				GameStateID: "s1",
+				Response:    *bot_response.NewPass(),
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := codec.Decode([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if !reflect.DeepEqual(p, tt.expected) {
+				t.Errorf("Expected packet %+v, got %+v", tt.expected, p)
+			}
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		expectedType packet.PacketType
+		unknown      bool
+		missing      bool
+	}{
+		{name: "Invalid JSON", input: `{"type": "ping"`},
+		{name: "Unknown Type", input: `{"type": "someFuturePacket"}`, expectedType: "someFuturePacket", unknown: true},
+		{name: "Missing Payload", input: `{"type": "lobbyData"}`, expectedType: packet.LobbyDataPacket, missing: true},
+		{name: "Null Payload", input: `{"type": "customWarning", "payload": null}`, expectedType: packet.CustomWarning, missing: true},
+		{name: "Malformed Custom Warning", input: `{"type": "customWarning", "payload": {"message": 1}}`, expectedType: packet.CustomWarning},
+		{name: "Malformed Game State", input: `{"type": "gameState", "payload": {"map": {"tiles": [[[{"type": "portal"}]]]}}}`, expectedType: packet.GameStatePacket},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := codec.Decode([]byte(tt.input))
+			if err == nil {
+				t.Fatalf("Expected an error, got packet %+v", p)
+			}
+			if p.Type != tt.expectedType {
+				t.Errorf("Expected packet type %q, got %q", tt.expectedType, p.Type)
+			}
+
+			var unknownErr *codec.UnknownPacketTypeError
+			if errors.As(err, &unknownErr) != tt.unknown {
+				t.Errorf("Expected UnknownPacketTypeError = %v, got %v", tt.unknown, err)
+			}
+
+			var malformedErr *codec.MalformedPacketError
+			if errors.As(err, &malformedErr) == tt.unknown {
+				t.Errorf("Expected MalformedPacketError = %v, got %v", !tt.unknown, err)
+			}
+
+			if errors.Is(err, codec.ErrMissingPayload) != tt.missing {
+				t.Errorf("Expected ErrMissingPayload = %v, got %v", tt.missing, err)
+			}
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		name         string
+		packet       packet.Packet
+		expectedJSON string
+	}{
+		{
+			name:         "Pong",
+			packet:       packet.Packet{Type: packet.Pong},
+			expectedJSON: `{"type":"pong"}`,
+		},
+		{
+			name:         "Action",
+			packet:       bot_response.NewMovement(movement.Backward).ToPacket("s1"),
+			expectedJSON: `{"type":"movement","payload":{"direction":"backward","gameStateId":"s1"}}`,
+		},
+		{
+			name:         "Payload Passed By Value",
+			packet:       packet.Packet{Type: packet.PassPacket, Payload: bot_response.Action{GameStateID: "s1", Response: *bot_response.NewPass()}},
+			expectedJSON: `{"type":"pass","payload":{"gameStateId":"s1"}}`,
+		},
+		{
+			name:         "Custom Warning",
+			packet:       packet.Packet{Type: packet.CustomWarning, Payload: &custom_warning.CustomWarning{Message: "Be careful"}},
+			expectedJSON: `{"type":"customWarning","payload":{"message":"Be careful"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := codec.Encode(tt.packet)
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if string(data) != tt.expectedJSON {
+				t.Errorf("Expected JSON:\n%s\nGot:\n%s", tt.expectedJSON, string(data))
+			}
+		})
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		packet packet.Packet
+	}{
+		{name: "Unknown Type", packet: packet.Packet{Type: "someFuturePacket"}},
+		{name: "Unexpected Payload", packet: packet.Packet{Type: packet.Ping, Payload: map[string]string{"a": "b"}}},
+		{name: "Missing Payload", packet: packet.Packet{Type: packet.CustomWarning}},
+		{name: "Wrong Payload Type", packet: packet.Packet{Type: packet.LobbyDataPacket, Payload: &game_end.GameEnd{}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if data, err := codec.Encode(tt.packet); err == nil {
+				t.Errorf("Expected an error, got %s", data)
+			}
+		})
+	}
+}
+
+func TestActionRoundTrip(t *testing.T) {
+	responses := []*bot_response.BotResponse{
+		bot_response.NewMovement(movement.Forward),
+		bot_response.NewRotation(rotation.Left, rotation.Right),
+		bot_response.NewRotation("", rotation.Right),
+		bot_response.NewAbilityUse(ability.DropMine),
+		bot_response.NewPass(),
+	}
+
+	for _, response := range responses {
+		data, err := codec.Encode(response.ToPacket("s1"))
+		if err != nil {
+			t.Fatalf("Encode() error = %v", err)
+		}
+
+		p, err := codec.Decode(data)
+		if err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+
+		action := p.Payload.(*bot_response.Action)
+		if action.GameStateID != "s1" || action.Response != *response {
+			t.Errorf("Expected %+v for game state s1, got %+v", *response, *action)
+		}
+	}
+}
@@ -46,6 +46,13 @@ type Packet struct {
 	Payload interface{} `json:"payload,omitempty"`
 }
 
+// RawPacket is a received packet whose payload is kept as raw JSON,
+// so it can be decoded once, straight into the payload type matching the packet type.
+type RawPacket struct {
+	Type    PacketType      `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
 func (p *Packet) MarshalJSON() ([]byte, error) {
 	type Alias Packet
 	return json.Marshal(&struct {
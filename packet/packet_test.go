@@ -343,3 +343,26 @@ func TestPacketUnmarshalJSONWithGameEnd(t *testing.T) {
 		t.Fatalf("Expected player2 score to be 20, got %v", player2.Score)
 	}
 }
+
+func TestRawPacketUnmarshalJSON(t *testing.T) {
+	input := `{"type": "customWarning", "payload": {"message": "Be careful"}}`
+
+	var p packet.RawPacket
+	if err := json.Unmarshal([]byte(input), &p); err != nil {
+		t.Fatalf("Error unmarshalling packet: %v", err)
+	}
+	if p.Type != packet.CustomWarning {
+		t.Fatalf("Expected type %v, got %v", packet.CustomWarning, p.Type)
+	}
+	if string(p.Payload) != `{"message": "Be careful"}` {
+		t.Fatalf("Expected raw payload to be kept as sent, got %s", p.Payload)
+	}
+
+	var withoutPayload packet.RawPacket
+	if err := json.Unmarshal([]byte(`{"type": "ping"}`), &withoutPayload); err != nil {
+		t.Fatalf("Error unmarshalling packet: %v", err)
+	}
+	if withoutPayload.Payload != nil {
+		t.Fatalf("Expected payload to be nil, got %s", withoutPayload.Payload)
+	}
+}
@@ -0,0 +1,42 @@
+package bot_response
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Action is the payload of the packet sent in response to a game state.
+type Action struct {
+	// GameStateID is the ID of the game state the action responds to.
+	GameStateID string
+
+	// Response is the action chosen by the bot.
+	Response BotResponse
+}
+
+// MarshalJSON customizes the JSON representation of the Action type.
+// The fields of the response are written next to the game state ID.
+func (a *Action) MarshalJSON() ([]byte, error) {
+	payload := map[string]string{
+		"gameStateId": a.GameStateID,
+	}
+
+	switch a.Response.Type {
+	case Movement:
+		payload["direction"] = a.Response.Direction
+	case Rotation:
+		if a.Response.TankRotation != "" {
+			payload["tankRotation"] = a.Response.TankRotation
+		}
+		if a.Response.TurretRotation != "" {
+			payload["turretRotation"] = a.Response.TurretRotation
+		}
+	case AbilityUse:
+		payload["abilityType"] = a.Response.AbilityType
+	case Pass:
+	default:
+		return nil, errors.New("invalid response type")
+	}
+
+	return json.Marshal(payload)
+}
@@ -155,48 +155,29 @@ func hasKey(m map[string]json.RawMessage, key string) bool {
 	return ok
 }
 
+// ToPacket wraps the response into the packet sent to the server in response to the given game state.
 func (ar BotResponse) ToPacket(gameStateID string) packet.Packet {
+	var packetType packet.PacketType
 	switch ar.Type {
 	case Movement:
-		return packet.Packet{
-			Type: packet.MovementPacket,
-			Payload: map[string]interface{}{
-				"gameStateId": gameStateID,
-				"direction":   ar.Direction,
-			},
-		}
+		packetType = packet.MovementPacket
 	case Rotation:
-		payload := map[string]interface{}{
-			"gameStateId": gameStateID,
-		}
-		if ar.TankRotation != "" {
-			payload["tankRotation"] = ar.TankRotation
-		}
-		if ar.TurretRotation != "" {
-			payload["turretRotation"] = ar.TurretRotation
-		}
-		return packet.Packet{
-			Type:    packet.RotationPacket,
-			Payload: payload,
-		}
+		packetType = packet.RotationPacket
 	case AbilityUse:
-		return packet.Packet{
-			Type: packet.AbilityUsePacket,
-			Payload: map[string]interface{}{
-				"gameStateId": gameStateID,
-				"abilityType": ar.AbilityType,
-			},
-		}
+		packetType = packet.AbilityUsePacket
 	case Pass:
-		return packet.Packet{
-			Type: packet.PassPacket,
-			Payload: map[string]interface{}{
-				"gameStateId": gameStateID,
-			},
-		}
+		packetType = packet.PassPacket
 	default:
 		return packet.Packet{
 			Type: packet.InvalidPacketTypeError,
 		}
 	}
+
+	return packet.Packet{
+		Type: packetType,
+		Payload: &Action{
+			GameStateID: gameStateID,
+			Response:    ar,
+		},
+	}
 }
@@ -0,0 +1,7 @@
+package connection_rejected
+
+// ConnectionRejected represents the reason the server rejected the connection.
+type ConnectionRejected struct {
+	// Reason is the explanation sent by the server, e.g. that the nickname is already taken.
+	Reason string `json:"reason"`
+}
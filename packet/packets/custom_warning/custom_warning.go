@@ -0,0 +1,7 @@
+package custom_warning
+
+// CustomWarning represents a warning with a custom message sent by the server.
+type CustomWarning struct {
+	// Message is the content of the warning.
+	Message string `json:"message"`
+}
@@ -0,0 +1,8 @@
+package direction
+
+const (
+	Up    = "up"
+	Right = "right"
+	Down  = "down"
+	Left  = "left"
+)
@@ -41,6 +41,116 @@ type GameState struct {
 	Mines []Mine
 }
 
+// Tank returns the tank of the player with ownerID, or nil if the game state has none.
+func (gameState *GameState) Tank(ownerID string) *Tank {
+	for i := range gameState.Tanks {
+		if gameState.Tanks[i].OwnerID == ownerID {
+			return &gameState.Tanks[i]
+		}
+	}
+	return nil
+}
+
+// Player returns the player with the ID, or nil if the game state has none.
+func (gameState *GameState) Player(id string) *Player {
+	for i := range gameState.Players {
+		if gameState.Players[i].ID == id {
+			return &gameState.Players[i]
+		}
+	}
+	return nil
+}
+
+// Bullet returns the bullet with the ID, or nil if the game state has none.
+func (gameState *GameState) Bullet(id int) *Bullet {
+	for i := range gameState.Bullets {
+		if gameState.Bullets[i].ID == id {
+			return &gameState.Bullets[i]
+		}
+	}
+	return nil
+}
+
+// Laser returns the laser with the ID, or nil if the game state has none.
+func (gameState *GameState) Laser(id int) *Laser {
+	for i := range gameState.Lasers {
+		if gameState.Lasers[i].ID == id {
+			return &gameState.Lasers[i]
+		}
+	}
+	return nil
+}
+
+// Clone returns a deep copy of the game state, sharing no slices or pointers with it.
+func (gameState *GameState) Clone() *GameState {
+	clone := *gameState
+
+	clone.Tanks = cloneSlice(gameState.Tanks)
+	for i := range clone.Tanks {
+		tank := &clone.Tanks[i]
+		tank.Health = clonePointer(tank.Health)
+		tank.SecondaryItem = clonePointer(tank.SecondaryItem)
+		tank.Turret.BulletCount = clonePointer(tank.Turret.BulletCount)
+		tank.Turret.TicksToRegenBullet = clonePointer(tank.Turret.TicksToRegenBullet)
+	}
+
+	clone.Players = cloneSlice(gameState.Players)
+	for i := range clone.Players {
+		player := &clone.Players[i]
+		player.Ping = clonePointer(player.Ping)
+		player.Score = clonePointer(player.Score)
+		player.TicksToRegen = clonePointer(player.TicksToRegen)
+		player.IsUsingRadar = clonePointer(player.IsUsingRadar)
+	}
+
+	clone.Zones = cloneSlice(gameState.Zones)
+	for i := range clone.Zones {
+		status := &clone.Zones[i].Status
+		status.BeingCaptured = clonePointer(status.BeingCaptured)
+		status.Captured = clonePointer(status.Captured)
+		status.BeingContested = clonePointer(status.BeingContested)
+		if status.BeingContested != nil {
+			status.BeingContested.CapturedByID = clonePointer(status.BeingContested.CapturedByID)
+		}
+		status.BeingRetaken = clonePointer(status.BeingRetaken)
+	}
+
+	if gameState.Visibility != nil {
+		clone.Visibility = make([][]bool, len(gameState.Visibility))
+		for i, row := range gameState.Visibility {
+			clone.Visibility[i] = cloneSlice(row)
+		}
+	}
+
+	clone.Walls = cloneSlice(gameState.Walls)
+	clone.Bullets = cloneSlice(gameState.Bullets)
+	clone.Items = cloneSlice(gameState.Items)
+	clone.Lasers = cloneSlice(gameState.Lasers)
+	clone.Mines = cloneSlice(gameState.Mines)
+	for i := range clone.Mines {
+		clone.Mines[i].ExplosionRemainingTicks = clonePointer(clone.Mines[i].ExplosionRemainingTicks)
+	}
+
+	return &clone
+}
+
+// cloneSlice returns a copy of the slice, keeping nil slices nil.
+func cloneSlice[T any](slice []T) []T {
+	if slice == nil {
+		return nil
+	}
+	return append(make([]T, 0, len(slice)), slice...)
+}
+
+// clonePointer returns a pointer to a copy of the value, or nil for nil.
+func clonePointer[T any](pointer *T) *T {
+	if pointer == nil {
+		return nil
+	}
+	value := *pointer
+	return &value
+}
+
 // RawTank represents the raw JSON structure of a tank.
 type RawTank struct {
 	// The direction the tank is facing. "up", "right", "down", or "left".
@@ -205,6 +315,75 @@ type ZoneStatus struct {
 	BeingRetaken *BeingRetakenStatus `json:"beingRetaken,omitempty"`
 }
 
+// UnmarshalJSON custom unmarshals the JSON data into a ZoneStatus object.
+// The server sends the fields of the status variant next to its type,
+// so they are decoded into the variant matching the type.
+func (status *ZoneStatus) UnmarshalJSON(data []byte) error {
+	var rawStatus struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &rawStatus); err != nil {
+		return err
+	}
+
+	*status = ZoneStatus{Type: rawStatus.Type}
+
+	switch rawStatus.Type {
+	case "beingCaptured":
+		status.BeingCaptured = &BeingCapturedStatus{}
+		return json.Unmarshal(data, status.BeingCaptured)
+	case "captured":
+		status.Captured = &CapturedStatus{}
+		return json.Unmarshal(data, status.Captured)
+	case "beingContested":
+		status.BeingContested = &BeingContestedStatus{}
+		return json.Unmarshal(data, status.BeingContested)
+	case "beingRetaken":
+		status.BeingRetaken = &BeingRetakenStatus{}
+		return json.Unmarshal(data, status.BeingRetaken)
+	}
+
+	return nil
+}
+
+// MarshalJSON custom marshals the ZoneStatus object into the JSON format sent by the server:
+// the fields of the status variant in their declared order, followed by its type.
+// Optional fields the server leaves empty are written as null.
+func (status ZoneStatus) MarshalJSON() ([]byte, error) {
+	var variant interface{}
+	switch {
+	case status.BeingCaptured != nil:
+		variant = status.BeingCaptured
+	case status.Captured != nil:
+		variant = status.Captured
+	case status.BeingContested != nil:
+		variant = status.BeingContested
+	case status.BeingRetaken != nil:
+		variant = status.BeingRetaken
+	default:
+		variant = struct{}{}
+	}
+
+	variantBytes, err := json.Marshal(variant)
+	if err != nil {
+		return nil, err
+	}
+
+	typeBytes, err := json.Marshal(status.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	// The variant is a JSON object, so the type goes in before its closing brace
+	data := variantBytes[:len(variantBytes)-1]
+	if len(data) > 1 {
+		data = append(data, ',')
+	}
+	data = append(data, `"type":`...)
+	data = append(data, typeBytes...)
+	return append(data, '}'), nil
+}
+
 // BeingCapturedStatus represents the status of a zone being captured.
 type BeingCapturedStatus struct {
 	// The remaining ticks until the zone is captured.
@@ -223,7 +402,7 @@ type CapturedStatus struct {
 // BeingContestedStatus represents the status of a zone being contested.
 type BeingContestedStatus struct {
 	// The ID of the player who captured the zone, if any.
-	CapturedByID *string `json:"capturedById,omitempty"`
+	CapturedByID *string `json:"capturedById"`
 }
 
 // BeingRetakenStatus represents the status of a zone being retaken.
@@ -291,14 +470,14 @@ type rawGameState struct {
 	// A slice of Player objects representing all the players in the game.
 	Players []Player `json:"players"`
 
-	// The raw JSON message for the map data.
-	Map json.RawMessage `json:"map"`
+	// The map data.
+	Map rawMap `json:"map"`
 }
 
 // rawMap is a custom struct to unmarshal the map data.
 type rawMap struct {
-	// A 3D slice representing the tiles in the map.
-	Tiles [][][]json.RawMessage `json:"tiles"`
+	// A 3D slice representing the tiles in the map, indexed by x and then y.
+	Tiles [][][]rawMapTile `json:"tiles"`
 
 	// A slice of Zone objects representing different zones in the game.
 	Zones []Zone `json:"zones"`
@@ -307,133 +486,124 @@ type rawMap struct {
 	Visibility []string `json:"visibility"`
 }
 
+// rawMapTile is a single object lying on a tile, decoded in one pass.
+type rawMapTile struct {
+	// The type of the object. Can be "wall", "tank", "bullet", "item", "laser", or "mine".
+	Type string `json:"type"`
+
+	// The payload of the object, with the fields of every object type.
+	Payload rawTilePayload `json:"payload"`
+}
+
+// rawTilePayload holds the payload fields of every tile object type,
+// so a tile can be decoded without knowing its type up front.
+type rawTilePayload struct {
+	// The direction of a tank or bullet.
+	Direction string `json:"direction"`
+
+	// The health of a tank.
+	Health *int `json:"health"`
+
+	// The ID of the player who owns a tank.
+	OwnerID string `json:"ownerId"`
+
+	// The turret of a tank.
+	Turret Turret `json:"turret"`
+
+	// The secondary item a tank is carrying.
+	SecondaryItem *string `json:"secondaryItem"`
+
+	// The unique identifier for a bullet, laser or mine.
+	ID int `json:"id"`
+
+	// The speed of a bullet.
+	Speed float64 `json:"speed"`
+
+	// The type of a bullet or item.
+	Type string `json:"type"`
+
+	// The orientation of a laser.
+	Orientation string `json:"orientation"`
+
+	// The number of ticks remaining until a mine explodes.
+	ExplosionRemainingTicks *int `json:"explosionRemainingTicks"`
+}
+
 // UnmarshalJSON custom unmarshals the JSON data into a GameState object.
+// The whole game state, including every tile payload, is decoded in a single pass.
+// Only the first object lying on a tile is taken into account.
 func (gameState *GameState) UnmarshalJSON(data []byte) error {
 	var raw rawGameState
 	if err := json.Unmarshal(data, &raw); err != nil {
 		return err
 	}
 
-	gameState.ID = raw.ID
-	gameState.Tick = raw.Tick
-	gameState.Players = raw.Players
-
-	var rawMapData rawMap
-	if err := json.Unmarshal(raw.Map, &rawMapData); err != nil {
-		return err
+	*gameState = GameState{
+		ID:      raw.ID,
+		Tick:    raw.Tick,
+		Players: raw.Players,
+		Zones:   raw.Map.Zones,
 	}
 
-	gameState.Zones = rawMapData.Zones
-
 	// Process tiles
-	for x, column := range rawMapData.Tiles {
+	for x, column := range raw.Map.Tiles {
 		for y, cell := range column {
-			if len(cell) > 0 {
-				var tileType struct {
-					Type string `json:"type"`
-				}
-				if err := json.Unmarshal(cell[0], &tileType); err != nil {
-					return err
-				}
-
-				switch tileType.Type {
-				case "wall":
-					gameState.Walls = append(gameState.Walls, Wall{X: x, Y: y})
-				case "tank":
-					var rawTank struct {
-						Payload RawTank `json:"payload"`
-					}
-					if err := json.Unmarshal(cell[0], &rawTank); err != nil {
-						return err
-					}
-					tank := Tank{
-						X:             x,
-						Y:             y,
-						Direction:     rawTank.Payload.Direction,
-						Health:        rawTank.Payload.Health,
-						OwnerID:       rawTank.Payload.OwnerID,
-						Turret:        rawTank.Payload.Turret,
-						SecondaryItem: rawTank.Payload.SecondaryItem,
-					}
-					gameState.Tanks = append(gameState.Tanks, tank)
-				case "bullet":
-					var rawBullet struct {
-						Payload struct {
-							RawBullet
-							Type string `json:"type"`
-						} `json:"payload"`
-					}
-					if err := json.Unmarshal(cell[0], &rawBullet); err != nil {
-						return err
-					}
-					bullet := Bullet{
-						X:         x,
-						Y:         y,
-						Direction: rawBullet.Payload.Direction,
-						ID:        rawBullet.Payload.ID,
-						Speed:     rawBullet.Payload.Speed,
-						Type:      rawBullet.Payload.Type,
-					}
-					gameState.Bullets = append(gameState.Bullets, bullet)
-				case "item":
-					var rawItem struct {
-						Payload struct {
-							Type string `json:"type"`
-						} `json:"payload"`
-					}
-					if err := json.Unmarshal(cell[0], &rawItem); err != nil {
-						return err
-					}
-					item := Item{
-						X:    x,
-						Y:    y,
-						Type: rawItem.Payload.Type,
-					}
-					gameState.Items = append(gameState.Items, item)
-				case "laser":
-					var rawLaser struct {
-						Payload struct {
-							ID          int    `json:"id"`
-							Orientation string `json:"orientation"`
-						} `json:"payload"`
-					}
-					if err := json.Unmarshal(cell[0], &rawLaser); err != nil {
-						return err
-					}
-					laser := Laser{
-						X:           x,
-						Y:           y,
-						ID:          rawLaser.Payload.ID,
-						Orientation: rawLaser.Payload.Orientation,
-					}
-					gameState.Lasers = append(gameState.Lasers, laser)
-				case "mine":
-					var rawMine struct {
-						Payload struct {
-							ID                      int  `json:"id"`
-							ExplosionRemainingTicks *int `json:"explosionRemainingTicks"`
-						} `json:"payload"`
-					}
-					if err := json.Unmarshal(cell[0], &rawMine); err != nil {
-						return err
-					}
-					mine := Mine{
-						X:                       x,
-						Y:                       y,
-						ID:                      rawMine.Payload.ID,
-						ExplosionRemainingTicks: rawMine.Payload.ExplosionRemainingTicks,
-					}
-					gameState.Mines = append(gameState.Mines, mine)
-				default:
-					return fmt.Errorf("unknown tile type: %s", tileType.Type)
-				}
+			if len(cell) == 0 {
+				continue
+			}
+
+			payload := &cell[0].Payload
+			switch cell[0].Type {
+			case "wall":
+				gameState.Walls = append(gameState.Walls, Wall{X: x, Y: y})
+			case "tank":
+				gameState.Tanks = append(gameState.Tanks, Tank{
+					X:             x,
+					Y:             y,
+					Direction:     payload.Direction,
+					Health:        payload.Health,
+					OwnerID:       payload.OwnerID,
+					Turret:        payload.Turret,
+					SecondaryItem: payload.SecondaryItem,
+				})
+			case "bullet":
+				gameState.Bullets = append(gameState.Bullets, Bullet{
+					X:         x,
+					Y:         y,
+					Direction: payload.Direction,
+					ID:        payload.ID,
+					Speed:     payload.Speed,
+					Type:      payload.Type,
+				})
+			case "item":
+				gameState.Items = append(gameState.Items, Item{
+					X:    x,
+					Y:    y,
+					Type: payload.Type,
+				})
+			case "laser":
+				gameState.Lasers = append(gameState.Lasers, Laser{
+					X:           x,
+					Y:           y,
+					ID:          payload.ID,
+					Orientation: payload.Orientation,
+				})
+			case "mine":
+				gameState.Mines = append(gameState.Mines, Mine{
+					X:                       x,
+					Y:                       y,
+					ID:                      payload.ID,
+					ExplosionRemainingTicks: payload.ExplosionRemainingTicks,
+				})
+			default:
+				return fmt.Errorf("unknown tile type: %s", cell[0].Type)
 			}
 		}
 	}
 
 	// Process visibility
-	gameState.Visibility = make([][]bool, len(rawMapData.Visibility))
-	for y, row := range rawMapData.Visibility {
+	gameState.Visibility = make([][]bool, len(raw.Map.Visibility))
+	for y, row := range raw.Map.Visibility {
 		gameState.Visibility[y] = make([]bool, len(row))
 		for x, cell := range row {
 			gameState.Visibility[y][x] = cell == '1'
@@ -442,3 +612,178 @@ func (gameState *GameState) UnmarshalJSON(data []byte) error {
 
 	return nil
 }
+
+// encodedTile is a single object lying on a tile, as written by MarshalJSON.
+type encodedTile struct {
+	// The type of the object. Can be "wall", "tank", "bullet", "item", "laser", or "mine".
+	Type string `json:"type"`
+
+	// The payload of the object. It is nil for walls.
+	Payload interface{} `json:"payload,omitempty"`
+}
+
+// encodedGameState is the JSON structure of the game state written by MarshalJSON.
+type encodedGameState struct {
+	ID      string     `json:"id"`
+	Tick    uint64     `json:"tick"`
+	Players []Player   `json:"players"`
+	Map     encodedMap `json:"map"`
+}
+
+// encodedMap is the JSON structure of the map written by MarshalJSON.
+type encodedMap struct {
+	Tiles      [][][]encodedTile `json:"tiles"`
+	Zones      []Zone            `json:"zones"`
+	Visibility []string          `json:"visibility"`
+}
+
+// MarshalJSON custom marshals the GameState object into the JSON format sent by the server.
+// Objects sharing a tile are written in the order: walls, tanks, bullets, lasers, mines and items.
+// UnmarshalJSON only keeps the first object of a tile, so a game state with stacked objects
+// loses all but the first of them when it is decoded again.
+func (gameState GameState) MarshalJSON() ([]byte, error) {
+	width, height := gameState.mapSize()
+
+	tiles := make([][][]encodedTile, width)
+	for x := range tiles {
+		tiles[x] = make([][]encodedTile, height)
+		for y := range tiles[x] {
+			tiles[x][y] = []encodedTile{}
+		}
+	}
+
+	addTile := func(x, y int, tile encodedTile) error {
+		if x < 0 || y < 0 {
+			return fmt.Errorf("%s at negative coordinates (%d, %d)", tile.Type, x, y)
+		}
+		tiles[x][y] = append(tiles[x][y], tile)
+		return nil
+	}
+
+	for _, wall := range gameState.Walls {
+		if err := addTile(wall.X, wall.Y, encodedTile{Type: "wall"}); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, tank := range gameState.Tanks {
+		payload := RawTank{
+			Direction:     tank.Direction,
+			Health:        tank.Health,
+			OwnerID:       tank.OwnerID,
+			Turret:        tank.Turret,
+			SecondaryItem: tank.SecondaryItem,
+		}
+		if err := addTile(tank.X, tank.Y, encodedTile{Type: "tank", Payload: payload}); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, bullet := range gameState.Bullets {
+		payload := RawBullet{
+			Direction: bullet.Direction,
+			ID:        bullet.ID,
+			Speed:     bullet.Speed,
+			Type:      bullet.Type,
+		}
+		if err := addTile(bullet.X, bullet.Y, encodedTile{Type: "bullet", Payload: payload}); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, laser := range gameState.Lasers {
+		payload := struct {
+			ID          int    `json:"id"`
+			Orientation string `json:"orientation"`
+		}{laser.ID, laser.Orientation}
+		if err := addTile(laser.X, laser.Y, encodedTile{Type: "laser", Payload: payload}); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, mine := range gameState.Mines {
+		payload := struct {
+			ID                      int  `json:"id"`
+			ExplosionRemainingTicks *int `json:"explosionRemainingTicks"`
+		}{mine.ID, mine.ExplosionRemainingTicks}
+		if err := addTile(mine.X, mine.Y, encodedTile{Type: "mine", Payload: payload}); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, item := range gameState.Items {
+		payload := struct {
+			Type string `json:"type"`
+		}{item.Type}
+		if err := addTile(item.X, item.Y, encodedTile{Type: "item", Payload: payload}); err != nil {
+			return nil, err
+		}
+	}
+
+	visibility := make([]string, len(gameState.Visibility))
+	for y, row := range gameState.Visibility {
+		cells := make([]byte, len(row))
+		for x, visible := range row {
+			cells[x] = '0'
+			if visible {
+				cells[x] = '1'
+			}
+		}
+		visibility[y] = string(cells)
+	}
+
+	players := gameState.Players
+	if players == nil {
+		players = []Player{}
+	}
+
+	zones := gameState.Zones
+	if zones == nil {
+		zones = []Zone{}
+	}
+
+	return json.Marshal(encodedGameState{
+		ID:      gameState.ID,
+		Tick:    gameState.Tick,
+		Players: players,
+		Map: encodedMap{
+			Tiles:      tiles,
+			Zones:      zones,
+			Visibility: visibility,
+		},
+	})
+}
+
+// mapSize returns the number of tile columns and rows needed to hold the visibility map
+// and every object in the game state.
+func (gameState *GameState) mapSize() (width int, height int) {
+	height = len(gameState.Visibility)
+	for _, row := range gameState.Visibility {
+		width = max(width, len(row))
+	}
+
+	include := func(x, y int) {
+		width = max(width, x+1)
+		height = max(height, y+1)
+	}
+	for _, wall := range gameState.Walls {
+		include(wall.X, wall.Y)
+	}
+	for _, tank := range gameState.Tanks {
+		include(tank.X, tank.Y)
+	}
+	for _, bullet := range gameState.Bullets {
+		include(bullet.X, bullet.Y)
+	}
+	for _, laser := range gameState.Lasers {
+		include(laser.X, laser.Y)
+	}
+	for _, mine := range gameState.Mines {
+		include(mine.X, mine.Y)
+	}
+	for _, item := range gameState.Items {
+		include(item.X, item.Y)
+	}
+
+	return width, height
+}
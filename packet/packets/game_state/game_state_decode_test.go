@@ -0,0 +1,367 @@
+package game_state
+
+import (
+	"encoding/json"
+	"fmt"
+	"hackarena2-0-mono-tanks-go/packet"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestUnmarshalJSONMatchesLegacy(t *testing.T) {
+	inputs := []string{
+		// Objects stacked on a tile, only the first one is decoded
+		`{"id":"a","tick":1,"players":[],"map":{"tiles":[[[{"type":"mine","payload":{"id":3,"explosionRemainingTicks":null}},{"type":"tank","payload":{"ownerId":"p1","direction":"up","turret":{"direction":"up"}}}],[]]],"zones":[],"visibility":["1","1"]}}`,
+		// Unknown fields are ignored
+		`{"id":"b","tick":2,"extra":true,"players":[{"id":"p1","nickname":"n","color":1,"extra":1}],"map":{"tiles":[[[{"type":"item","payload":{"type":"radar","extra":1}}]]],"zones":[],"visibility":["1"]}}`,
+		// Missing map parts
+		`{"id":"c","tick":3,"players":null,"map":{}}`,
+	}
+
+	for seed := int64(0); seed < 200; seed++ {
+		data, err := json.Marshal(randomGameState(rand.New(rand.NewSource(seed))))
+		if err != nil {
+			t.Fatalf("seed %d: MarshalJSON() error = %v", seed, err)
+		}
+		inputs = append(inputs, string(data))
+	}
+
+	for i, input := range inputs {
+		var expected, got GameState
+		if err := legacyUnmarshalJSON([]byte(input), &expected); err != nil {
+			t.Fatalf("input %d: legacy decoder error = %v", i, err)
+		}
+		if err := json.Unmarshal([]byte(input), &got); err != nil {
+			t.Fatalf("input %d: UnmarshalJSON() error = %v", i, err)
+		}
+		if !reflect.DeepEqual(got, expected) {
+			t.Fatalf("input %d: decoders disagree\nexpected: %+v\ngot:      %+v", i, expected, got)
+		}
+	}
+}
+
+func TestUnmarshalJSONUnknownTileType(t *testing.T) {
+	input := `{"id":"a","tick":1,"players":[],"map":{"tiles":[[[{"type":"portal"}]]],"zones":[],"visibility":["1"]}}`
+
+	var gameState GameState
+	if err := json.Unmarshal([]byte(input), &gameState); err == nil {
+		t.Errorf("Expected an error for an unknown tile type")
+	}
+}
+
+func TestUnmarshalJSONResetsGameState(t *testing.T) {
+	input := `{"id":"a","tick":1,"players":[],"map":{"tiles":[[[{"type":"wall"}]]],"zones":[],"visibility":["1"]}}`
+
+	var gameState GameState
+	for i := 0; i < 2; i++ {
+		if err := json.Unmarshal([]byte(input), &gameState); err != nil {
+			t.Fatalf("UnmarshalJSON() error = %v", err)
+		}
+	}
+	if len(gameState.Walls) != 1 {
+		t.Errorf("Expected 1 wall after decoding twice into the same game state, got %d", len(gameState.Walls))
+	}
+}
+
+func BenchmarkUnmarshalJSON(b *testing.B) {
+	data := benchmarkGameStateJSON(b)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var gameState GameState
+		if err := json.Unmarshal(data, &gameState); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLegacyUnmarshalJSON(b *testing.B) {
+	data := benchmarkGameStateJSON(b)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var gameState GameState
+		if err := legacyUnmarshalJSON(data, &gameState); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkDecodePacket measures decoding a whole gameState packet the way the client does.
+func BenchmarkDecodePacket(b *testing.B) {
+	message := benchmarkPacketJSON(b)
+	b.SetBytes(int64(len(message)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var p packet.RawPacket
+		if err := json.Unmarshal(message, &p); err != nil {
+			b.Fatal(err)
+		}
+		var gameState GameState
+		if err := json.Unmarshal(p.Payload, &gameState); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkLegacyDecodePacket measures decoding a whole gameState packet the way the client did
+// before: into an interface{} payload, marshalled again and decoded with the legacy decoder.
+func BenchmarkLegacyDecodePacket(b *testing.B) {
+	message := benchmarkPacketJSON(b)
+	b.SetBytes(int64(len(message)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var p packet.Packet
+		if err := json.Unmarshal(message, &p); err != nil {
+			b.Fatal(err)
+		}
+		payloadBytes, err := json.Marshal(p.Payload)
+		if err != nil {
+			b.Fatal(err)
+		}
+		var gameState GameState
+		if err := legacyUnmarshalJSON(payloadBytes, &gameState); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchmarkGameStateJSON returns a game state on a 24x24 map with four tanks,
+// a fifth of the tiles covered with walls and a few bullets, items and mines.
+func benchmarkGameStateJSON(b *testing.B) []byte {
+	const dimension = 24
+
+	r := rand.New(rand.NewSource(1))
+	gameState := GameState{
+		ID:         "36af2d82-40df-4332-9138-1bb0e6b09fcb",
+		Tick:       110,
+		Visibility: make([][]bool, dimension),
+	}
+
+	for i := 0; i < 4; i++ {
+		playerID := fmt.Sprintf("player-%d", i)
+		gameState.Players = append(gameState.Players, Player{ID: playerID, Nickname: playerID, Ping: uint64Ptr(1), Score: uint64Ptr(10)})
+	}
+
+	for x := 0; x < dimension; x++ {
+		for y := 0; y < dimension; y++ {
+			switch n := r.Intn(100); {
+			case n < 20:
+				gameState.Walls = append(gameState.Walls, Wall{X: x, Y: y})
+			case n < 22 && len(gameState.Tanks) < 4:
+				gameState.Tanks = append(gameState.Tanks, Tank{
+					X:         x,
+					Y:         y,
+					Direction: "up",
+					Health:    intPtr(100),
+					OwnerID:   fmt.Sprintf("player-%d", len(gameState.Tanks)),
+					Turret:    Turret{Direction: "left", BulletCount: intPtr(3), TicksToRegenBullet: intPtr(0)},
+				})
+			case n < 25:
+				gameState.Bullets = append(gameState.Bullets, Bullet{X: x, Y: y, Direction: "down", ID: x*dimension + y, Speed: 2, Type: "basic"})
+			case n < 26:
+				gameState.Items = append(gameState.Items, Item{X: x, Y: y, Type: "laser"})
+			case n < 27:
+				gameState.Mines = append(gameState.Mines, Mine{X: x, Y: y, ID: x*dimension + y})
+			}
+		}
+	}
+
+	for y := range gameState.Visibility {
+		gameState.Visibility[y] = make([]bool, dimension)
+		for x := range gameState.Visibility[y] {
+			gameState.Visibility[y][x] = r.Intn(2) == 0
+		}
+	}
+
+	data, err := json.Marshal(gameState)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return data
+}
+
+func benchmarkPacketJSON(b *testing.B) []byte {
+	message, err := json.Marshal(packet.RawPacket{
+		Type:    packet.GameStatePacket,
+		Payload: benchmarkGameStateJSON(b),
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return message
+}
+
+// legacyRawGameState is the struct the legacy decoder used to unmarshal the JSON data for the game state.
+type legacyRawGameState struct {
+	// A unique identifier for the game state.
+	ID string `json:"id"`
+
+	// The current tick of the game.
+	Tick uint64 `json:"tick"`
+
+	// A slice of Player objects representing all the players in the game.
+	Players []Player `json:"players"`
+
+	// The raw JSON message for the map data.
+	Map json.RawMessage `json:"map"`
+}
+
+// legacyRawMap is the struct the legacy decoder used to unmarshal the map data.
+type legacyRawMap struct {
+	// A 3D slice representing the tiles in the map.
+	Tiles [][][]json.RawMessage `json:"tiles"`
+
+	// A slice of Zone objects representing different zones in the game.
+	Zones []Zone `json:"zones"`
+
+	// A slice of strings representing the visibility map.
+	Visibility []string `json:"visibility"`
+}
+
+// legacyUnmarshalJSON is the decoder used before the single pass UnmarshalJSON.
+// It is kept as the reference the current decoder must agree with, and as the
+// baseline for the benchmarks.
+func legacyUnmarshalJSON(data []byte, gameState *GameState) error {
+	var raw legacyRawGameState
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	gameState.ID = raw.ID
+	gameState.Tick = raw.Tick
+	gameState.Players = raw.Players
+
+	var rawMapData legacyRawMap
+	if err := json.Unmarshal(raw.Map, &rawMapData); err != nil {
+		return err
+	}
+
+	gameState.Zones = rawMapData.Zones
+
+	// Process tiles
+	for x, column := range rawMapData.Tiles {
+		for y, cell := range column {
+			if len(cell) > 0 {
+				var tileType struct {
+					Type string `json:"type"`
+				}
+				if err := json.Unmarshal(cell[0], &tileType); err != nil {
+					return err
+				}
+
+				switch tileType.Type {
+				case "wall":
+					gameState.Walls = append(gameState.Walls, Wall{X: x, Y: y})
+				case "tank":
+					var rawTank struct {
+						Payload RawTank `json:"payload"`
+					}
+					if err := json.Unmarshal(cell[0], &rawTank); err != nil {
+						return err
+					}
+					tank := Tank{
+						X:             x,
+						Y:             y,
+						Direction:     rawTank.Payload.Direction,
+						Health:        rawTank.Payload.Health,
+						OwnerID:       rawTank.Payload.OwnerID,
+						Turret:        rawTank.Payload.Turret,
+						SecondaryItem: rawTank.Payload.SecondaryItem,
+					}
+					gameState.Tanks = append(gameState.Tanks, tank)
+				case "bullet":
+					var rawBullet struct {
+						Payload struct {
+							RawBullet
+							Type string `json:"type"`
+						} `json:"payload"`
+					}
+					if err := json.Unmarshal(cell[0], &rawBullet); err != nil {
+						return err
+					}
+					bullet := Bullet{
+						X:         x,
+						Y:         y,
+						Direction: rawBullet.Payload.Direction,
+						ID:        rawBullet.Payload.ID,
+						Speed:     rawBullet.Payload.Speed,
+						Type:      rawBullet.Payload.Type,
+					}
+					gameState.Bullets = append(gameState.Bullets, bullet)
+				case "item":
+					var rawItem struct {
+						Payload struct {
+							Type string `json:"type"`
+						} `json:"payload"`
+					}
+					if err := json.Unmarshal(cell[0], &rawItem); err != nil {
+						return err
+					}
+					item := Item{
+						X:    x,
+						Y:    y,
+						Type: rawItem.Payload.Type,
+					}
+					gameState.Items = append(gameState.Items, item)
+				case "laser":
+					var rawLaser struct {
+						Payload struct {
+							ID          int    `json:"id"`
+							Orientation string `json:"orientation"`
+						} `json:"payload"`
+					}
+					if err := json.Unmarshal(cell[0], &rawLaser); err != nil {
+						return err
+					}
+					laser := Laser{
+						X:           x,
+						Y:           y,
+						ID:          rawLaser.Payload.ID,
+						Orientation: rawLaser.Payload.Orientation,
+					}
+					gameState.Lasers = append(gameState.Lasers, laser)
+				case "mine":
+					var rawMine struct {
+						Payload struct {
+							ID                      int  `json:"id"`
+							ExplosionRemainingTicks *int `json:"explosionRemainingTicks"`
+						} `json:"payload"`
+					}
+					if err := json.Unmarshal(cell[0], &rawMine); err != nil {
+						return err
+					}
+					mine := Mine{
+						X:                       x,
+						Y:                       y,
+						ID:                      rawMine.Payload.ID,
+						ExplosionRemainingTicks: rawMine.Payload.ExplosionRemainingTicks,
+					}
+					gameState.Mines = append(gameState.Mines, mine)
+				default:
+					return fmt.Errorf("unknown tile type: %s", tileType.Type)
+				}
+			}
+		}
+	}
+
+	// Process visibility
+	gameState.Visibility = make([][]bool, len(rawMapData.Visibility))
+	for y, row := range rawMapData.Visibility {
+		gameState.Visibility[y] = make([]bool, len(row))
+		for x, cell := range row {
+			gameState.Visibility[y][x] = cell == '1'
+		}
+	}
+
+	return nil
+}
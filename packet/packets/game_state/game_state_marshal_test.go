@@ -0,0 +1,376 @@
+package game_state
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	gameState := GameState{
+		ID:   "36af2d82-40df-4332-9138-1bb0e6b09fcb",
+		Tick: 110,
+		Players: []Player{
+			{ID: "p1", Nickname: "GO1", Color: 4294925049, Ping: uint64Ptr(1), Score: uint64Ptr(10)},
+		},
+		Walls: []Wall{{X: 0, Y: 1}},
+		Tanks: []Tank{
+			{
+				X:         1,
+				Y:         0,
+				Direction: "up",
+				Health:    intPtr(100),
+				OwnerID:   "p1",
+				Turret: Turret{
+					Direction:          "right",
+					BulletCount:        intPtr(3),
+					TicksToRegenBullet: intPtr(1),
+				},
+				SecondaryItem: stringPtr("laser"),
+			},
+		},
+		Bullets: []Bullet{{X: 1, Y: 1, Direction: "down", ID: 1, Speed: 0.5, Type: "basic"}},
+		Zones: []Zone{
+			{
+				Index:  65,
+				X:      0,
+				Y:      0,
+				Width:  2,
+				Height: 2,
+				Status: ZoneStatus{Type: "captured", Captured: &CapturedStatus{PlayerID: "p1"}},
+			},
+		},
+		Visibility: [][]bool{{true, false}, {false, true}},
+	}
+
+	expected := `{"id":"36af2d82-40df-4332-9138-1bb0e6b09fcb","tick":110,` +
+		`"players":[{"id":"p1","nickname":"GO1","color":4294925049,"ping":1,"score":10}],` +
+		`"map":{"tiles":[` +
+		`[[],[{"type":"wall"}]],` +
+		`[[{"type":"tank","payload":{"direction":"up","health":100,"ownerId":"p1","turret":{"bulletCount":3,"ticksToRegenBullet":1,"direction":"right"},"secondaryItem":"laser"}}],` +
+		`[{"type":"bullet","payload":{"direction":"down","id":1,"speed":0.5,"type":"basic"}}]]],` +
+		`"zones":[{"index":65,"x":0,"y":0,"width":2,"height":2,"status":{"playerId":"p1","type":"captured"}}],` +
+		`"visibility":["10","01"]}}`
+
+	data, err := json.Marshal(gameState)
+	if err != nil {
+		t.Fatalf("MarshalJSON() error = %v", err)
+	}
+	if string(data) != expected {
+		t.Errorf("Expected JSON:\n%s\nGot:\n%s", expected, string(data))
+	}
+}
+
+func TestZoneStatusMarshalJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   ZoneStatus
+		expected string
+	}{
+		{
+			name:     "Neutral",
+			status:   ZoneStatus{Type: "neutral"},
+			expected: `{"type":"neutral"}`,
+		},
+		{
+			name:     "Being Captured",
+			status:   ZoneStatus{Type: "beingCaptured", BeingCaptured: &BeingCapturedStatus{RemainingTicks: 100, PlayerID: "p1"}},
+			expected: `{"remainingTicks":100,"playerId":"p1","type":"beingCaptured"}`,
+		},
+		{
+			name:     "Captured",
+			status:   ZoneStatus{Type: "captured", Captured: &CapturedStatus{PlayerID: "p1"}},
+			expected: `{"playerId":"p1","type":"captured"}`,
+		},
+		{
+			name:     "Being Contested",
+			status:   ZoneStatus{Type: "beingContested", BeingContested: &BeingContestedStatus{CapturedByID: stringPtr("p1")}},
+			expected: `{"capturedById":"p1","type":"beingContested"}`,
+		},
+		{
+			name:     "Being Contested Without Owner",
+			status:   ZoneStatus{Type: "beingContested", BeingContested: &BeingContestedStatus{}},
+			expected: `{"capturedById":null,"type":"beingContested"}`,
+		},
+		{
+			name:     "Being Retaken",
+			status:   ZoneStatus{Type: "beingRetaken", BeingRetaken: &BeingRetakenStatus{RemainingTicks: 5, CapturedByID: "p1", RetakenByID: "p2"}},
+			expected: `{"remainingTicks":5,"capturedById":"p1","retakenById":"p2","type":"beingRetaken"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.status)
+			if err != nil {
+				t.Fatalf("MarshalJSON() error = %v", err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("Expected JSON %s, got %s", tt.expected, data)
+			}
+		})
+	}
+}
+
+func TestMarshalJSONNegativeCoordinates(t *testing.T) {
+	gameState := GameState{Walls: []Wall{{X: -1, Y: 0}}}
+	if _, err := json.Marshal(gameState); err == nil {
+		t.Errorf("Expected an error for a wall at negative coordinates")
+	}
+}
+
+func TestMarshalJSONRoundTrip(t *testing.T) {
+	stacked := 0
+	for seed := int64(0); seed < 500; seed++ {
+		r := rand.New(rand.NewSource(seed))
+		gameState := randomGameState(r)
+		expected := firstObjectPerTile(gameState)
+		if !reflect.DeepEqual(expected, gameState) {
+			stacked++
+		}
+
+		data, err := json.Marshal(gameState)
+		if err != nil {
+			t.Fatalf("seed %d: MarshalJSON() error = %v", seed, err)
+		}
+
+		var decoded GameState
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("seed %d: UnmarshalJSON() error = %v\n%s", seed, err, data)
+		}
+
+		if !reflect.DeepEqual(decoded, expected) {
+			t.Fatalf("seed %d: decode(encode(x)) differs from the first object of each tile of x\nexpected: %+v\ngot:      %+v\njson: %s", seed, expected, decoded, data)
+		}
+	}
+
+	if stacked == 0 {
+		t.Errorf("Expected some game states with stacked objects")
+	}
+}
+
+func TestMarshalJSONStackedObjects(t *testing.T) {
+	gameState := GameState{
+		Players:    []Player{},
+		Zones:      []Zone{},
+		Tanks:      []Tank{{X: 0, Y: 0, Direction: "up", OwnerID: "p1", Turret: Turret{Direction: "up"}}},
+		Mines:      []Mine{{X: 0, Y: 0, ID: 1}},
+		Visibility: [][]bool{{true}},
+	}
+
+	data, err := json.Marshal(gameState)
+	if err != nil {
+		t.Fatalf("MarshalJSON() error = %v", err)
+	}
+
+	expectedTiles := `"tiles":[[[{"type":"tank",` +
+		`"payload":{"direction":"up","health":null,"ownerId":"p1","turret":{"bulletCount":null,"ticksToRegenBullet":null,"direction":"up"}}},` +
+		`{"type":"mine","payload":{"id":1,"explosionRemainingTicks":null}}]]]`
+	if !strings.Contains(string(data), expectedTiles) {
+		t.Errorf("Expected both objects on the tile, got %s", data)
+	}
+
+	// Decoding keeps the first object of a tile only
+	var decoded GameState
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("UnmarshalJSON() error = %v", err)
+	}
+	if len(decoded.Tanks) != 1 || len(decoded.Mines) != 0 {
+		t.Errorf("Expected only the tank to be decoded, got %d tanks and %d mines", len(decoded.Tanks), len(decoded.Mines))
+	}
+}
+
+// firstObjectPerTile returns the game state as UnmarshalJSON decodes it
+// after MarshalJSON: only the first object of each tile, in the order
+// MarshalJSON writes them, is kept.
+func firstObjectPerTile(gameState GameState) GameState {
+	taken := make(map[[2]int]bool)
+	keep := func(x, y int) bool {
+		if taken[[2]int{x, y}] {
+			return false
+		}
+		taken[[2]int{x, y}] = true
+		return true
+	}
+
+	result := gameState
+	result.Walls, result.Tanks, result.Bullets, result.Lasers, result.Mines, result.Items = nil, nil, nil, nil, nil, nil
+	for _, wall := range gameState.Walls {
+		if keep(wall.X, wall.Y) {
+			result.Walls = append(result.Walls, wall)
+		}
+	}
+	for _, tank := range gameState.Tanks {
+		if keep(tank.X, tank.Y) {
+			result.Tanks = append(result.Tanks, tank)
+		}
+	}
+	for _, bullet := range gameState.Bullets {
+		if keep(bullet.X, bullet.Y) {
+			result.Bullets = append(result.Bullets, bullet)
+		}
+	}
+	for _, laser := range gameState.Lasers {
+		if keep(laser.X, laser.Y) {
+			result.Lasers = append(result.Lasers, laser)
+		}
+	}
+	for _, mine := range gameState.Mines {
+		if keep(mine.X, mine.Y) {
+			result.Mines = append(result.Mines, mine)
+		}
+	}
+	for _, item := range gameState.Items {
+		if keep(item.X, item.Y) {
+			result.Items = append(result.Items, item)
+		}
+	}
+	return result
+}
+
+// randomGameState generates a game state with objects ordered by column
+// and then row, and players and zones as non-nil slices. Some tiles hold
+// two objects.
+func randomGameState(r *rand.Rand) GameState {
+	directions := []string{"up", "right", "down", "left"}
+	itemTypes := []string{"unknown", "doubleBullet", "laser", "radar", "mine"}
+	width, height := 1+r.Intn(12), 1+r.Intn(12)
+
+	gameState := GameState{
+		ID:      fmt.Sprintf("state-%d", r.Int()),
+		Tick:    uint64(r.Intn(3000)),
+		Players: []Player{},
+		Zones:   []Zone{},
+	}
+
+	for i := r.Intn(5); i > 0; i-- {
+		player := Player{
+			ID:       fmt.Sprintf("player-%d", i),
+			Nickname: fmt.Sprintf("Player %d", i),
+			Color:    uint64(r.Uint32()),
+		}
+		if r.Intn(2) == 0 {
+			player.Ping = uint64Ptr(uint64(r.Intn(200)))
+		}
+		if r.Intn(2) == 0 {
+			player.Score = uint64Ptr(uint64(r.Intn(1000)))
+		}
+		if r.Intn(4) == 0 {
+			player.TicksToRegen = uint64Ptr(uint64(r.Intn(50)))
+		}
+		if r.Intn(4) == 0 {
+			player.IsUsingRadar = boolPtr(r.Intn(2) == 0)
+		}
+		gameState.Players = append(gameState.Players, player)
+	}
+
+	nextID := 0
+	place := func(x, y, kind int) {
+		nextID++
+		switch kind {
+		case 0:
+			gameState.Walls = append(gameState.Walls, Wall{X: x, Y: y})
+		case 1:
+			tank := Tank{
+				X:         x,
+				Y:         y,
+				Direction: directions[r.Intn(4)],
+				OwnerID:   fmt.Sprintf("player-%d", nextID),
+				Turret:    Turret{Direction: directions[r.Intn(4)]},
+			}
+			if r.Intn(2) == 0 {
+				tank.Health = intPtr(r.Intn(101))
+				tank.Turret.BulletCount = intPtr(r.Intn(4))
+				tank.Turret.TicksToRegenBullet = intPtr(r.Intn(10))
+				if r.Intn(2) == 0 {
+					tank.SecondaryItem = stringPtr(itemTypes[1+r.Intn(4)])
+				}
+			}
+			gameState.Tanks = append(gameState.Tanks, tank)
+		case 2:
+			bulletType := "basic"
+			if r.Intn(2) == 0 {
+				bulletType = "double"
+			}
+			gameState.Bullets = append(gameState.Bullets, Bullet{
+				X:         x,
+				Y:         y,
+				Direction: directions[r.Intn(4)],
+				ID:        nextID,
+				Speed:     float64(r.Intn(8)) / 4,
+				Type:      bulletType,
+			})
+		case 3:
+			gameState.Items = append(gameState.Items, Item{X: x, Y: y, Type: itemTypes[r.Intn(5)]})
+		case 4:
+			orientation := "horizontal"
+			if r.Intn(2) == 0 {
+				orientation = "vertical"
+			}
+			gameState.Lasers = append(gameState.Lasers, Laser{X: x, Y: y, ID: nextID, Orientation: orientation})
+		case 5:
+			mine := Mine{X: x, Y: y, ID: nextID}
+			if r.Intn(2) == 0 {
+				mine.ExplosionRemainingTicks = intPtr(r.Intn(10))
+			}
+			gameState.Mines = append(gameState.Mines, mine)
+		}
+	}
+
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			kind := r.Intn(12)
+			place(x, y, kind)
+			if kind < 6 && r.Intn(4) == 0 {
+				place(x, y, r.Intn(6))
+			}
+		}
+	}
+
+	gameState.Visibility = make([][]bool, height)
+	for y := range gameState.Visibility {
+		gameState.Visibility[y] = make([]bool, width)
+		for x := range gameState.Visibility[y] {
+			gameState.Visibility[y][x] = r.Intn(2) == 0
+		}
+	}
+
+	for i := r.Intn(4); i > 0; i-- {
+		gameState.Zones = append(gameState.Zones, Zone{
+			Index:  uint8('A' + i),
+			X:      uint64(r.Intn(width)),
+			Y:      uint64(r.Intn(height)),
+			Width:  uint64(1 + r.Intn(4)),
+			Height: uint64(1 + r.Intn(4)),
+			Status: randomZoneStatus(r),
+		})
+	}
+
+	return gameState
+}
+
+func randomZoneStatus(r *rand.Rand) ZoneStatus {
+	switch r.Intn(5) {
+	case 0:
+		return ZoneStatus{Type: "beingCaptured", BeingCaptured: &BeingCapturedStatus{RemainingTicks: uint64(r.Intn(100)), PlayerID: "player-1"}}
+	case 1:
+		return ZoneStatus{Type: "captured", Captured: &CapturedStatus{PlayerID: "player-1"}}
+	case 2:
+		status := ZoneStatus{Type: "beingContested", BeingContested: &BeingContestedStatus{}}
+		if r.Intn(2) == 0 {
+			status.BeingContested.CapturedByID = stringPtr("player-1")
+		}
+		return status
+	case 3:
+		return ZoneStatus{Type: "beingRetaken", BeingRetaken: &BeingRetakenStatus{RemainingTicks: uint64(r.Intn(100)), CapturedByID: "player-1", RetakenByID: "player-2"}}
+	default:
+		return ZoneStatus{Type: "neutral"}
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
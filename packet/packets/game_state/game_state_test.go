@@ -2,6 +2,8 @@ package game_state
 
 import (
 	"encoding/json"
+	"math/rand"
+	"reflect"
 	"testing"
 )
 
@@ -457,6 +459,58 @@ func TestUnmarshalJSON(t *testing.T) {
 	}
 }
 
+func TestZoneStatusUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		jsonData string
+		expected ZoneStatus
+	}{
+		{
+			name:     "Neutral",
+			jsonData: `{"type": "neutral"}`,
+			expected: ZoneStatus{Type: "neutral"},
+		},
+		{
+			name:     "Being Captured",
+			jsonData: `{"type": "beingCaptured", "remainingTicks": 10, "playerId": "p1"}`,
+			expected: ZoneStatus{Type: "beingCaptured", BeingCaptured: &BeingCapturedStatus{RemainingTicks: 10, PlayerID: "p1"}},
+		},
+		{
+			name:     "Captured",
+			jsonData: `{"type": "captured", "playerId": "p1"}`,
+			expected: ZoneStatus{Type: "captured", Captured: &CapturedStatus{PlayerID: "p1"}},
+		},
+		{
+			name:     "Being Contested",
+			jsonData: `{"type": "beingContested", "capturedById": "p1"}`,
+			expected: ZoneStatus{Type: "beingContested", BeingContested: &BeingContestedStatus{CapturedByID: stringPtr("p1")}},
+		},
+		{
+			name:     "Being Contested Without Owner",
+			jsonData: `{"type": "beingContested", "capturedById": null}`,
+			expected: ZoneStatus{Type: "beingContested", BeingContested: &BeingContestedStatus{}},
+		},
+		{
+			name:     "Being Retaken",
+			jsonData: `{"type": "beingRetaken", "remainingTicks": 5, "capturedById": "p1", "retakenById": "p2"}`,
+			expected: ZoneStatus{Type: "beingRetaken", BeingRetaken: &BeingRetakenStatus{RemainingTicks: 5, CapturedByID: "p1", RetakenByID: "p2"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var status ZoneStatus
+			if err := json.Unmarshal([]byte(tt.jsonData), &status); err != nil {
+				t.Fatalf("UnmarshalJSON() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(status, tt.expected) {
+				t.Errorf("expected ZoneStatus = %+v, got %+v", tt.expected, status)
+			}
+		})
+	}
+}
+
 func uint64Ptr(i uint64) *uint64 {
 	return &i
 }
@@ -468,3 +522,97 @@ func intPtr(i int) *int {
 func stringPtr(s string) *string {
 	return &s
 }
+
+func TestLookups(t *testing.T) {
+	gameState := GameState{
+		Tanks:   []Tank{{OwnerID: "p1", X: 1}, {OwnerID: "p2", X: 2}},
+		Players: []Player{{ID: "p1"}, {ID: "p2", Nickname: "second"}},
+		Bullets: []Bullet{{ID: 4, X: 1}},
+		Lasers:  []Laser{{ID: 7, X: 2}},
+	}
+
+	if tank := gameState.Tank("p2"); tank == nil || tank.X != 2 {
+		t.Errorf("Expected the tank of p2, got %+v", tank)
+	} else if tank != &gameState.Tanks[1] {
+		t.Errorf("Expected a pointer into the game state")
+	}
+	if tank := gameState.Tank("p3"); tank != nil {
+		t.Errorf("Expected no tank for p3, got %+v", tank)
+	}
+
+	if player := gameState.Player("p2"); player == nil || player.Nickname != "second" {
+		t.Errorf("Expected player p2, got %+v", player)
+	}
+	if player := gameState.Player("p3"); player != nil {
+		t.Errorf("Expected no player p3, got %+v", player)
+	}
+
+	if bullet := gameState.Bullet(4); bullet == nil || bullet.X != 1 {
+		t.Errorf("Expected bullet 4, got %+v", bullet)
+	}
+	if bullet := gameState.Bullet(7); bullet != nil {
+		t.Errorf("Expected no bullet 7, got %+v", bullet)
+	}
+	if laser := gameState.Laser(7); laser == nil || laser.X != 2 {
+		t.Errorf("Expected laser 7, got %+v", laser)
+	}
+	if laser := gameState.Laser(4); laser != nil {
+		t.Errorf("Expected no laser 4, got %+v", laser)
+	}
+}
+
+func TestClone(t *testing.T) {
+	for seed := int64(0); seed < 50; seed++ {
+		original := randomGameState(rand.New(rand.NewSource(seed)))
+		expected := randomGameState(rand.New(rand.NewSource(seed)))
+
+		clone := original.Clone()
+		if !reflect.DeepEqual(*clone, original) {
+			t.Fatalf("seed %d: expected the clone to equal the original", seed)
+		}
+
+		// Change everything the clone could share with the original
+		changeInt := func(value *int) {
+			if value != nil {
+				*value = -1
+			}
+		}
+		for i := range clone.Tanks {
+			clone.Tanks[i].X++
+			changeInt(clone.Tanks[i].Health)
+			changeInt(clone.Tanks[i].Turret.BulletCount)
+			changeInt(clone.Tanks[i].Turret.TicksToRegenBullet)
+			if clone.Tanks[i].SecondaryItem != nil {
+				*clone.Tanks[i].SecondaryItem = "changed"
+			}
+		}
+		for i := range clone.Players {
+			clone.Players[i].Nickname = "changed"
+			if clone.Players[i].Score != nil {
+				*clone.Players[i].Score = 1 << 40
+			}
+		}
+		for i := range clone.Zones {
+			clone.Zones[i].X++
+			if status := clone.Zones[i].Status.BeingContested; status != nil && status.CapturedByID != nil {
+				*status.CapturedByID = "changed"
+			}
+			if status := clone.Zones[i].Status.BeingCaptured; status != nil {
+				status.PlayerID = "changed"
+			}
+		}
+		for y := range clone.Visibility {
+			for x := range clone.Visibility[y] {
+				clone.Visibility[y][x] = !clone.Visibility[y][x]
+			}
+		}
+		for i := range clone.Mines {
+			changeInt(clone.Mines[i].ExplosionRemainingTicks)
+		}
+		clone.Walls = append(clone.Walls[:0], Wall{X: -1})
+
+		if !reflect.DeepEqual(original, expected) {
+			t.Fatalf("seed %d: expected changes to the clone to leave the original alone", seed)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+// Package pathing finds the fastest way for a tank to reach a tile.
+//
+// A tank does not move like a piece on a board. Every tick it can either
+// move one tile forward or backward along the direction its body faces, or
+// rotate its body by 90 degrees, so a turn costs a tick of its own. The
+// search runs over poses, a tile together with a body direction, and counts
+// ticks instead of tiles.
+package pathing
+
+import (
+	"hackarena2-0-mono-tanks-go/packet/packets/bot_response"
+	"hackarena2-0-mono-tanks-go/packet/packets/bot_response/movement"
+	"hackarena2-0-mono-tanks-go/packet/packets/bot_response/rotation"
+	"hackarena2-0-mono-tanks-go/packet/packets/game_state"
+	"hackarena2-0-mono-tanks-go/packet/packets/game_state/direction"
+)
+
+// Directions lists the directions clockwise, starting from up.
+var Directions = []string{direction.Up, direction.Right, direction.Down, direction.Left}
+
+var (
+	deltaX = [4]int{0, 1, 0, -1}
+	deltaY = [4]int{-1, 0, 1, 0}
+)
+
+// DirectionIndex returns the position of the direction in Directions, or -1 if it is unknown.
+func DirectionIndex(dir string) int {
+	for i, d := range Directions {
+		if d == dir {
+			return i
+		}
+	}
+	return -1
+}
+
+// Step returns the tile next to (x, y) in the direction.
+func Step(x, y int, dir string) (int, int) {
+	i := DirectionIndex(dir)
+	if i < 0 {
+		return x, y
+	}
+	return x + deltaX[i], y + deltaY[i]
+}
+
+// Point is a tile of the map.
+type Point struct {
+	X int
+	Y int
+}
+
+// Distance returns the number of tiles between the points, moving along rows and columns.
+func (p Point) Distance(other Point) int {
+	return abs(p.X-other.X) + abs(p.Y-other.Y)
+}
+
+// Pose is the position and body direction of a tank.
+type Pose struct {
+	X         int
+	Y         int
+	Direction string
+}
+
+// PoseOf returns the pose of the tank.
+func PoseOf(tank *game_state.Tank) Pose {
+	return Pose{X: tank.X, Y: tank.Y, Direction: tank.Direction}
+}
+
+// Map holds the tiles a tank can't drive onto.
+type Map struct {
+	Width   int
+	Height  int
+	blocked []bool
+}
+
+// NewMap marks walls, mines, lasers and the tanks of other players as
+// blocked. The tank of the player with ownerID is not an obstacle to itself.
+func NewMap(gameState *game_state.GameState, ownerID string) *Map {
+	height := len(gameState.Visibility)
+	width := 0
+	if height > 0 {
+		width = len(gameState.Visibility[0])
+	}
+
+	m := &Map{
+		Width:   width,
+		Height:  height,
+		blocked: make([]bool, width*height),
+	}
+	for _, wall := range gameState.Walls {
+		m.Block(wall.X, wall.Y)
+	}
+	for _, mine := range gameState.Mines {
+		m.Block(mine.X, mine.Y)
+	}
+	for _, laser := range gameState.Lasers {
+		m.Block(laser.X, laser.Y)
+	}
+	for _, tank := range gameState.Tanks {
+		if tank.OwnerID != ownerID {
+			m.Block(tank.X, tank.Y)
+		}
+	}
+	return m
+}
+
+// Inside reports whether the tile is on the map.
+func (m *Map) Inside(x, y int) bool {
+	return x >= 0 && y >= 0 && x < m.Width && y < m.Height
+}
+
+// Block marks the tile as blocked. Tiles outside of the map are ignored.
+func (m *Map) Block(x, y int) {
+	if m.Inside(x, y) {
+		m.blocked[y*m.Width+x] = true
+	}
+}
+
+// Blocked reports whether a tank can't drive onto the tile. Tiles outside
+// of the map are blocked.
+func (m *Map) Blocked(x, y int) bool {
+	return !m.Inside(x, y) || m.blocked[y*m.Width+x]
+}
+
+// Search holds the fastest ways from a pose to every reachable pose.
+type Search struct {
+	m *Map
+
+	// ticks is indexed by pose and -1 for poses that can't be reached.
+	ticks []int
+
+	// previous is the pose each pose is reached from, and action the action taken there.
+	previous []int
+	action   []*bot_response.BotResponse
+}
+
+// Search finds the fastest ways from the pose to every reachable pose.
+func (m *Map) Search(from Pose) *Search {
+	s := &Search{
+		m:        m,
+		ticks:    make([]int, len(m.blocked)*4),
+		previous: make([]int, len(m.blocked)*4),
+		action:   make([]*bot_response.BotResponse, len(m.blocked)*4),
+	}
+	for i := range s.ticks {
+		s.ticks[i] = -1
+	}
+
+	dir := DirectionIndex(from.Direction)
+	if !m.Inside(from.X, from.Y) || dir < 0 {
+		return s
+	}
+
+	var (
+		forward     = bot_response.NewMovement(movement.Forward)
+		backward    = bot_response.NewMovement(movement.Backward)
+		rotateLeft  = bot_response.NewRotation(rotation.Left, "")
+		rotateRight = bot_response.NewRotation(rotation.Right, "")
+	)
+
+	start := s.index(from.X, from.Y, dir)
+	s.ticks[start] = 0
+	s.previous[start] = -1
+	queue := []int{start}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		x, y, d := s.pose(current)
+
+		visit := func(x, y, d int, action *bot_response.BotResponse) {
+			if m.Blocked(x, y) {
+				return
+			}
+			next := s.index(x, y, d)
+			if s.ticks[next] >= 0 {
+				return
+			}
+			s.ticks[next] = s.ticks[current] + 1
+			s.previous[next] = current
+			s.action[next] = action
+			queue = append(queue, next)
+		}
+
+		visit(x+deltaX[d], y+deltaY[d], d, forward)
+		visit(x-deltaX[d], y-deltaY[d], d, backward)
+		visit(x, y, (d+3)%4, rotateLeft)
+		visit(x, y, (d+1)%4, rotateRight)
+	}
+
+	return s
+}
+
+func (s *Search) index(x, y, dir int) int {
+	return (y*s.m.Width+x)*4 + dir
+}
+
+func (s *Search) pose(index int) (x, y, dir int) {
+	tile := index / 4
+	return tile % s.m.Width, tile / s.m.Width, index % 4
+}
+
+// Ticks returns the number of ticks needed to reach the tile facing any direction.
+func (s *Search) Ticks(x, y int) (int, bool) {
+	best := s.bestIndex(x, y)
+	if best < 0 {
+		return 0, false
+	}
+	return s.ticks[best], true
+}
+
+// PoseTicks returns the number of ticks needed to reach the pose.
+func (s *Search) PoseTicks(pose Pose) (int, bool) {
+	dir := DirectionIndex(pose.Direction)
+	if !s.m.Inside(pose.X, pose.Y) || dir < 0 {
+		return 0, false
+	}
+	ticks := s.ticks[s.index(pose.X, pose.Y, dir)]
+	return ticks, ticks >= 0
+}
+
+// Path returns the actions that reach the tile the fastest, in the order
+// they have to be taken, or nil if it can't be reached. Every action is a
+// new response the caller may change.
+func (s *Search) Path(x, y int) []*bot_response.BotResponse {
+	current := s.bestIndex(x, y)
+	if current < 0 {
+		return nil
+	}
+
+	path := make([]*bot_response.BotResponse, s.ticks[current])
+	for i := len(path) - 1; i >= 0; i-- {
+		action := *s.action[current]
+		path[i] = &action
+		current = s.previous[current]
+	}
+	return path
+}
+
+// Nearest returns the reachable tile among targets that takes the fewest
+// ticks to reach, with the number of ticks. Ties go to the first target.
+func (s *Search) Nearest(targets []Point) (Point, int, bool) {
+	var nearest Point
+	best := -1
+	for _, target := range targets {
+		if ticks, ok := s.Ticks(target.X, target.Y); ok && (best < 0 || ticks < best) {
+			nearest = target
+			best = ticks
+		}
+	}
+	return nearest, best, best >= 0
+}
+
+func (s *Search) bestIndex(x, y int) int {
+	if !s.m.Inside(x, y) {
+		return -1
+	}
+
+	best := -1
+	for dir := 0; dir < 4; dir++ {
+		i := s.index(x, y, dir)
+		if s.ticks[i] >= 0 && (best < 0 || s.ticks[i] < s.ticks[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
@@ -0,0 +1,134 @@
+package pathing
+
+import (
+	"hackarena2-0-mono-tanks-go/packet/packets/bot_response"
+	"hackarena2-0-mono-tanks-go/packet/packets/bot_response/movement"
+	"hackarena2-0-mono-tanks-go/packet/packets/bot_response/rotation"
+	"hackarena2-0-mono-tanks-go/scenario"
+	"testing"
+)
+
+func search(t *testing.T, picture string) *Search {
+	t.Helper()
+	s := scenario.MustParse(picture)
+	return NewMap(s.GameState, scenario.MyID).Search(PoseOf(s.MyTank()))
+}
+
+func TestSearchCountsTurns(t *testing.T) {
+	s := search(t, `
+		# # # # #
+		# > . . #
+		# # # . #
+		# . . . #
+		# # # # #
+	`)
+
+	if ticks, ok := s.Ticks(3, 3); !ok || ticks != 5 {
+		t.Errorf("Expected (3, 3) in 5 ticks, got %d, %v", ticks, ok)
+	}
+	if ticks, ok := s.Ticks(1, 3); !ok || ticks != 8 {
+		t.Errorf("Expected (1, 3) in 8 ticks, got %d, %v", ticks, ok)
+	}
+	if ticks, ok := s.PoseTicks(Pose{X: 1, Y: 1, Direction: "left"}); !ok || ticks != 2 {
+		t.Errorf("Expected turning around in 2 ticks, got %d, %v", ticks, ok)
+	}
+	if _, ok := s.Ticks(0, 0); ok {
+		t.Errorf("Expected walls to be unreachable")
+	}
+
+	path := s.Path(3, 3)
+	if len(path) != 5 {
+		t.Fatalf("Expected a path of 5 actions, got %d", len(path))
+	}
+	if pose := replay(Pose{X: 1, Y: 1, Direction: "right"}, path); pose.X != 3 || pose.Y != 3 {
+		t.Errorf("Expected the path to end at (3, 3), got %+v", pose)
+	}
+}
+
+// replay applies the actions of a path to the pose.
+func replay(pose Pose, path []*bot_response.BotResponse) Pose {
+	for _, action := range path {
+		dir := DirectionIndex(pose.Direction)
+		switch {
+		case action.Direction == movement.Forward:
+			pose.X, pose.Y = Step(pose.X, pose.Y, pose.Direction)
+		case action.Direction == movement.Backward:
+			pose.X, pose.Y = Step(pose.X, pose.Y, Directions[(dir+2)%4])
+		case action.TankRotation == rotation.Left:
+			pose.Direction = Directions[(dir+3)%4]
+		case action.TankRotation == rotation.Right:
+			pose.Direction = Directions[(dir+1)%4]
+		}
+	}
+	return pose
+}
+
+func TestSearchMovesBackward(t *testing.T) {
+	s := search(t, `
+		# # # # #
+		# . < . #
+		# # # # #
+	`)
+
+	path := s.Path(3, 1)
+	if len(path) != 1 || path[0].Direction != movement.Backward {
+		t.Errorf("Expected a single backward move, got %v", path)
+	}
+}
+
+func TestPathReturnsFreshResponses(t *testing.T) {
+	s := search(t, `
+		# # # # # #
+		# > . . . #
+		# # # # # #
+	`)
+
+	path := s.Path(4, 1)
+	if len(path) != 3 {
+		t.Fatalf("Expected a path of 3 actions, got %d", len(path))
+	}
+	if path[0] == path[1] {
+		t.Errorf("Expected every action to be a separate response")
+	}
+
+	path[0].Direction = movement.Backward
+	if again := s.Path(4, 1); again[0].Direction != movement.Forward || path[1].Direction != movement.Forward {
+		t.Errorf("Expected changing a path not to change other paths, got %v and %v", again[0], path[1])
+	}
+}
+
+func TestSearchAvoidsObstacles(t *testing.T) {
+	s := search(t, `
+		# # # # # # #
+		# > . T . . #
+		# . # # # X #
+		# . . ═ . . #
+		# # # # # # #
+	`)
+
+	for _, tile := range []Point{{4, 1}, {5, 1}, {4, 3}, {5, 3}} {
+		if _, ok := s.Ticks(tile.X, tile.Y); ok {
+			t.Errorf("Expected %v to be cut off by the tank, mine and laser", tile)
+		}
+	}
+
+	nearest, ticks, ok := s.Nearest([]Point{{5, 3}, {2, 3}, {1, 3}})
+	if !ok || nearest != (Point{1, 3}) || ticks != 3 {
+		t.Errorf("Expected (1, 3) in 3 ticks to be the nearest, got %v in %d, %v", nearest, ticks, ok)
+	}
+	if s.Path(5, 3) != nil {
+		t.Errorf("Expected no path to an unreachable tile")
+	}
+}
+
+func TestStep(t *testing.T) {
+	if x, y := Step(2, 2, "up"); x != 2 || y != 1 {
+		t.Errorf("Expected up to decrease Y, got (%d, %d)", x, y)
+	}
+	if x, y := Step(2, 2, "left"); x != 1 || y != 2 {
+		t.Errorf("Expected left to decrease X, got (%d, %d)", x, y)
+	}
+	if distance := (Point{1, 1}).Distance(Point{3, 0}); distance != 3 {
+		t.Errorf("Expected distance 3, got %d", distance)
+	}
+}
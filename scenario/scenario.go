@@ -0,0 +1,323 @@
+// Package scenario builds game states from ASCII pictures, so bot tests can
+// describe a situation in a few lines instead of a raw server JSON fixture.
+//
+// A picture is a rectangle of cells, one rune per cell. Spaces and tabs are
+// ignored, so cells may be separated the same way the sample bot prints the
+// map, and blank lines are skipped. Row 0 is the first line and column 0 the
+// first cell of each line, matching the X and Y fields of the game state.
+//
+// Cells:
+//
+//	.           empty visible tile
+//	~           empty tile outside of our visibility
+//	#           wall
+//	^ > v <     our tank facing up, right, down or left
+//	T           enemy tank facing up, each one owned by a separate player
+//	↑ → ↓ ←     basic bullet travelling in the arrow's direction
+//	⇈ ⇉ ⇊ ⇇     double bullet travelling in the arrow's direction
+//	═ -         horizontal laser
+//	║ |         vertical laser
+//	X           mine
+//	D L R M     doubleBullet, laser, radar or mine item
+//	?           unknown item
+//	a-z         tile of the zone with the same uppercase index, the zone is
+//	            the bounding box of all of its tiles, except v which is our
+//	            tank facing down, so zone V can't be drawn
+//
+// The symbols follow the sample bot's map printout, except that the sample
+// bot prints zones in uppercase and leaves tiles outside of our visibility
+// blank, while pictures use uppercase letters for items, lowercase letters
+// for zones and ~ for tiles we can't see.
+package scenario
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"hackarena2-0-mono-tanks-go/packet/packets/game_state"
+	"hackarena2-0-mono-tanks-go/packet/packets/game_state/direction"
+	"hackarena2-0-mono-tanks-go/packet/packets/lobby_data"
+)
+
+const (
+	// MyID is the player ID of our tank in every scenario.
+	MyID = "me"
+
+	// GameStateID is the ID of every scenario game state.
+	GameStateID = "scenario"
+
+	// DefaultHealth is the health of our tank.
+	DefaultHealth = 100
+
+	// DefaultBulletCount is the number of bullets in our tank's turret.
+	DefaultBulletCount = 3
+
+	// DefaultBulletSpeed is the speed of drawn basic bullets.
+	DefaultBulletSpeed = 1.0
+
+	// DefaultDoubleBulletSpeed is the speed of drawn double bullets.
+	DefaultDoubleBulletSpeed = 1.5
+)
+
+// Scenario is a game state parsed from an ASCII picture.
+type Scenario struct {
+	// GameState is the parsed game state. Tests may adjust it freely
+	// before passing it to the bot.
+	GameState *game_state.GameState
+}
+
+var tankDirections = map[rune]string{
+	'^': direction.Up,
+	'>': direction.Right,
+	'v': direction.Down,
+	'<': direction.Left,
+}
+
+var bulletGlyphs = map[rune]struct {
+	direction  string
+	bulletType string
+	speed      float64
+}{
+	'↑': {direction.Up, "basic", DefaultBulletSpeed},
+	'→': {direction.Right, "basic", DefaultBulletSpeed},
+	'↓': {direction.Down, "basic", DefaultBulletSpeed},
+	'←': {direction.Left, "basic", DefaultBulletSpeed},
+	'⇈': {direction.Up, "double", DefaultDoubleBulletSpeed},
+	'⇉': {direction.Right, "double", DefaultDoubleBulletSpeed},
+	'⇊': {direction.Down, "double", DefaultDoubleBulletSpeed},
+	'⇇': {direction.Left, "double", DefaultDoubleBulletSpeed},
+}
+
+var laserOrientations = map[rune]string{
+	'═': "horizontal",
+	'-': "horizontal",
+	'║': "vertical",
+	'|': "vertical",
+}
+
+var itemTypes = map[rune]string{
+	'D': "doubleBullet",
+	'L': "laser",
+	'R': "radar",
+	'M': "mine",
+	'?': "unknown",
+}
+
+// zoneBounds is the bounding box of the tiles drawn for a zone.
+type zoneBounds struct {
+	minX, minY, maxX, maxY int
+}
+
+// Parse builds a scenario from an ASCII picture.
+func Parse(picture string) (*Scenario, error) {
+	var rows [][]rune
+	for _, line := range strings.Split(picture, "\n") {
+		row := []rune(strings.Map(func(r rune) rune {
+			if r == ' ' || r == '\t' || r == '\r' {
+				return -1
+			}
+			return r
+		}, line))
+		if len(row) > 0 {
+			rows = append(rows, row)
+		}
+	}
+
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("empty picture")
+	}
+
+	gameState := &game_state.GameState{
+		ID:         GameStateID,
+		Players:    []game_state.Player{newPlayer(MyID, "Me")},
+		Visibility: make([][]bool, len(rows)),
+	}
+
+	zones := make(map[rune]*zoneBounds)
+	nextID := 1
+
+	for y, row := range rows {
+		if len(row) != len(rows[0]) {
+			return nil, fmt.Errorf("row %d has %d cells, expected %d", y, len(row), len(rows[0]))
+		}
+
+		gameState.Visibility[y] = make([]bool, len(row))
+		for x, cell := range row {
+			gameState.Visibility[y][x] = cell != '~'
+
+			if dir, ok := tankDirections[cell]; ok {
+				for _, tank := range gameState.Tanks {
+					if tank.OwnerID == MyID {
+						if cell == 'v' {
+							return nil, fmt.Errorf("second tank of ours at (%d, %d), v is our tank facing down and can't be a zone", x, y)
+						}
+						return nil, fmt.Errorf("second tank of ours at (%d, %d)", x, y)
+					}
+				}
+				gameState.Tanks = append(gameState.Tanks, game_state.Tank{
+					X:         x,
+					Y:         y,
+					Direction: dir,
+					Health:    intPtr(DefaultHealth),
+					OwnerID:   MyID,
+					Turret: game_state.Turret{
+						Direction:   dir,
+						BulletCount: intPtr(DefaultBulletCount),
+					},
+				})
+				continue
+			}
+
+			if bullet, ok := bulletGlyphs[cell]; ok {
+				gameState.Bullets = append(gameState.Bullets, game_state.Bullet{
+					X:         x,
+					Y:         y,
+					Direction: bullet.direction,
+					ID:        nextID,
+					Speed:     bullet.speed,
+					Type:      bullet.bulletType,
+				})
+				nextID++
+				continue
+			}
+
+			if orientation, ok := laserOrientations[cell]; ok {
+				gameState.Lasers = append(gameState.Lasers, game_state.Laser{
+					X:           x,
+					Y:           y,
+					ID:          nextID,
+					Orientation: orientation,
+				})
+				nextID++
+				continue
+			}
+
+			if itemType, ok := itemTypes[cell]; ok {
+				gameState.Items = append(gameState.Items, game_state.Item{X: x, Y: y, Type: itemType})
+				continue
+			}
+
+			switch {
+			case cell == '.' || cell == '~':
+			case cell == '#':
+				gameState.Walls = append(gameState.Walls, game_state.Wall{X: x, Y: y})
+			case cell == 'T':
+				enemyID := fmt.Sprintf("enemy-%d", len(gameState.Players))
+				gameState.Players = append(gameState.Players, newPlayer(enemyID, fmt.Sprintf("Enemy %d", len(gameState.Players))))
+				gameState.Tanks = append(gameState.Tanks, game_state.Tank{
+					X:         x,
+					Y:         y,
+					Direction: direction.Up,
+					OwnerID:   enemyID,
+					Turret: game_state.Turret{
+						Direction: direction.Up,
+					},
+				})
+			case cell == 'X':
+				gameState.Mines = append(gameState.Mines, game_state.Mine{X: x, Y: y, ID: nextID})
+				nextID++
+			case cell >= 'a' && cell <= 'z':
+				bounds, ok := zones[cell]
+				if !ok {
+					bounds = &zoneBounds{minX: x, minY: y, maxX: x, maxY: y}
+					zones[cell] = bounds
+				}
+				bounds.minX = min(bounds.minX, x)
+				bounds.minY = min(bounds.minY, y)
+				bounds.maxX = max(bounds.maxX, x)
+				bounds.maxY = max(bounds.maxY, y)
+			default:
+				return nil, fmt.Errorf("unknown cell %q at (%d, %d)", cell, x, y)
+			}
+		}
+	}
+
+	for cell, bounds := range zones {
+		gameState.Zones = append(gameState.Zones, game_state.Zone{
+			Index:  uint8(unicode.ToUpper(cell)),
+			X:      uint64(bounds.minX),
+			Y:      uint64(bounds.minY),
+			Width:  uint64(bounds.maxX - bounds.minX + 1),
+			Height: uint64(bounds.maxY - bounds.minY + 1),
+			Status: game_state.ZoneStatus{Type: "neutral"},
+		})
+	}
+	sort.Slice(gameState.Zones, func(i, j int) bool {
+		return gameState.Zones[i].Index < gameState.Zones[j].Index
+	})
+
+	return &Scenario{GameState: gameState}, nil
+}
+
+// MustParse is like Parse but panics if the picture is invalid.
+// It is meant for test fixtures written as literals.
+func MustParse(picture string) *Scenario {
+	s, err := Parse(picture)
+	if err != nil {
+		panic(fmt.Sprintf("scenario: %v", err))
+	}
+	return s
+}
+
+// MyTank returns our tank, or nil if none was drawn.
+func (s *Scenario) MyTank() *game_state.Tank {
+	return s.GameState.Tank(MyID)
+}
+
+// Enemies returns the enemy tanks in reading order.
+func (s *Scenario) Enemies() []*game_state.Tank {
+	var enemies []*game_state.Tank
+	for i := range s.GameState.Tanks {
+		if s.GameState.Tanks[i].OwnerID != MyID {
+			enemies = append(enemies, &s.GameState.Tanks[i])
+		}
+	}
+	return enemies
+}
+
+// LobbyData returns lobby data matching the scenario, suitable for
+// creating a bot with bot.OnJoiningLobby.
+func (s *Scenario) LobbyData() *lobby_data.LobbyData {
+	players := make([]lobby_data.LobbyPlayer, 0, len(s.GameState.Players))
+	for _, player := range s.GameState.Players {
+		players = append(players, lobby_data.LobbyPlayer{
+			ID:       player.ID,
+			Nickname: player.Nickname,
+			Color:    player.Color,
+		})
+	}
+
+	dimension := len(s.GameState.Visibility)
+	if width := len(s.GameState.Visibility[0]); width > dimension {
+		dimension = width
+	}
+
+	return &lobby_data.LobbyData{
+		PlayerID: MyID,
+		Players:  players,
+		ServerSettings: lobby_data.ServerSettings{
+			GridDimension:   uint32(dimension),
+			NumberOfPlayers: uint32(len(players)),
+			SandboxMode:     true,
+		},
+	}
+}
+
+func newPlayer(id string, nickname string) game_state.Player {
+	return game_state.Player{
+		ID:       id,
+		Nickname: nickname,
+		Ping:     uint64Ptr(0),
+		Score:    uint64Ptr(0),
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func uint64Ptr(i uint64) *uint64 {
+	return &i
+}
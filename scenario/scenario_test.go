@@ -0,0 +1,94 @@
+package scenario
+
+import (
+	"hackarena2-0-mono-tanks-go/packet/packets/game_state/direction"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	s := MustParse(`
+		# # # # #
+		# > . ↓ #
+		# a a T #
+		# a a X #
+		# D ~ ~ #
+	`)
+	gameState := s.GameState
+
+	if len(gameState.Walls) != 13 {
+		t.Errorf("expected 13 walls, got %d", len(gameState.Walls))
+	}
+
+	myTank := s.MyTank()
+	if myTank == nil {
+		t.Fatalf("expected our tank to be parsed")
+	}
+	if myTank.X != 1 || myTank.Y != 1 || myTank.Direction != direction.Right {
+		t.Errorf("expected our tank at (1, 1) facing right, got (%d, %d) facing %s", myTank.X, myTank.Y, myTank.Direction)
+	}
+	if myTank.Turret.BulletCount == nil || *myTank.Turret.BulletCount != DefaultBulletCount {
+		t.Errorf("expected our tank to have %d bullets, got %v", DefaultBulletCount, myTank.Turret.BulletCount)
+	}
+
+	enemies := s.Enemies()
+	if len(enemies) != 1 || enemies[0].X != 3 || enemies[0].Y != 2 {
+		t.Fatalf("expected one enemy tank at (3, 2), got %v", enemies)
+	}
+	if enemies[0].Health != nil {
+		t.Errorf("expected enemy health to be hidden, got %v", *enemies[0].Health)
+	}
+	if len(gameState.Players) != 2 {
+		t.Errorf("expected 2 players, got %d", len(gameState.Players))
+	}
+
+	if len(gameState.Bullets) != 1 || gameState.Bullets[0].Direction != direction.Down || gameState.Bullets[0].Type != "basic" {
+		t.Errorf("expected one basic bullet travelling down, got %v", gameState.Bullets)
+	}
+	if len(gameState.Mines) != 1 || gameState.Mines[0].X != 3 || gameState.Mines[0].Y != 3 {
+		t.Errorf("expected one mine at (3, 3), got %v", gameState.Mines)
+	}
+	if len(gameState.Items) != 1 || gameState.Items[0].Type != "doubleBullet" {
+		t.Errorf("expected one doubleBullet item, got %v", gameState.Items)
+	}
+
+	if len(gameState.Zones) != 1 {
+		t.Fatalf("expected 1 zone, got %d", len(gameState.Zones))
+	}
+	zone := gameState.Zones[0]
+	if zone.Index != 'A' || zone.X != 1 || zone.Y != 2 || zone.Width != 2 || zone.Height != 2 {
+		t.Errorf("expected zone A at (1, 2) of size 2x2, got %c at (%d, %d) of size %dx%d", zone.Index, zone.X, zone.Y, zone.Width, zone.Height)
+	}
+
+	if !gameState.Visibility[4][1] || gameState.Visibility[4][2] || gameState.Visibility[4][3] {
+		t.Errorf("expected fog at (2, 4) and (3, 4), got %v", gameState.Visibility[4])
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		picture string
+	}{
+		{name: "Empty", picture: "\n\n"},
+		{name: "Ragged Rows", picture: "...\n.."},
+		{name: "Unknown Cell", picture: ".&."},
+		{name: "Two Own Tanks", picture: "^.v"},
+		{name: "Zone V", picture: "v v\nv v"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.picture); err == nil {
+				t.Errorf("expected an error for picture %q", tt.picture)
+			}
+		})
+	}
+}
+
+func TestParseZoneVIsOurTank(t *testing.T) {
+	_, err := Parse("a v v")
+	if err == nil || !strings.Contains(err.Error(), "can't be a zone") {
+		t.Errorf("expected drawing zone v to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package spectator
+
+import (
+	"io"
+
+	"hackarena2-0-mono-tanks-go/packet"
+	"hackarena2-0-mono-tanks-go/packet/codec"
+)
+
+// Recorder writes the packets a spectator receives to a recording, one
+// packet per line, encoded the way the server sends them. Decode each line
+// with codec.Decode to replay a recorded game.
+type Recorder struct {
+	w io.Writer
+}
+
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{w: w}
+}
+
+// Record writes a packet with the payload registered for its type in the codec.
+func (r *Recorder) Record(packetType packet.PacketType, payload interface{}) error {
+	message, err := codec.Encode(packet.Packet{Type: packetType, Payload: payload})
+	if err != nil {
+		return err
+	}
+	_, err = r.w.Write(append(message, '\n'))
+	return err
+}
@@ -0,0 +1,106 @@
+package spectator
+
+import (
+	"fmt"
+	"strings"
+
+	"hackarena2-0-mono-tanks-go/packet/packets/game_state"
+)
+
+// Symbols drawn by Render, following the sample bot's map printout.
+var (
+	basicBulletSymbols  = map[string]string{"up": "↑", "right": "→", "down": "↓", "left": "←"}
+	doubleBulletSymbols = map[string]string{"up": "⇈", "right": "⇉", "down": "⇊", "left": "⇇"}
+	laserSymbols        = map[string]string{"horizontal": "═", "vertical": "║"}
+	itemSymbols         = map[string]string{"doubleBullet": "D", "laser": "L", "radar": "R", "mine": "M"}
+)
+
+// Render draws the map of a game state with the symbols of the sample bot's
+// printout, followed by a line naming the tank of each player. A tank is
+// drawn as the number of its player in the game state, from 1 to 9, or T
+// past the ninth player. Zones are drawn with their uppercase index.
+func Render(gameState *game_state.GameState) string {
+	height := len(gameState.Visibility)
+	width := 0
+	if height > 0 {
+		width = len(gameState.Visibility[0])
+	}
+
+	cells := make([][]string, height)
+	for y := range cells {
+		cells[y] = make([]string, width)
+		for x := range cells[y] {
+			cells[y][x] = "."
+		}
+	}
+	draw := func(x, y int, symbol string) {
+		if x >= 0 && x < width && y >= 0 && y < height {
+			cells[y][x] = symbol
+		}
+	}
+
+	// Later symbols cover earlier ones, in the sample bot's order of precedence
+	for _, zone := range gameState.Zones {
+		for y := int(zone.Y); y < int(zone.Y+zone.Height); y++ {
+			for x := int(zone.X); x < int(zone.X+zone.Width); x++ {
+				draw(x, y, string(rune(zone.Index)))
+			}
+		}
+	}
+	for _, item := range gameState.Items {
+		symbol, ok := itemSymbols[item.Type]
+		if !ok {
+			symbol = "?"
+		}
+		draw(item.X, item.Y, symbol)
+	}
+	for _, mine := range gameState.Mines {
+		draw(mine.X, mine.Y, "X")
+	}
+	for _, laser := range gameState.Lasers {
+		draw(laser.X, laser.Y, laserSymbols[laser.Orientation])
+	}
+	for _, bullet := range gameState.Bullets {
+		if bullet.Type == "double" {
+			draw(bullet.X, bullet.Y, doubleBulletSymbols[bullet.Direction])
+		} else {
+			draw(bullet.X, bullet.Y, basicBulletSymbols[bullet.Direction])
+		}
+	}
+	for _, tank := range gameState.Tanks {
+		draw(tank.X, tank.Y, tankSymbol(gameState, tank.OwnerID))
+	}
+	for _, wall := range gameState.Walls {
+		draw(wall.X, wall.Y, "#")
+	}
+
+	var b strings.Builder
+	for _, row := range cells {
+		b.WriteString(strings.Join(row, " "))
+		b.WriteString("\n")
+	}
+
+	var legend []string
+	for _, tank := range gameState.Tanks {
+		nickname := tank.OwnerID
+		if player := gameState.Player(tank.OwnerID); player != nil {
+			nickname = player.Nickname
+		}
+		legend = append(legend, fmt.Sprintf("%s %s facing %s", tankSymbol(gameState, tank.OwnerID), nickname, tank.Direction))
+	}
+	if len(legend) > 0 {
+		fmt.Fprintf(&b, "Tanks: %s\n", strings.Join(legend, ", "))
+	}
+
+	return b.String()
+}
+
+// tankSymbol returns the symbol of the tank of the player.
+func tankSymbol(gameState *game_state.GameState, ownerID string) string {
+	for i, player := range gameState.Players {
+		if player.ID == ownerID && i < 9 {
+			return fmt.Sprint(i + 1)
+		}
+	}
+	return "T"
+}
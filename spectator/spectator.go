@@ -0,0 +1,93 @@
+// Package spectator watches a game without taking part in it.
+//
+// A spectator receives the same packets as a bot, but its game states show
+// the tanks, bullets and items of every player, and it never responds to them.
+// The spectator draws the map of every game state in the terminal, and can
+// record the game to replay it later.
+package spectator
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"hackarena2-0-mono-tanks-go/packet"
+	"hackarena2-0-mono-tanks-go/packet/packets/game_end"
+	"hackarena2-0-mono-tanks-go/packet/packets/game_state"
+	"hackarena2-0-mono-tanks-go/packet/packets/lobby_data"
+)
+
+// Spectator draws the map and a summary of each game state it receives.
+type Spectator struct {
+	lobbyData *lobby_data.LobbyData
+	recorder  *Recorder
+}
+
+// OnJoiningLobby is called when the spectator joins the lobby. The spectator
+// records the packets it receives to recording, nil for none.
+func OnJoiningLobby(lobbyData *lobby_data.LobbyData, recording io.Writer) *Spectator {
+	fmt.Printf("[System] 👀 Watching a lobby of %d players\n", len(lobbyData.Players))
+	s := &Spectator{lobbyData: lobbyData}
+	if recording != nil {
+		s.recorder = NewRecorder(recording)
+	}
+	s.record(packet.LobbyDataPacket, lobbyData)
+	return s
+}
+
+// OnLobbyDataChanged is called when the lobby changes, for example when a player joins.
+func (s *Spectator) OnLobbyDataChanged(lobbyData *lobby_data.LobbyData) {
+	s.lobbyData = lobbyData
+	s.record(packet.LobbyDataPacket, lobbyData)
+}
+
+// OnGameState is called for every game state, with the whole map visible.
+func (s *Spectator) OnGameState(gameState *game_state.GameState) {
+	s.record(packet.GameStatePacket, gameState)
+	fmt.Print(Render(gameState))
+	fmt.Println(Summary(gameState))
+}
+
+// OnGameEnded is called with the final results of the game.
+func (s *Spectator) OnGameEnded(gameEnd *game_end.GameEnd) {
+	s.record(packet.GameEndedPacket, gameEnd)
+	fmt.Println("[System] 🏁 Final results:")
+	for i, player := range gameEnd.Players {
+		fmt.Printf("%d. %s - Score: %d, Kills: %d\n", i+1, player.Nickname, player.Score, player.Kills)
+	}
+}
+
+// record records the packet if the spectator is recording.
+func (s *Spectator) record(packetType packet.PacketType, payload interface{}) {
+	if s.recorder == nil {
+		return
+	}
+	if err := s.recorder.Record(packetType, payload); err != nil {
+		log.Printf("[System] 🚨 %s packet not recorded -> %v", packetType, err)
+	}
+}
+
+// Summary describes the players of a game state in one line, such as
+// "Tick 42 | GO1: 10 pts, 80 hp | PY1: 5 pts, respawning in 3".
+func Summary(gameState *game_state.GameState) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Tick %d", gameState.Tick)
+
+	for _, player := range gameState.Players {
+		var score uint64
+		if player.Score != nil {
+			score = *player.Score
+		}
+		fmt.Fprintf(&b, " | %s: %d pts", player.Nickname, score)
+
+		switch tank := gameState.Tank(player.ID); {
+		case player.TicksToRegen != nil:
+			fmt.Fprintf(&b, ", respawning in %d", *player.TicksToRegen)
+		case tank != nil && tank.Health != nil:
+			fmt.Fprintf(&b, ", %d hp", *tank.Health)
+		}
+	}
+
+	return b.String()
+}
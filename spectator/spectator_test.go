@@ -0,0 +1,75 @@
+package spectator
+
+import (
+	"bytes"
+	"hackarena2-0-mono-tanks-go/packet"
+	"hackarena2-0-mono-tanks-go/packet/codec"
+	"hackarena2-0-mono-tanks-go/packet/packets/game_end"
+	"hackarena2-0-mono-tanks-go/packet/packets/game_state"
+	"hackarena2-0-mono-tanks-go/scenario"
+	"strings"
+	"testing"
+)
+
+func TestSummary(t *testing.T) {
+	health := 80
+	score, otherScore, ticksToRegen := uint64(10), uint64(5), uint64(3)
+
+	gameState := &game_state.GameState{
+		Tick: 42,
+		Players: []game_state.Player{
+			{ID: "p1", Nickname: "GO1", Score: &score},
+			{ID: "p2", Nickname: "PY1", Score: &otherScore, TicksToRegen: &ticksToRegen},
+			{ID: "p3", Nickname: "RS1"},
+		},
+		Tanks: []game_state.Tank{{OwnerID: "p1", Health: &health}},
+	}
+
+	expected := "Tick 42 | GO1: 10 pts, 80 hp | PY1: 5 pts, respawning in 3 | RS1: 0 pts"
+	if summary := Summary(gameState); summary != expected {
+		t.Errorf("Expected %q, got %q", expected, summary)
+	}
+}
+
+func TestRender(t *testing.T) {
+	s := scenario.MustParse(`
+		# # # # #
+		# > . T #
+		# a ⇊ X #
+		# a L ═ #
+	`)
+
+	expected := "# # # # #\n" +
+		"# 1 . 2 #\n" +
+		"# A ⇊ X #\n" +
+		"# A L ═ #\n" +
+		"Tanks: 1 Me facing right, 2 Enemy 1 facing up\n"
+	if picture := Render(s.GameState); picture != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, picture)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	s := scenario.MustParse(`> T`)
+	var recording bytes.Buffer
+	spectator := OnJoiningLobby(s.LobbyData(), &recording)
+	spectator.OnGameState(s.GameState)
+	spectator.OnGameEnded(&game_end.GameEnd{Players: []game_end.GameEndPlayer{{Nickname: "GO1", Score: 10}}})
+
+	lines := strings.Split(strings.TrimSpace(recording.String()), "\n")
+	expected := []packet.PacketType{packet.LobbyDataPacket, packet.GameStatePacket, packet.GameEndedPacket}
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected %d recorded packets, got %d:\n%s", len(expected), len(lines), recording.String())
+	}
+	for i, line := range lines {
+		p, err := codec.Decode([]byte(line))
+		if err != nil || p.Type != expected[i] {
+			t.Errorf("Expected a %s packet, got %s, %v", expected[i], p.Type, err)
+		}
+	}
+
+	p, _ := codec.Decode([]byte(lines[1]))
+	if gameState := p.Payload.(*game_state.GameState); len(gameState.Tanks) != 2 || gameState.Tank(scenario.MyID) == nil {
+		t.Errorf("Expected the recorded game state to hold both tanks, got %+v", gameState.Tanks)
+	}
+}
@@ -0,0 +1,14 @@
+// Package strategies names the strategies the bot can play with, chosen with
+// the --strategy flag.
+package strategies
+
+const (
+	// Random is the sample logic in the bot's NextMove, taking random actions.
+	Random = "random"
+
+	// MCTS searches for the next move with the mcts package.
+	MCTS = "mcts"
+)
+
+// Names lists the strategies the bot can play with.
+var Names = []string{Random, MCTS}
@@ -0,0 +1,215 @@
+// Package timing estimates how much time the bot has left to respond to a
+// game state.
+//
+// The server broadcasts a game state every tick and only accepts an action
+// for it until the next broadcast. The Tracker combines the broadcast
+// interval from the lobby settings, the actual arrival times of the game
+// states and the ping the server reports for the bot into a Timing for
+// every tick.
+package timing
+
+import (
+	"sync"
+	"time"
+
+	"hackarena2-0-mono-tanks-go/packet/packets/game_state"
+)
+
+// Timing describes the time budget of a single tick.
+type Timing struct {
+	// Tick is the tick of the game state.
+	Tick uint64
+
+	// ReceivedAt is when the game state was received.
+	ReceivedAt time.Time
+
+	// TickStart is when the server is estimated to have broadcast the game
+	// state, half of the ping before it was received.
+	TickStart time.Time
+
+	// TickInterval is the estimated time between two game states, zero if unknown.
+	TickInterval time.Duration
+
+	// Deadline is the estimated time by which the action has to be sent to
+	// reach the server before the next tick, zero if the interval is unknown.
+	Deadline time.Time
+
+	// Ping is the round-trip time the server reports for the bot, zero if unknown.
+	Ping time.Duration
+
+	// LastResponseTime is the time between receiving the previous game state
+	// and sending the action for it, zero if no action was sent.
+	LastResponseTime time.Duration
+}
+
+// SinceTickStart returns the time elapsed since the server broadcast the game state.
+func (t Timing) SinceTickStart() time.Duration {
+	return time.Since(t.TickStart)
+}
+
+// RemainingBudget returns the estimated time left to send the action, which
+// is negative once the deadline has passed, and zero if it is unknown.
+func (t Timing) RemainingBudget() time.Duration {
+	if t.Deadline.IsZero() {
+		return 0
+	}
+	return time.Until(t.Deadline)
+}
+
+// ResponseStats summarizes the time between receiving game states and
+// sending the actions for them.
+type ResponseStats struct {
+	// Count is the number of actions sent.
+	Count int
+
+	// Average is the mean response time.
+	Average time.Duration
+
+	// Max is the longest response time.
+	Max time.Duration
+
+	// Late is the number of actions sent after their estimated deadline.
+	Late int
+}
+
+const (
+	// smoothing is the weight of a new arrival in the tick interval estimate.
+	smoothing = 0.2
+
+	// keptTicks is the number of recent game states kept to match the actions sent for them.
+	keptTicks = 16
+)
+
+// Tracker is safe for concurrent use.
+type Tracker struct {
+	mutex            sync.Mutex
+	playerID         string
+	interval         time.Duration
+	lastTick         uint64
+	lastReceivedAt   time.Time
+	timings          map[string]Timing
+	order            []string
+	lastResponseTime time.Duration
+	total            time.Duration
+	stats            ResponseStats
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{
+		timings: make(map[string]Timing),
+	}
+}
+
+// SetLobby sets the player whose ping is tracked, and the broadcast interval
+// used until game states have been received.
+func (t *Tracker) SetLobby(playerID string, broadcastInterval time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.playerID = playerID
+	if t.lastReceivedAt.IsZero() {
+		t.interval = broadcastInterval
+	}
+}
+
+// Reset forgets the game states received so far, so that the ticks of the
+// next game, which start over from zero, are not taken for late ones. The
+// interval estimate and the response stats are kept.
+func (t *Tracker) Reset() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.lastTick = 0
+	t.lastReceivedAt = time.Time{}
+	t.timings = make(map[string]Timing)
+	t.order = nil
+}
+
+// Received records the arrival of a game state and returns its timing.
+func (t *Tracker) Received(gameState *game_state.GameState, receivedAt time.Time) Timing {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	// Out of order game states say nothing about the cadence
+	if !t.lastReceivedAt.IsZero() && gameState.Tick > t.lastTick {
+		sample := receivedAt.Sub(t.lastReceivedAt) / time.Duration(gameState.Tick-t.lastTick)
+		if t.interval == 0 {
+			t.interval = sample
+		} else {
+			t.interval += time.Duration(smoothing * float64(sample-t.interval))
+		}
+	}
+	if t.lastReceivedAt.IsZero() || gameState.Tick > t.lastTick {
+		t.lastTick = gameState.Tick
+		t.lastReceivedAt = receivedAt
+	}
+
+	var ping time.Duration
+	for _, player := range gameState.Players {
+		if player.ID == t.playerID && player.Ping != nil {
+			ping = time.Duration(*player.Ping) * time.Millisecond
+		}
+	}
+
+	timing := Timing{
+		Tick:             gameState.Tick,
+		ReceivedAt:       receivedAt,
+		TickStart:        receivedAt.Add(-ping / 2),
+		TickInterval:     t.interval,
+		Ping:             ping,
+		LastResponseTime: t.lastResponseTime,
+	}
+	if t.interval > 0 {
+		timing.Deadline = timing.TickStart.Add(t.interval - ping/2)
+	}
+
+	t.timings[gameState.ID] = timing
+	t.order = append(t.order, gameState.ID)
+	if len(t.order) > keptTicks {
+		delete(t.timings, t.order[0])
+		t.order = t.order[1:]
+	}
+
+	return timing
+}
+
+// Timing returns the timing of a recently received game state.
+func (t *Tracker) Timing(gameStateID string) (Timing, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	timing, ok := t.timings[gameStateID]
+	return timing, ok
+}
+
+// Sent records that the action for a game state was sent, and returns the
+// time since the game state was received.
+func (t *Tracker) Sent(gameStateID string, sentAt time.Time) (time.Duration, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	timing, ok := t.timings[gameStateID]
+	if !ok {
+		return 0, false
+	}
+
+	responseTime := sentAt.Sub(timing.ReceivedAt)
+	t.lastResponseTime = responseTime
+	t.total += responseTime
+	t.stats.Count++
+	t.stats.Average = t.total / time.Duration(t.stats.Count)
+	if responseTime > t.stats.Max {
+		t.stats.Max = responseTime
+	}
+	if !timing.Deadline.IsZero() && sentAt.After(timing.Deadline) {
+		t.stats.Late++
+	}
+	return responseTime, true
+}
+
+// Stats returns the response times of the actions sent so far.
+func (t *Tracker) Stats() ResponseStats {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.stats
+}
@@ -0,0 +1,134 @@
+package timing
+
+import (
+	"hackarena2-0-mono-tanks-go/packet/packets/game_state"
+	"testing"
+	"time"
+)
+
+func gameStateAt(id string, tick uint64, ping uint64) *game_state.GameState {
+	return &game_state.GameState{
+		ID:      id,
+		Tick:    tick,
+		Players: []game_state.Player{{ID: "me", Ping: &ping}, {ID: "enemy"}},
+	}
+}
+
+func TestReceivedUsesBroadcastIntervalUntilGameStatesArrive(t *testing.T) {
+	tracker := NewTracker()
+	tracker.SetLobby("me", 100*time.Millisecond)
+
+	start := time.Unix(1000, 0)
+	timing := tracker.Received(gameStateAt("s1", 1, 20), start)
+
+	if timing.Ping != 20*time.Millisecond {
+		t.Errorf("Expected ping 20ms, got %v", timing.Ping)
+	}
+	if expected := start.Add(-10 * time.Millisecond); !timing.TickStart.Equal(expected) {
+		t.Errorf("Expected tick start half a ping before arrival %v, got %v", expected, timing.TickStart)
+	}
+	if timing.TickInterval != 100*time.Millisecond {
+		t.Errorf("Expected broadcast interval 100ms, got %v", timing.TickInterval)
+	}
+	// The action has to travel back to the server too
+	if expected := start.Add(80 * time.Millisecond); !timing.Deadline.Equal(expected) {
+		t.Errorf("Expected deadline %v, got %v", expected, timing.Deadline)
+	}
+}
+
+func TestReceivedEstimatesIntervalFromArrivals(t *testing.T) {
+	tracker := NewTracker()
+	tracker.SetLobby("me", 100*time.Millisecond)
+
+	start := time.Unix(1000, 0)
+	tracker.Received(gameStateAt("s1", 1, 0), start)
+	timing := tracker.Received(gameStateAt("s2", 2, 0), start.Add(150*time.Millisecond))
+
+	// 100ms + 0.2 * (150ms - 100ms)
+	if timing.TickInterval != 110*time.Millisecond {
+		t.Errorf("Expected interval 110ms, got %v", timing.TickInterval)
+	}
+
+	// A missed tick counts as two intervals
+	timing = tracker.Received(gameStateAt("s4", 4, 0), start.Add(370*time.Millisecond))
+	if timing.TickInterval != 110*time.Millisecond {
+		t.Errorf("Expected interval to stay 110ms, got %v", timing.TickInterval)
+	}
+
+	// Late game states of past ticks do not change the estimate
+	timing = tracker.Received(gameStateAt("s3", 3, 0), start.Add(400*time.Millisecond))
+	if timing.TickInterval != 110*time.Millisecond {
+		t.Errorf("Expected interval to stay 110ms, got %v", timing.TickInterval)
+	}
+}
+
+func TestResetStartsOverForTheNextGame(t *testing.T) {
+	tracker := NewTracker()
+	tracker.SetLobby("me", 100*time.Millisecond)
+
+	start := time.Unix(1000, 0)
+	tracker.Received(gameStateAt("s1", 500, 0), start)
+	tracker.Received(gameStateAt("s2", 501, 0), start.Add(100*time.Millisecond))
+	tracker.Reset()
+
+	if _, ok := tracker.Timing("s2"); ok {
+		t.Errorf("Expected the game states of the last game to be forgotten")
+	}
+
+	// The ticks of the next game start over, and still say something about the cadence
+	tracker.Received(gameStateAt("n1", 1, 0), start.Add(5*time.Second))
+	timing := tracker.Received(gameStateAt("n2", 2, 0), start.Add(5*time.Second+150*time.Millisecond))
+	if timing.TickInterval != 110*time.Millisecond {
+		t.Errorf("Expected interval 110ms, got %v", timing.TickInterval)
+	}
+}
+
+func TestReceivedWithoutInterval(t *testing.T) {
+	tracker := NewTracker()
+	timing := tracker.Received(gameStateAt("s1", 1, 0), time.Unix(1000, 0))
+
+	if timing.TickInterval != 0 || !timing.Deadline.IsZero() || timing.RemainingBudget() != 0 {
+		t.Errorf("Expected an unknown budget, got %+v", timing)
+	}
+}
+
+func TestSent(t *testing.T) {
+	tracker := NewTracker()
+	tracker.SetLobby("me", 100*time.Millisecond)
+
+	start := time.Unix(1000, 0)
+	tracker.Received(gameStateAt("s1", 1, 0), start)
+	if responseTime, ok := tracker.Sent("s1", start.Add(30*time.Millisecond)); !ok || responseTime != 30*time.Millisecond {
+		t.Errorf("Expected response time 30ms, got %v, %v", responseTime, ok)
+	}
+
+	timing := tracker.Received(gameStateAt("s2", 2, 0), start.Add(100*time.Millisecond))
+	if timing.LastResponseTime != 30*time.Millisecond {
+		t.Errorf("Expected last response time 30ms, got %v", timing.LastResponseTime)
+	}
+	tracker.Sent("s2", start.Add(250*time.Millisecond))
+
+	if _, ok := tracker.Sent("unknown", start); ok {
+		t.Errorf("Expected no response time for an unknown game state")
+	}
+
+	expected := ResponseStats{Count: 2, Average: 90 * time.Millisecond, Max: 150 * time.Millisecond, Late: 1}
+	if stats := tracker.Stats(); stats != expected {
+		t.Errorf("Expected stats %+v, got %+v", expected, stats)
+	}
+}
+
+func TestTimingKeepsRecentTicks(t *testing.T) {
+	tracker := NewTracker()
+	start := time.Unix(1000, 0)
+	for tick := uint64(0); tick < keptTicks+1; tick++ {
+		tracker.Received(gameStateAt(string(rune('a'+tick)), tick, 0), start.Add(time.Duration(tick)*time.Second))
+	}
+
+	if _, ok := tracker.Timing("a"); ok {
+		t.Errorf("Expected the oldest game state to be forgotten")
+	}
+	if timing, ok := tracker.Timing(string(rune('a' + keptTicks))); !ok || timing.Tick != keptTicks {
+		t.Errorf("Expected the newest game state to be kept, got %+v, %v", timing, ok)
+	}
+}
@@ -0,0 +1,397 @@
+// Package utility_ai chooses moves by scoring every response the bot could
+// send, as an alternative to the bt package's behaviour trees.
+//
+// Each tick the Reasoner plays every legal response in the forward_model,
+// followed by a few ticks of every tank passing, and rates the outcome with
+// considerations: functions returning a value from 0 to 1 for a single
+// concern, such as the danger the tank is in or its way to a zone. The
+// weighted sum of the considerations is the score of the response, and the
+// best score wins. Turn on Config.Debug to see every score and tune the
+// weights.
+package utility_ai
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+
+	"hackarena2-0-mono-tanks-go/forward_model"
+	"hackarena2-0-mono-tanks-go/item_planner"
+	"hackarena2-0-mono-tanks-go/packet/packets/bot_response"
+	"hackarena2-0-mono-tanks-go/packet/packets/game_state"
+	"hackarena2-0-mono-tanks-go/pathing"
+	"hackarena2-0-mono-tanks-go/zone_control"
+)
+
+// Context is what considerations know about the tick being scored.
+type Context struct {
+	GameState *game_state.GameState
+	PlayerID  string
+
+	// Before is the game state in the forward model, and Me the index of our tank in it.
+	Before *forward_model.State
+	Me     int
+
+	// Zones are the zones as seen from our tank.
+	Zones []zone_control.Report
+
+	// Map holds the tiles our tank can't drive onto.
+	Map *pathing.Map
+
+	searches map[pathing.Pose]*pathing.Search
+}
+
+// Search returns the fastest ways from the pose of the tank, computed once per pose and tick.
+func (c *Context) Search(tank *forward_model.Tank) *pathing.Search {
+	pose := pathing.Pose{X: tank.X, Y: tank.Y, Direction: pathing.Directions[tank.Direction]}
+	search, ok := c.searches[pose]
+	if !ok {
+		search = c.Map.Search(pose)
+		c.searches[pose] = search
+	}
+	return search
+}
+
+// Option is a response the bot could send, with its outcome.
+type Option struct {
+	Action   forward_model.Action
+	Response *bot_response.BotResponse
+
+	// After is the state once the action and Config.Lookahead ticks in total are played.
+	After *forward_model.State
+}
+
+// Me returns our tank after the option.
+func (o *Option) Me(context *Context) *forward_model.Tank {
+	return &o.After.Tanks[context.Me]
+}
+
+// Consideration rates one concern of an option, from 0 to 1.
+type Consideration func(context *Context, option *Option) float64
+
+// Weighted is a consideration with its weight in the score. Concerns to
+// avoid, such as danger, have a negative weight.
+type Weighted struct {
+	Name     string
+	Weight   float64
+	Consider Consideration
+}
+
+// DefaultConsiderations steer clear of danger first, then look for kills,
+// zones and items.
+var DefaultConsiderations = []Weighted{
+	{Name: "danger", Weight: -4, Consider: Danger},
+	{Name: "kill", Weight: 3, Consider: KillChance},
+	{Name: "zone", Weight: 1, Consider: ZoneValue},
+	{Name: "item", Weight: 1, Consider: ItemValue},
+}
+
+// Config tunes the reasoner.
+type Config struct {
+	// Rules are the rules of the forward model.
+	Rules *forward_model.Rules
+
+	// Lookahead is the number of ticks played for each option, the first
+	// with the option and the rest with every tank passing.
+	Lookahead int
+
+	// Considerations score the options.
+	Considerations []Weighted
+
+	// Debug receives the scores of every tick, nil for none.
+	Debug io.Writer
+}
+
+// DefaultConfig looks three ticks ahead, long enough for a bullet fired at
+// close range to land.
+var DefaultConfig = Config{
+	Rules:          &forward_model.DefaultRules,
+	Lookahead:      3,
+	Considerations: DefaultConsiderations,
+}
+
+// Score is the score of an option.
+type Score struct {
+	Action   forward_model.Action
+	Response *bot_response.BotResponse
+
+	// Values holds the value of each consideration, in the order of Config.Considerations.
+	Values []float64
+
+	// Total is the weighted sum of the values.
+	Total float64
+}
+
+// Scores are the scores of a tick, best first.
+type Scores struct {
+	Tick           uint64
+	Considerations []Weighted
+	Options        []Score
+}
+
+// String draws the scores as a table.
+func (s Scores) String() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%-26s", "response")
+	for _, consideration := range s.Considerations {
+		fmt.Fprintf(&builder, " %9s", fmt.Sprintf("%s*%g", consideration.Name, consideration.Weight))
+	}
+	fmt.Fprintf(&builder, " %9s\n", "total")
+
+	for _, option := range s.Options {
+		fmt.Fprintf(&builder, "%-26s", Describe(option.Response))
+		for _, value := range option.Values {
+			fmt.Fprintf(&builder, " %9.3f", value)
+		}
+		fmt.Fprintf(&builder, " %9.3f\n", option.Total)
+	}
+	return builder.String()
+}
+
+// Describe names a response for the debug dump, such as "move forward" or
+// "rotate left, turret right".
+func Describe(response *bot_response.BotResponse) string {
+	switch response.Type {
+	case bot_response.Movement:
+		return "move " + response.Direction
+	case bot_response.Rotation:
+		var parts []string
+		if response.TankRotation != "" {
+			parts = append(parts, "rotate "+response.TankRotation)
+		}
+		if response.TurretRotation != "" {
+			parts = append(parts, "turret "+response.TurretRotation)
+		}
+		return strings.Join(parts, ", ")
+	case bot_response.AbilityUse:
+		return response.AbilityType
+	default:
+		return string(response.Type)
+	}
+}
+
+// Reasoner picks the response with the best score.
+type Reasoner struct {
+	playerID string
+	config   Config
+	last     Scores
+}
+
+func New(playerID string, config Config) *Reasoner {
+	return &Reasoner{
+		playerID: playerID,
+		config:   config,
+	}
+}
+
+// LastScores returns the scores of the last NextMove.
+func (r *Reasoner) LastScores() Scores {
+	return r.last
+}
+
+// NextMove returns the response with the best score, or a pass while our
+// tank is destroyed or the game state can't be scored.
+func (r *Reasoner) NextMove(gameState *game_state.GameState) *bot_response.BotResponse {
+	scores, err := r.Score(gameState)
+	r.last = scores
+	if err != nil {
+		log.Printf("[System] 🚨 Tick %d not scored, passing instead -> %v", gameState.Tick, err)
+		return bot_response.NewPass()
+	}
+	if r.config.Debug != nil {
+		fmt.Fprintf(r.config.Debug, "[System] 🧮 Tick %d utility scores\n%s", gameState.Tick, r.last)
+	}
+
+	if len(r.last.Options) == 0 {
+		return bot_response.NewPass()
+	}
+	return r.last.Options[0].Response
+}
+
+// Score scores every legal response of our tank. Options with the same
+// score keep the order of forward_model.Actions. It fails if the forward
+// model can't read the game state.
+func (r *Reasoner) Score(gameState *game_state.GameState) (Scores, error) {
+	scores := Scores{Tick: gameState.Tick, Considerations: r.config.Considerations}
+
+	before, err := forward_model.FromGameState(gameState, r.config.Rules)
+	if err != nil {
+		return scores, err
+	}
+	me := before.TankIndex(r.playerID)
+	if me < 0 || !before.Tanks[me].Alive() {
+		return scores, nil
+	}
+
+	context := &Context{
+		GameState: gameState,
+		PlayerID:  r.playerID,
+		Before:    before,
+		Me:        me,
+		Zones:     zone_control.Analyze(gameState, r.playerID, zone_control.DefaultOptions),
+		Map:       pathing.NewMap(gameState, r.playerID),
+		searches:  make(map[pathing.Pose]*pathing.Search),
+	}
+
+	actions := make([]forward_model.Action, len(before.Tanks))
+	for _, action := range forward_model.Actions {
+		if !before.Legal(me, action) {
+			continue
+		}
+
+		option := &Option{Action: action, Response: action.Response(), After: before.Clone()}
+		actions[me] = action
+		option.After.Step(actions)
+		actions[me] = forward_model.Pass
+		for tick := 1; tick < r.config.Lookahead; tick++ {
+			option.After.Step(actions)
+		}
+
+		score := Score{Action: action, Response: option.Response, Values: make([]float64, len(r.config.Considerations))}
+		for i, consideration := range r.config.Considerations {
+			score.Values[i] = consideration.Consider(context, option)
+			score.Total += consideration.Weight * score.Values[i]
+		}
+		scores.Options = append(scores.Options, score)
+	}
+
+	sort.SliceStable(scores.Options, func(i, j int) bool {
+		return scores.Options[i].Total > scores.Options[j].Total
+	})
+	return scores, nil
+}
+
+// Danger rates how much of our health is lost during the lookahead, plus
+// the damage of the bullets and loaded enemy turrets lined up with our
+// tank at its end. A destroyed tank is in full danger.
+func Danger(context *Context, option *Option) float64 {
+	me := option.Me(context)
+	if !me.Alive() {
+		return 1
+	}
+
+	rules := option.After.Rules
+	health := float64(context.Before.Tanks[context.Me].Health)
+	danger := float64(context.Before.Tanks[context.Me].Health - me.Health)
+
+	for _, bullet := range option.After.Bullets {
+		if option.After.LineOfFire(bullet.X, bullet.Y, bullet.Direction) == context.Me {
+			danger += float64(bullet.Damage)
+		}
+	}
+	for i := range option.After.Tanks {
+		enemy := &option.After.Tanks[i]
+		if i == context.Me || !enemy.Alive() || enemy.Bullets == 0 {
+			continue
+		}
+		if option.After.LineOfFire(enemy.X, enemy.Y, enemy.TurretDirection) == context.Me {
+			danger += float64(rules.BulletDamage)
+		}
+	}
+
+	return min(danger/health, 1)
+}
+
+// KillChance rates the damage done to the most hurt enemy during the
+// lookahead, plus our bullets still flying at it, as a share of its health.
+// Aiming a loaded turret at an enemy is worth a quarter of a bullet.
+func KillChance(context *Context, option *Option) float64 {
+	best := 0.0
+	expected := make([]float64, len(option.After.Tanks))
+
+	for i := range option.After.Tanks {
+		if i != context.Me {
+			expected[i] = float64(context.Before.Tanks[i].Health - max(option.After.Tanks[i].Health, 0))
+		}
+	}
+	for _, bullet := range option.After.Bullets {
+		if bullet.Owner != context.Me {
+			continue
+		}
+		if target := option.After.LineOfFire(bullet.X, bullet.Y, bullet.Direction); target >= 0 && target != context.Me {
+			expected[target] += float64(bullet.Damage)
+		}
+	}
+
+	me := option.Me(context)
+	if me.Alive() && me.Bullets > 0 {
+		if target := option.After.LineOfFire(me.X, me.Y, me.TurretDirection); target >= 0 {
+			expected[target] += float64(option.After.Rules.BulletDamage) / 4
+		}
+	}
+
+	for i, damage := range expected {
+		if i == context.Me || damage == 0 {
+			continue
+		}
+		best = max(best, min(damage/float64(context.Before.Tanks[i].Health), 1))
+	}
+	return best
+}
+
+// ZoneValue is 1 inside a zone we don't hold, and falls with the ticks
+// needed to reach the nearest one otherwise.
+func ZoneValue(context *Context, option *Option) float64 {
+	me := option.Me(context)
+	if !me.Alive() {
+		return 0
+	}
+
+	var targets []pathing.Point
+	for _, report := range context.Zones {
+		if report.Status == zone_control.Captured && report.OwnerID == context.PlayerID {
+			continue
+		}
+		if zone_control.Contains(report.Zone, me.X, me.Y) {
+			return 1
+		}
+		for y := int(report.Zone.Y); y < int(report.Zone.Y+report.Zone.Height); y++ {
+			for x := int(report.Zone.X); x < int(report.Zone.X+report.Zone.Width); x++ {
+				targets = append(targets, pathing.Point{X: x, Y: y})
+			}
+		}
+	}
+
+	if _, ticks, ok := context.Search(me).Nearest(targets); ok {
+		return 1 / float64(1+ticks)
+	}
+	return 0
+}
+
+// ItemValue rates the item picked up during the lookahead, or the best
+// item on the map divided by one more than the ticks needed to reach it.
+// Items are worth their value in item_planner.DefaultOptions, relative to
+// the best one. A tank holding an item can't pick up another.
+func ItemValue(context *Context, option *Option) float64 {
+	me := option.Me(context)
+	if !me.Alive() || context.Before.Tanks[context.Me].Item != "" {
+		return 0
+	}
+	if me.Item != "" {
+		return itemValue(me.Item)
+	}
+
+	best := 0.0
+	search := context.Search(me)
+	for _, item := range option.After.Items {
+		if ticks, ok := search.Ticks(item.X, item.Y); ok {
+			best = max(best, itemValue(item.Type)/float64(1+ticks))
+		}
+	}
+	return best
+}
+
+// itemValue returns the value of the item type from 0 to 1.
+func itemValue(itemType string) float64 {
+	best := 0.0
+	for _, value := range item_planner.DefaultOptions.Values {
+		best = max(best, value)
+	}
+	value, ok := item_planner.DefaultOptions.Values[itemType]
+	if !ok {
+		value = item_planner.DefaultOptions.Values[item_planner.UnknownItem]
+	}
+	return value / best
+}
@@ -0,0 +1,148 @@
+package utility_ai
+
+import (
+	"bytes"
+	"hackarena2-0-mono-tanks-go/forward_model"
+	"hackarena2-0-mono-tanks-go/packet/packets/bot_response"
+	"hackarena2-0-mono-tanks-go/packet/packets/bot_response/movement"
+	"hackarena2-0-mono-tanks-go/scenario"
+	"strings"
+	"testing"
+)
+
+func TestNextMoveFiresAtAnEnemyInLine(t *testing.T) {
+	s := scenario.MustParse(`
+		# # # # #
+		# > . T #
+		# # # # #
+	`)
+	reasoner := New(scenario.MyID, DefaultConfig)
+
+	response := reasoner.NextMove(s.GameState)
+	if *response != *bot_response.NewAbilityUse("fireBullet") {
+		t.Errorf("Expected to fire, got %+v\n%s", response, reasoner.LastScores())
+	}
+
+	// Without an item, the four abilities using one are not legal
+	if options := len(reasoner.LastScores().Options); options != len(forward_model.Actions)-4 {
+		t.Errorf("Expected %d options, got %d", len(forward_model.Actions)-4, options)
+	}
+}
+
+func TestNextMoveDodgesABullet(t *testing.T) {
+	s := scenario.MustParse(`
+		# # # # #
+		# . > . #
+		# . . . #
+		# . . . #
+		# . ↑ . #
+		# # # # #
+	`)
+	reasoner := New(scenario.MyID, DefaultConfig)
+
+	response := reasoner.NextMove(s.GameState)
+	if response.Type != bot_response.Movement {
+		t.Errorf("Expected to drive out of the bullet's way, got %+v\n%s", response, reasoner.LastScores())
+	}
+
+	scores := reasoner.LastScores()
+	for _, option := range scores.Options {
+		if option.Action == forward_model.Pass && option.Values[0] != 0.2 {
+			t.Errorf("Expected a danger of 0.2 when passing, got %v", option.Values[0])
+		}
+	}
+}
+
+func TestNextMoveDrivesToAZone(t *testing.T) {
+	s := scenario.MustParse(`> . . a a`)
+	reasoner := New(scenario.MyID, DefaultConfig)
+
+	if response := reasoner.NextMove(s.GameState); *response != *bot_response.NewMovement(movement.Forward) {
+		t.Errorf("Expected to drive to the zone, got %+v\n%s", response, reasoner.LastScores())
+	}
+}
+
+func TestNextMovePicksUpAnItem(t *testing.T) {
+	s := scenario.MustParse(`
+		. . .
+		. ^ .
+		. D .
+	`)
+	reasoner := New(scenario.MyID, DefaultConfig)
+
+	if response := reasoner.NextMove(s.GameState); *response != *bot_response.NewMovement(movement.Backward) {
+		t.Errorf("Expected to back onto the item, got %+v\n%s", response, reasoner.LastScores())
+	}
+}
+
+func TestNextMovePassesWithoutATank(t *testing.T) {
+	s := scenario.MustParse(`. T`)
+	reasoner := New(scenario.MyID, DefaultConfig)
+
+	if response := reasoner.NextMove(s.GameState); *response != *bot_response.NewPass() {
+		t.Errorf("Expected a pass, got %+v", response)
+	}
+	if options := reasoner.LastScores().Options; len(options) != 0 {
+		t.Errorf("Expected no options, got %d", len(options))
+	}
+}
+
+func TestScoreRejectsUnknownDirections(t *testing.T) {
+	s := scenario.MustParse(`> .`)
+	s.GameState.Tanks[0].Direction = "sideways"
+	reasoner := New(scenario.MyID, DefaultConfig)
+
+	if _, err := reasoner.Score(s.GameState); err == nil {
+		t.Errorf("Expected an error for an unknown direction")
+	}
+	if response := reasoner.NextMove(s.GameState); *response != *bot_response.NewPass() {
+		t.Errorf("Expected a pass, got %+v", response)
+	}
+}
+
+func TestCustomConsiderations(t *testing.T) {
+	s := scenario.MustParse(`> .`)
+	config := DefaultConfig
+	config.Considerations = []Weighted{{Name: "spin", Weight: 2, Consider: func(context *Context, option *Option) float64 {
+		if option.Action == forward_model.RotateRightTurretLeft {
+			return 1
+		}
+		return 0
+	}}}
+	reasoner := New(scenario.MyID, config)
+
+	if response := reasoner.NextMove(s.GameState); *response != *forward_model.RotateRightTurretLeft.Response() {
+		t.Errorf("Expected the custom consideration to decide, got %+v", response)
+	}
+	if total := reasoner.LastScores().Options[0].Total; total != 2 {
+		t.Errorf("Expected a total of 2, got %v", total)
+	}
+}
+
+func TestDebugDump(t *testing.T) {
+	s := scenario.MustParse(`
+		# # # # #
+		# > . T #
+		# # # # #
+	`)
+	s.GameState.Tick = 42
+	var output bytes.Buffer
+	config := DefaultConfig
+	config.Debug = &output
+	New(scenario.MyID, config).NextMove(s.GameState)
+
+	dump := output.String()
+	for _, expected := range []string{"Tick 42 utility scores", "danger*-4", "kill*3", "fireBullet", "rotate left, turret right", "move backward", "pass"} {
+		if !strings.Contains(dump, expected) {
+			t.Errorf("Expected the dump to contain %q, got:\n%s", expected, dump)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(dump), "\n")
+	if len(lines) != 2+len(forward_model.Actions)-4 {
+		t.Errorf("Expected a header and a line per option, got %d lines", len(lines))
+	}
+	if !strings.HasPrefix(lines[2], "fireBullet") {
+		t.Errorf("Expected the best option first, got %q", lines[2])
+	}
+}
@@ -0,0 +1,345 @@
+package ws_client
+
+import (
+	"context"
+	"hackarena2-0-mono-tanks-go/packet"
+	"hackarena2-0-mono-tanks-go/packet/codec"
+	"hackarena2-0-mono-tanks-go/packet/packets/game_state"
+	"hackarena2-0-mono-tanks-go/packet/packets/lobby_data"
+	"hackarena2-0-mono-tanks-go/scenario"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// fakeServerTimeout bounds every wait in the fake server, so a broken client
+// fails the test instead of hanging it.
+const fakeServerTimeout = 2 * time.Second
+
+// fakeServer is an in-process MonoTanks server. Each accepted connection is
+// handed to the test as a fakeSession, which scripts the packets the server
+// sends and records the packets the client sends back.
+type fakeServer struct {
+	t            *testing.T
+	server       *httptest.Server
+	sessions     chan *fakeSession
+	cancelClient context.CancelFunc
+
+	// ignoreClose makes sessions never answer the client's close frame.
+	// It must be set before the client connects.
+	ignoreClose bool
+
+	// rejectHandshake, if set, is sent with a 403 status instead of upgrading
+	// the connection. It must be set before the client connects.
+	rejectHandshake string
+}
+
+// fakeSession is a single client connection to the fake server.
+type fakeSession struct {
+	t        *testing.T
+	conn     *websocket.Conn
+	query    url.Values
+	received chan packet.Packet
+	closed   chan error
+	log      []packet.Packet
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	t.Helper()
+
+	s := &fakeServer{
+		t:        t,
+		sessions: make(chan *fakeSession, 1),
+	}
+
+	upgrader := websocket.Upgrader{}
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.rejectHandshake != "" {
+			http.Error(w, s.rejectHandshake, http.StatusForbidden)
+			return
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+
+		if s.ignoreClose {
+			conn.SetCloseHandler(func(code int, text string) error { return nil })
+		}
+
+		session := &fakeSession{
+			t:        t,
+			conn:     conn,
+			query:    r.URL.Query(),
+			received: make(chan packet.Packet, 100),
+			closed:   make(chan error, 1),
+		}
+		s.sessions <- session
+		session.readLoop()
+	}))
+	t.Cleanup(s.server.Close)
+
+	return s
+}
+
+// start connects the client to the fake server and runs it in the background.
+// The returned channel receives the result of Run.
+func (s *fakeServer) start(client *WebSocketClient, nickname string, code string) <-chan error {
+	s.t.Helper()
+
+	host, port := s.address()
+	if err := client.Connect(host, port, code, nickname); err != nil {
+		s.t.Fatalf("Failed to connect to fake server: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancelClient = cancel
+	s.t.Cleanup(cancel)
+
+	result := make(chan error, 1)
+	go func() {
+		result <- client.Run(ctx)
+	}()
+	return result
+}
+
+// address returns the host and port the fake server listens on.
+func (s *fakeServer) address() (string, int) {
+	s.t.Helper()
+
+	serverURL, err := url.Parse(s.server.URL)
+	if err != nil {
+		s.t.Fatalf("Failed to parse fake server URL: %v", err)
+	}
+	port, err := strconv.Atoi(serverURL.Port())
+	if err != nil {
+		s.t.Fatalf("Failed to parse fake server port: %v", err)
+	}
+	return serverURL.Hostname(), port
+}
+
+// accept waits for the next client connection.
+func (s *fakeServer) accept() *fakeSession {
+	s.t.Helper()
+
+	select {
+	case session := <-s.sessions:
+		return session
+	case <-time.After(fakeServerTimeout):
+		s.t.Fatalf("Timed out waiting for client to connect")
+		return nil
+	}
+}
+
+func (s *fakeSession) readLoop() {
+	defer close(s.received)
+	for {
+		_, message, err := s.conn.ReadMessage()
+		if err != nil {
+			s.closed <- err
+			return
+		}
+
+		p, err := codec.Decode(message)
+		if err != nil {
+			s.t.Errorf("Client sent malformed packet %s: %v", message, err)
+			continue
+		}
+		s.received <- p
+	}
+}
+
+// send sends a packet with the given payload to the client.
+func (s *fakeSession) send(packetType packet.PacketType, payload interface{}) {
+	s.t.Helper()
+
+	message, err := codec.Encode(packet.Packet{Type: packetType, Payload: payload})
+	if err != nil {
+		s.t.Fatalf("Failed to marshal %s packet: %v", packetType, err)
+	}
+	s.sendRaw(string(message))
+}
+
+// sendRaw sends a raw text message to the client.
+func (s *fakeSession) sendRaw(message string) {
+	s.t.Helper()
+
+	if err := s.conn.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+		s.t.Fatalf("Failed to send message to client: %v", err)
+	}
+}
+
+// expect waits for the client to send a packet of the given type. Packets of
+// other types received meanwhile are kept in the log and can still be expected
+// later, since the client processes messages concurrently.
+func (s *fakeSession) expect(packetType packet.PacketType) packet.Packet {
+	s.t.Helper()
+
+	for i, p := range s.log {
+		if p.Type == packetType {
+			s.log = append(s.log[:i], s.log[i+1:]...)
+			return p
+		}
+	}
+
+	timeout := time.After(fakeServerTimeout)
+	for {
+		select {
+		case p, ok := <-s.received:
+			if !ok {
+				s.t.Fatalf("Connection closed while waiting for %s packet", packetType)
+			}
+			if p.Type == packetType {
+				return p
+			}
+			s.log = append(s.log, p)
+		case <-timeout:
+			s.t.Fatalf("Timed out waiting for %s packet, received %v", packetType, s.log)
+		}
+	}
+}
+
+// expectAction waits for the client to respond to a game state with any action packet.
+func (s *fakeSession) expectAction() packet.Packet {
+	s.t.Helper()
+
+	timeout := time.After(fakeServerTimeout)
+	for {
+		select {
+		case p, ok := <-s.received:
+			if !ok {
+				s.t.Fatalf("Connection closed while waiting for an action")
+			}
+			switch p.Type {
+			case packet.MovementPacket, packet.RotationPacket, packet.AbilityUsePacket, packet.PassPacket:
+				return p
+			}
+			s.log = append(s.log, p)
+		case <-timeout:
+			s.t.Fatalf("Timed out waiting for an action, received %v", s.log)
+		}
+	}
+}
+
+// expectNothing fails the test if the client sends any packet within the given duration.
+func (s *fakeSession) expectNothing(d time.Duration) {
+	s.t.Helper()
+
+	select {
+	case p, ok := <-s.received:
+		if ok {
+			s.t.Fatalf("Expected no packets, received %s", p.Type)
+		}
+	case <-time.After(d):
+	}
+}
+
+// expectClose waits for the client to close the connection and returns the read error.
+func (s *fakeSession) expectClose() error {
+	s.t.Helper()
+
+	select {
+	case err := <-s.closed:
+		return err
+	case <-time.After(fakeServerTimeout):
+		s.t.Fatalf("Timed out waiting for client to close the connection")
+		return nil
+	}
+}
+
+// closeNormally sends a close frame to the client, as the server does when the game is over.
+func (s *fakeSession) closeNormally() {
+	s.t.Helper()
+
+	message := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	if err := s.conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(fakeServerTimeout)); err != nil {
+		s.t.Fatalf("Failed to send close frame: %v", err)
+	}
+}
+
+// drop closes the underlying connection without a close frame.
+func (s *fakeSession) drop() {
+	s.conn.UnderlyingConn().Close()
+}
+
+// expectNoClientGoroutines waits for every goroutine started by the client to return.
+func expectNoClientGoroutines(t *testing.T) {
+	t.Helper()
+
+	deadline := time.Now().Add(fakeServerTimeout)
+	for {
+		leaked := clientGoroutines()
+		if len(leaked) == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected no client goroutines after Run returned, got %d:\n%s", len(leaked), strings.Join(leaked, "\n\n"))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// clientGoroutines returns the stacks of the running goroutines started by a WebSocketClient.
+func clientGoroutines() []string {
+	buf := make([]byte, 1<<20)
+	buf = buf[:runtime.Stack(buf, true)]
+
+	var stacks []string
+	for _, stack := range strings.Split(string(buf), "\n\n") {
+		if strings.Contains(stack, "ws_client.(*WebSocketClient)") || strings.Contains(stack, "ws_client.waitGroupDone") {
+			stacks = append(stacks, stack)
+		}
+	}
+	return stacks
+}
+
+// waitForRun waits for the client's Run to return and returns its error.
+func waitForRun(t *testing.T, result <-chan error) error {
+	t.Helper()
+
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(fakeServerTimeout):
+		t.Fatalf("Timed out waiting for client to stop")
+		return nil
+	}
+}
+
+// lobbyDataPayload returns a lobby data payload for the scenario player.
+func lobbyDataPayload(sandboxMode bool) *lobby_data.LobbyData {
+	return &lobby_data.LobbyData{
+		PlayerID: scenario.MyID,
+		Players: []lobby_data.LobbyPlayer{
+			{ID: scenario.MyID, Nickname: "GO1", Color: 16711680},
+		},
+		ServerSettings: lobby_data.ServerSettings{
+			GridDimension:     3,
+			NumberOfPlayers:   2,
+			Seed:              12345,
+			BroadcastInterval: 100,
+			SandboxMode:       sandboxMode,
+			Version:           "1.0.0",
+		},
+	}
+}
+
+// gameStatePayload returns a 3x3 game state payload with the scenario player's tank in the middle.
+func gameStatePayload(id string, tick uint64) *game_state.GameState {
+	gameState := scenario.MustParse(`
+		. . .
+		. ^ .
+		. . .
+	`).GameState
+	gameState.ID = id
+	gameState.Tick = tick
+	return gameState
+}
@@ -0,0 +1,244 @@
+// Package outbound_queue holds the packets waiting to be written to the server.
+//
+// Control packets, such as pongs and readiness notifications, are written
+// first and in order. Actions are only relevant for the game state they
+// answer, so at most one is kept: the action for the newest game state
+// supersedes one that has not been written yet. Packets that will never reach the server are reported as
+// a Failure and counted in the Metrics.
+package outbound_queue
+
+import (
+	"errors"
+	"hackarena2-0-mono-tanks-go/packet"
+	"sync"
+)
+
+// ErrClosed is reported for packets pushed after the queue was closed.
+var ErrClosed = errors.New("client is closed")
+
+// ErrQueueFull is reported for control packets pushed while the queue is full.
+var ErrQueueFull = errors.New("send queue is full")
+
+// ErrSuperseded is reported for an action replaced by a newer one before it
+// was written, and for an action pushed while one for a later tick waits.
+var ErrSuperseded = errors.New("action superseded by a newer one")
+
+// ErrDropped is reported for packets discarded when the client shuts down before writing them.
+var ErrDropped = errors.New("dropped on shutdown")
+
+// Item is an encoded packet waiting to be written.
+type Item struct {
+	// Type is the type of the packet.
+	Type packet.PacketType
+
+	// GameStateID is the game state an action answers, empty for control packets.
+	GameStateID string
+
+	// Tick is the tick of the game state an action answers.
+	Tick uint64
+
+	// Message is the encoded packet.
+	Message []byte
+}
+
+// IsAction reports whether the item is a response to a game state.
+func (item Item) IsAction() bool {
+	switch item.Type {
+	case packet.MovementPacket, packet.RotationPacket, packet.AbilityUsePacket, packet.PassPacket:
+		return true
+	}
+	return false
+}
+
+// Failure describes a packet that did not reach the server.
+type Failure struct {
+	// Type is the type of the packet.
+	Type packet.PacketType
+
+	// GameStateID is the game state an action answers, empty for control packets.
+	GameStateID string
+
+	// Err is ErrClosed, ErrQueueFull, ErrSuperseded, ErrDropped or the error
+	// returned when writing the packet.
+	Err error
+}
+
+// Metrics counts what happened to the packets pushed to the queue.
+type Metrics struct {
+	// Sent is the number of packets written to the server.
+	Sent uint64
+
+	// Superseded is the number of actions replaced by a newer one.
+	Superseded uint64
+
+	// Rejected is the number of packets pushed while the queue was full or closed.
+	Rejected uint64
+
+	// Dropped is the number of packets discarded on shutdown.
+	Dropped uint64
+
+	// WriteFailed is the number of packets that failed to be written.
+	WriteFailed uint64
+}
+
+// Queue is safe for concurrent use. It never blocks the goroutines pushing to it.
+type Queue struct {
+	mutex     sync.Mutex
+	capacity  int
+	control   []Item
+	action    *Item
+	closed    bool
+	ready     chan struct{}
+	metrics   Metrics
+	onFailure func(Failure)
+}
+
+// New creates a queue holding up to capacity control packets. onFailure is
+// called for every packet that will not reach the server, it may be nil.
+func New(capacity int, onFailure func(Failure)) *Queue {
+	return &Queue{
+		capacity:  capacity,
+		ready:     make(chan struct{}, 1),
+		onFailure: onFailure,
+	}
+}
+
+// Push adds an item to the queue. It returns ErrClosed or ErrQueueFull if the
+// item is rejected. An action waiting to be written is superseded by one for
+// the same or a later tick, and supersedes one for an earlier tick, so the
+// action for the newest game state is kept whatever order they are pushed in.
+// A superseded action is reported to onFailure, but does not make Push fail.
+func (q *Queue) Push(item Item) error {
+	q.mutex.Lock()
+
+	var failures []Failure
+	var err error
+	switch {
+	case q.closed:
+		err = ErrClosed
+	case item.IsAction() && q.action != nil && item.Tick < q.action.Tick:
+		q.metrics.Superseded++
+		failures = append(failures, failureOf(item, ErrSuperseded))
+	case item.IsAction():
+		if q.action != nil {
+			q.metrics.Superseded++
+			failures = append(failures, failureOf(*q.action, ErrSuperseded))
+		}
+		q.action = &item
+	case len(q.control) >= q.capacity:
+		err = ErrQueueFull
+	default:
+		q.control = append(q.control, item)
+	}
+
+	if err != nil {
+		q.metrics.Rejected++
+		failures = append(failures, failureOf(item, err))
+	} else {
+		q.signal()
+	}
+	q.mutex.Unlock()
+
+	q.report(failures)
+	return err
+}
+
+// Next blocks until an item is available and removes it from the queue,
+// control packets first. It returns false once the queue is closed and empty.
+func (q *Queue) Next() (Item, bool) {
+	for {
+		q.mutex.Lock()
+		if len(q.control) > 0 {
+			item := q.control[0]
+			q.control = q.control[1:]
+			q.mutex.Unlock()
+			return item, true
+		}
+		if q.action != nil {
+			item := *q.action
+			q.action = nil
+			q.mutex.Unlock()
+			return item, true
+		}
+		closed := q.closed
+		q.mutex.Unlock()
+
+		if closed {
+			return Item{}, false
+		}
+		<-q.ready
+	}
+}
+
+// Sent records that an item returned by Next was written.
+func (q *Queue) Sent() {
+	q.mutex.Lock()
+	q.metrics.Sent++
+	q.mutex.Unlock()
+}
+
+// WriteFailed records that an item returned by Next could not be written.
+func (q *Queue) WriteFailed(item Item, err error) {
+	q.mutex.Lock()
+	q.metrics.WriteFailed++
+	q.mutex.Unlock()
+
+	q.report([]Failure{failureOf(item, err)})
+}
+
+// Close rejects any further pushes. Items already queued are still returned by Next.
+func (q *Queue) Close() {
+	q.mutex.Lock()
+	q.closed = true
+	q.signal()
+	q.mutex.Unlock()
+}
+
+// Drop discards the queued items and reports them with ErrDropped.
+func (q *Queue) Drop() {
+	q.mutex.Lock()
+	dropped := q.control
+	if q.action != nil {
+		dropped = append(dropped, *q.action)
+	}
+	q.control = nil
+	q.action = nil
+	q.metrics.Dropped += uint64(len(dropped))
+	q.signal()
+	q.mutex.Unlock()
+
+	failures := make([]Failure, 0, len(dropped))
+	for _, item := range dropped {
+		failures = append(failures, failureOf(item, ErrDropped))
+	}
+	q.report(failures)
+}
+
+// Metrics returns a snapshot of the counters.
+func (q *Queue) Metrics() Metrics {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.metrics
+}
+
+// signal wakes up Next. It must be called with the mutex held.
+func (q *Queue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// report calls onFailure outside of the mutex, so it may push to the queue.
+func (q *Queue) report(failures []Failure) {
+	if q.onFailure == nil {
+		return
+	}
+	for _, failure := range failures {
+		q.onFailure(failure)
+	}
+}
+
+func failureOf(item Item, err error) Failure {
+	return Failure{Type: item.Type, GameStateID: item.GameStateID, Err: err}
+}
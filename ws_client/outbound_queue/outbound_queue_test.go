@@ -0,0 +1,215 @@
+package outbound_queue
+
+import (
+	"hackarena2-0-mono-tanks-go/packet"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func control(packetType packet.PacketType) Item {
+	return Item{Type: packetType, Message: []byte(packetType)}
+}
+
+func action(gameStateID string) Item {
+	return Item{Type: packet.MovementPacket, GameStateID: gameStateID, Message: []byte(gameStateID)}
+}
+
+// recorder collects the failures reported by a queue.
+type recorder struct {
+	failures []Failure
+}
+
+func (r *recorder) onFailure(failure Failure) {
+	r.failures = append(r.failures, failure)
+}
+
+// drain returns the items of a closed queue in the order Next returns them.
+func drain(t *testing.T, q *Queue) []Item {
+	t.Helper()
+
+	var items []Item
+	for {
+		item, ok := q.Next()
+		if !ok {
+			return items
+		}
+		items = append(items, item)
+	}
+}
+
+func TestControlPacketsHavePriority(t *testing.T) {
+	q := New(10, nil)
+	q.Push(action("s1"))
+	q.Push(control(packet.Pong))
+	q.Push(control(packet.ReadyToReceiveGameState))
+	q.Close()
+
+	expected := []Item{control(packet.Pong), control(packet.ReadyToReceiveGameState), action("s1")}
+	if items := drain(t, q); !reflect.DeepEqual(items, expected) {
+		t.Errorf("Expected items %v, got %v", expected, items)
+	}
+}
+
+func TestNewerActionSupersedesOlder(t *testing.T) {
+	r := &recorder{}
+	q := New(10, r.onFailure)
+
+	for _, id := range []string{"s1", "s2", "s3"} {
+		if err := q.Push(action(id)); err != nil {
+			t.Fatalf("Push() error = %v", err)
+		}
+	}
+	q.Close()
+
+	if items := drain(t, q); !reflect.DeepEqual(items, []Item{action("s3")}) {
+		t.Errorf("Expected only the newest action, got %v", items)
+	}
+
+	expected := []Failure{
+		{Type: packet.MovementPacket, GameStateID: "s1", Err: ErrSuperseded},
+		{Type: packet.MovementPacket, GameStateID: "s2", Err: ErrSuperseded},
+	}
+	if !reflect.DeepEqual(r.failures, expected) {
+		t.Errorf("Expected failures %v, got %v", expected, r.failures)
+	}
+	if metrics := q.Metrics(); metrics.Superseded != 2 {
+		t.Errorf("Expected 2 superseded actions, got %+v", metrics)
+	}
+}
+
+func TestActionForEarlierTickIsSuperseded(t *testing.T) {
+	r := &recorder{}
+	q := New(10, r.onFailure)
+
+	newer := action("s2")
+	newer.Tick = 2
+	older := action("s1")
+	older.Tick = 1
+	q.Push(newer)
+	if err := q.Push(older); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	q.Close()
+
+	if items := drain(t, q); !reflect.DeepEqual(items, []Item{newer}) {
+		t.Errorf("Expected the action for the later tick, got %v", items)
+	}
+	expected := []Failure{{Type: packet.MovementPacket, GameStateID: "s1", Err: ErrSuperseded}}
+	if !reflect.DeepEqual(r.failures, expected) {
+		t.Errorf("Expected failures %v, got %v", expected, r.failures)
+	}
+}
+
+func TestFullQueueRejectsControlPackets(t *testing.T) {
+	r := &recorder{}
+	q := New(2, r.onFailure)
+
+	q.Push(control(packet.Pong))
+	q.Push(control(packet.Pong))
+	if err := q.Push(control(packet.GameStatusRequest)); err != ErrQueueFull {
+		t.Fatalf("Expected ErrQueueFull, got %v", err)
+	}
+
+	// Actions do not take up control slots
+	if err := q.Push(action("s1")); err != nil {
+		t.Fatalf("Expected action to be queued, got %v", err)
+	}
+
+	expected := []Failure{{Type: packet.GameStatusRequest, Err: ErrQueueFull}}
+	if !reflect.DeepEqual(r.failures, expected) {
+		t.Errorf("Expected failures %v, got %v", expected, r.failures)
+	}
+	if metrics := q.Metrics(); metrics.Rejected != 1 {
+		t.Errorf("Expected 1 rejected packet, got %+v", metrics)
+	}
+}
+
+func TestClosedQueue(t *testing.T) {
+	r := &recorder{}
+	q := New(10, r.onFailure)
+	q.Push(control(packet.Pong))
+	q.Close()
+
+	if err := q.Push(action("s1")); err != ErrClosed {
+		t.Fatalf("Expected ErrClosed, got %v", err)
+	}
+	if items := drain(t, q); !reflect.DeepEqual(items, []Item{control(packet.Pong)}) {
+		t.Errorf("Expected items queued before Close, got %v", items)
+	}
+
+	expected := []Failure{{Type: packet.MovementPacket, GameStateID: "s1", Err: ErrClosed}}
+	if !reflect.DeepEqual(r.failures, expected) {
+		t.Errorf("Expected failures %v, got %v", expected, r.failures)
+	}
+}
+
+func TestDrop(t *testing.T) {
+	r := &recorder{}
+	q := New(10, r.onFailure)
+	q.Push(control(packet.Pong))
+	q.Push(action("s1"))
+	q.Close()
+	q.Drop()
+
+	if items := drain(t, q); len(items) != 0 {
+		t.Errorf("Expected no items after Drop, got %v", items)
+	}
+
+	expected := []Failure{
+		{Type: packet.Pong, Err: ErrDropped},
+		{Type: packet.MovementPacket, GameStateID: "s1", Err: ErrDropped},
+	}
+	if !reflect.DeepEqual(r.failures, expected) {
+		t.Errorf("Expected failures %v, got %v", expected, r.failures)
+	}
+	if metrics := q.Metrics(); metrics.Dropped != 2 {
+		t.Errorf("Expected 2 dropped packets, got %+v", metrics)
+	}
+}
+
+func TestNextWaitsForPush(t *testing.T) {
+	q := New(10, nil)
+
+	result := make(chan Item)
+	go func() {
+		item, _ := q.Next()
+		result <- item
+	}()
+
+	select {
+	case item := <-result:
+		t.Fatalf("Expected Next to block on an empty queue, got %v", item)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	q.Push(control(packet.Pong))
+	select {
+	case item := <-result:
+		if !reflect.DeepEqual(item, control(packet.Pong)) {
+			t.Errorf("Expected pong, got %v", item)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for Next")
+	}
+}
+
+func TestNextReturnsAfterClose(t *testing.T) {
+	q := New(10, nil)
+
+	result := make(chan bool)
+	go func() {
+		_, ok := q.Next()
+		result <- ok
+	}()
+
+	q.Close()
+	select {
+	case ok := <-result:
+		if ok {
+			t.Errorf("Expected Next to report a closed queue")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for Next")
+	}
+}
@@ -0,0 +1,102 @@
+package ws_client
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrConnectionRejected matches every RejectionError.
+	ErrConnectionRejected = errors.New("connection rejected")
+
+	// ErrNicknameTaken matches a rejection because another player uses the same nickname.
+	ErrNicknameTaken = errors.New("nickname already taken")
+
+	// ErrInvalidJoinCode matches a rejection because the join code is missing or wrong.
+	ErrInvalidJoinCode = errors.New("invalid join code")
+
+	// ErrGameInProgress matches a rejection because the game has already started.
+	ErrGameInProgress = errors.New("game already in progress")
+
+	// ErrLobbyFull matches a rejection because the lobby has no free slots.
+	ErrLobbyFull = errors.New("lobby is full")
+)
+
+// RejectionError is returned when the server refuses the connection. Use
+// errors.Is with the Err values of this package to tell the reasons apart.
+type RejectionError struct {
+	// Reason is the reason given by the server.
+	Reason string
+
+	// Kind is the Err value matching the reason, ErrConnectionRejected if it is not recognized.
+	Kind error
+}
+
+func (e *RejectionError) Error() string {
+	return fmt.Sprintf("connection rejected by the server: %s", e.Reason)
+}
+
+func (e *RejectionError) Is(target error) bool {
+	return target == ErrConnectionRejected || target == e.Kind
+}
+
+// Hint suggests how to fix the cause of the rejection.
+func (e *RejectionError) Hint() string {
+	switch e.Kind {
+	case ErrNicknameTaken:
+		return "Choose a different --nickname, every bot in the lobby needs a unique one"
+	case ErrInvalidJoinCode:
+		return "Check the --code, it must match the join code the server was started with"
+	case ErrGameInProgress:
+		return "Wait for the current game to end, bots can only join before it starts"
+	case ErrLobbyFull:
+		return "Wait for a free slot, or ask the organizers to raise the number of players"
+	default:
+		return "Check the reason above and the server logs"
+	}
+}
+
+// rejectionRules recognize the reasons sent by the server. Reasons are
+// compared in lowercase with spaces removed, so both "Nickname already
+// exists" and "NicknameAlreadyExists" match.
+var rejectionRules = []struct {
+	all  []string
+	any  []string
+	kind error
+}{
+	{all: []string{"nickname"}, any: []string{"exist", "taken", "inuse", "duplicate"}, kind: ErrNicknameTaken},
+	{any: []string{"joincode", "invalidcode", "wrongcode"}, kind: ErrInvalidJoinCode},
+	{any: []string{"inprogress", "alreadystarted", "hasstarted"}, kind: ErrGameInProgress},
+	{any: []string{"full", "toomanyplayers", "maximumnumberofplayers"}, kind: ErrLobbyFull},
+}
+
+// newRejectionError classifies the reason of a rejection.
+func newRejectionError(reason string) *RejectionError {
+	normalized := strings.ToLower(strings.Join(strings.Fields(reason), ""))
+
+	for _, rule := range rejectionRules {
+		if containsAll(normalized, rule.all) && containsAny(normalized, rule.any) {
+			return &RejectionError{Reason: reason, Kind: rule.kind}
+		}
+	}
+	return &RejectionError{Reason: reason, Kind: ErrConnectionRejected}
+}
+
+func containsAll(s string, substrings []string) bool {
+	for _, substring := range substrings {
+		if !strings.Contains(s, substring) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsAny(s string, substrings []string) bool {
+	for _, substring := range substrings {
+		if strings.Contains(s, substring) {
+			return true
+		}
+	}
+	return false
+}
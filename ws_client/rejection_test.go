@@ -0,0 +1,39 @@
+package ws_client
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewRejectionError(t *testing.T) {
+	tests := []struct {
+		reason   string
+		expected error
+	}{
+		{reason: "Nickname already exists", expected: ErrNicknameTaken},
+		{reason: "NicknameAlreadyExists", expected: ErrNicknameTaken},
+		{reason: "A player with this nickname is already in use", expected: ErrNicknameTaken},
+		{reason: "Invalid join code", expected: ErrInvalidJoinCode},
+		{reason: "InvalidJoinCode", expected: ErrInvalidJoinCode},
+		{reason: "Game in progress", expected: ErrGameInProgress},
+		{reason: "The game has already started", expected: ErrGameInProgress},
+		{reason: "Game is full", expected: ErrLobbyFull},
+		{reason: "Nickname is too long", expected: ErrConnectionRejected},
+		{reason: "", expected: ErrConnectionRejected},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.reason, func(t *testing.T) {
+			err := newRejectionError(tt.reason)
+			if err.Kind != tt.expected {
+				t.Errorf("Expected %q to be classified as %v, got %v", tt.reason, tt.expected, err.Kind)
+			}
+			if !errors.Is(err, ErrConnectionRejected) {
+				t.Errorf("Expected every rejection to match ErrConnectionRejected")
+			}
+			if err.Hint() == "" {
+				t.Errorf("Expected a hint for %q", tt.reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package ws_client
+
+import (
+	"fmt"
+	"hackarena2-0-mono-tanks-go/packet"
+	"hackarena2-0-mono-tanks-go/packet/packets/game_end"
+	"hackarena2-0-mono-tanks-go/packet/packets/game_state"
+	"hackarena2-0-mono-tanks-go/packet/packets/lobby_data"
+	"hackarena2-0-mono-tanks-go/spectator"
+)
+
+// processSpectatorPacket handles the packets a spectator treats differently
+// from a bot. It returns false for the packets handled the same way.
+func (client *WebSocketClient) processSpectatorPacket(p packet.Packet) bool {
+	switch p.Type {
+
+	case packet.LobbyDataPacket:
+		fmt.Println("[System] 🎳 Lobby data received")
+		lobbyData := p.Payload.(*lobby_data.LobbyData)
+
+		client.botMutex.Lock()
+		if client.spectator != nil {
+			client.spectator.OnLobbyDataChanged(lobbyData)
+		} else {
+			client.spectator = spectator.OnJoiningLobby(lobbyData, client.options.Recording)
+			client.botReadyOnce.Do(func() { close(client.botReady) })
+		}
+		client.botMutex.Unlock()
+
+	case packet.GameStarting:
+		// Spectators receive game states without announcing they are ready
+		fmt.Println("[System] 🎲 Game starting")
+
+	case packet.GameStatePacket:
+		gameState := p.Payload.(*game_state.GameState)
+
+		if !client.waitForBot() {
+			return true
+		}
+
+		client.botMutex.Lock()
+		client.spectator.OnGameState(gameState)
+		client.botMutex.Unlock()
+
+	case packet.GameEndedPacket:
+		fmt.Println("[System] 🏁 Game ended")
+		gameEnd := p.Payload.(*game_end.GameEnd)
+
+		if !client.waitForBot() {
+			return true
+		}
+
+		client.botMutex.Lock()
+		client.spectator.OnGameEnded(gameEnd)
+		client.botMutex.Unlock()
+
+	default:
+		return false
+	}
+
+	return true
+}
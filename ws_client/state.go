@@ -0,0 +1,100 @@
+package ws_client
+
+import (
+	"fmt"
+	"hackarena2-0-mono-tanks-go/packet"
+)
+
+// State is the lifecycle state of the WebSocketClient.
+type State int
+
+const (
+	// Connecting is the state until the server accepts or rejects the connection.
+	Connecting State = iota
+	// Accepted is the state after the connection is accepted, while waiting for lobby data.
+	Accepted
+	// InLobby is the state after the first lobby data is received.
+	InLobby
+	// Starting is the state after the server announces the game is starting.
+	Starting
+	// InGame is the state while game states are being received.
+	InGame
+	// Ended is the state after the game has ended.
+	Ended
+	// Closed is the state after the connection is rejected or closed.
+	Closed
+)
+
+var stateNames = map[State]string{
+	Connecting: "Connecting",
+	Accepted:   "Accepted",
+	InLobby:    "InLobby",
+	Starting:   "Starting",
+	InGame:     "InGame",
+	Ended:      "Ended",
+	Closed:     "Closed",
+}
+
+func (s State) String() string {
+	if name, ok := stateNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// transitions maps each packet type to the states it is accepted in and the
+// state the client moves to after receiving it.
+var transitions = map[packet.PacketType]map[State]State{
+	packet.ConnectionAccepted: {Connecting: Accepted},
+	packet.ConnectionRejected: {Connecting: Closed},
+
+	// Lobby data is sent again whenever the lobby changes
+	packet.LobbyDataPacket: {
+		Accepted: InLobby,
+		InLobby:  InLobby,
+		Starting: Starting,
+		InGame:   InGame,
+		Ended:    Ended,
+	},
+
+	packet.GameNotStarted: {InLobby: InLobby},
+	// A new game may start after the last one has ended, for example when a
+	// sandbox game is restarted
+	packet.GameStarting: {InLobby: Starting, Ended: Starting},
+	// In sandbox mode the game may already be running when the lobby is joined,
+	// and the game started packet may follow the first game state
+	packet.GameStarted:    {InLobby: InGame, Starting: InGame, InGame: InGame, Ended: InGame},
+	packet.GameInProgress: {InLobby: InGame, Ended: InGame},
+
+	// The first game state may arrive before the game started packet, and in
+	// sandbox mode right after the bot is ready to receive it in the lobby
+	packet.GameStatePacket: {InLobby: InGame, Starting: InGame, InGame: InGame},
+	packet.GameEndedPacket: {InGame: Ended},
+}
+
+// statelessPackets are accepted in every state but Closed and do not change it.
+var statelessPackets = map[packet.PacketType]bool{
+	packet.Ping: true,
+	packet.Pong: true,
+
+	packet.CustomWarning:                  true,
+	packet.PlayerAlreadyMadeActionWarning: true,
+	packet.ActionIgnoredDueToDeadWarning:  true,
+	packet.SlowResponseWarning:            true,
+
+	packet.InvalidPacketTypeError:  true,
+	packet.InvalidPacketUsageError: true,
+}
+
+// nextState returns the state after receiving a packet of the given type in
+// the current state, and false if the packet is not expected in that state.
+func nextState(current State, packetType packet.PacketType) (State, bool) {
+	if current == Closed {
+		return Closed, false
+	}
+	if statelessPackets[packetType] {
+		return current, true
+	}
+	next, ok := transitions[packetType][current]
+	return next, ok
+}
@@ -0,0 +1,61 @@
+package ws_client
+
+import (
+	"hackarena2-0-mono-tanks-go/packet"
+	"testing"
+)
+
+func TestNextState(t *testing.T) {
+	tests := []struct {
+		name       string
+		current    State
+		packetType packet.PacketType
+		expected   State
+		ok         bool
+	}{
+		{name: "Connection Accepted", current: Connecting, packetType: packet.ConnectionAccepted, expected: Accepted, ok: true},
+		{name: "Connection Rejected", current: Connecting, packetType: packet.ConnectionRejected, expected: Closed, ok: true},
+		{name: "First Lobby Data", current: Accepted, packetType: packet.LobbyDataPacket, expected: InLobby, ok: true},
+		{name: "Lobby Data During Game", current: InGame, packetType: packet.LobbyDataPacket, expected: InGame, ok: true},
+		{name: "Game Starting", current: InLobby, packetType: packet.GameStarting, expected: Starting, ok: true},
+		{name: "Game Started", current: Starting, packetType: packet.GameStarted, expected: InGame, ok: true},
+		{name: "Game Started In Sandbox Mode", current: InLobby, packetType: packet.GameStarted, expected: InGame, ok: true},
+		{name: "Game In Progress", current: InLobby, packetType: packet.GameInProgress, expected: InGame, ok: true},
+		{name: "Game State", current: InGame, packetType: packet.GameStatePacket, expected: InGame, ok: true},
+		{name: "Game Ended", current: InGame, packetType: packet.GameEndedPacket, expected: Ended, ok: true},
+		{name: "Game State Before Game Started", current: Starting, packetType: packet.GameStatePacket, expected: InGame, ok: true},
+		{name: "Game State In Sandbox Mode", current: InLobby, packetType: packet.GameStatePacket, expected: InGame, ok: true},
+		{name: "Game Started After First Game State", current: InGame, packetType: packet.GameStarted, expected: InGame, ok: true},
+		{name: "Game Starting After Game Ended", current: Ended, packetType: packet.GameStarting, expected: Starting, ok: true},
+		{name: "Game Started After Game Ended", current: Ended, packetType: packet.GameStarted, expected: InGame, ok: true},
+		{name: "Game In Progress After Game Ended", current: Ended, packetType: packet.GameInProgress, expected: InGame, ok: true},
+		{name: "Ping Before Acceptance", current: Connecting, packetType: packet.Ping, expected: Connecting, ok: true},
+		{name: "Warning During Game", current: InGame, packetType: packet.SlowResponseWarning, expected: InGame, ok: true},
+		{name: "Game State Before Lobby Data", current: Accepted, packetType: packet.GameStatePacket},
+		{name: "Game Starting Before Lobby Data", current: Accepted, packetType: packet.GameStarting},
+		{name: "Connection Accepted Twice", current: Accepted, packetType: packet.ConnectionAccepted},
+		{name: "Game State After Game Ended", current: Ended, packetType: packet.GameStatePacket},
+		{name: "Ping After Close", current: Closed, packetType: packet.Ping, expected: Closed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, ok := nextState(tt.current, tt.packetType)
+			if ok != tt.ok {
+				t.Fatalf("Expected %s in %s state to be accepted = %v, got %v", tt.packetType, tt.current, tt.ok, ok)
+			}
+			if ok && next != tt.expected {
+				t.Errorf("Expected %s in %s state to move to %s, got %s", tt.packetType, tt.current, tt.expected, next)
+			}
+		})
+	}
+}
+
+func TestStateString(t *testing.T) {
+	if got := InLobby.String(); got != "InLobby" {
+		t.Errorf("Expected InLobby, got %s", got)
+	}
+	if got := State(42).String(); got != "State(42)" {
+		t.Errorf("Expected State(42), got %s", got)
+	}
+}
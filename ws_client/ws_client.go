@@ -2,38 +2,254 @@ package ws_client
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"hackarena2-0-mono-tanks-go/action_validator"
 	"hackarena2-0-mono-tanks-go/bot"
 	"hackarena2-0-mono-tanks-go/handlers"
 	"hackarena2-0-mono-tanks-go/packet"
+	"hackarena2-0-mono-tanks-go/packet/codec"
+	"hackarena2-0-mono-tanks-go/packet/packets/bot_response"
+	"hackarena2-0-mono-tanks-go/packet/packets/connection_rejected"
+	"hackarena2-0-mono-tanks-go/packet/packets/custom_warning"
 	"hackarena2-0-mono-tanks-go/packet/packets/game_end"
 	"hackarena2-0-mono-tanks-go/packet/packets/game_state"
 	"hackarena2-0-mono-tanks-go/packet/packets/lobby_data"
+	"io"
 	"log"
+	"net"
 	"net/url"
+	"strings"
 	"sync"
 	"time"
 
 	"hackarena2-0-mono-tanks-go/packet/warning"
+	"hackarena2-0-mono-tanks-go/spectator"
+	"hackarena2-0-mono-tanks-go/timing"
+	"hackarena2-0-mono-tanks-go/ws_client/outbound_queue"
 
 	"github.com/gorilla/websocket"
 )
 
+// Options configures the optional behaviour of the WebSocketClient.
+type Options struct {
+	// GameEvents enables delivering the events extracted from consecutive
+	// game states to the bot before each NextMove.
+	GameEvents bool
+
+	// OnStateChange is called from the reader goroutine whenever the client
+	// moves to a new lifecycle state.
+	OnStateChange func(from State, to State)
+
+	// ActionValidation selects what happens to actions that are invalid in
+	// the game state they answer. The zero value disables the validation.
+	ActionValidation action_validator.Mode
+
+	// Bot holds the options applied to the bot once it is created.
+	Bot bot.Options
+
+	// Spectate joins the game as a spectator, which receives the game states
+	// of all players and never responds to them, instead of as a bot.
+	Spectate bool
+
+	// Recording receives the packets a spectator receives, one per line,
+	// nil for none. See spectator.Recorder.
+	Recording io.Writer
+}
+
+// ErrClientClosed is returned by Send once the client has started shutting down.
+var ErrClientClosed = outbound_queue.ErrClosed
+
+// ErrSendQueueFull is returned by Send when the writer cannot keep up with control packets.
+var ErrSendQueueFull = outbound_queue.ErrQueueFull
+
+const (
+	// defaultDrainTimeout bounds how long packets queued before shutdown are
+	// still written, the rest are dropped.
+	defaultDrainTimeout = time.Second
+
+	// sendQueueCapacity is the number of control packets that can wait for the writer.
+	sendQueueCapacity = 100
+
+	// defaultCloseTimeout bounds how long the server has to answer the close
+	// frame before the connection is closed without it.
+	defaultCloseTimeout = time.Second
+)
+
 type WebSocketClient struct {
-	readTask    *sync.WaitGroup
-	writeTask   *sync.WaitGroup
-	conn        *websocket.Conn
-	tx          chan []byte
-	botMutex    sync.Mutex
-	botInstance *bot.Bot
+	options        Options
+	readTask       *sync.WaitGroup
+	writeTask      *sync.WaitGroup
+	handlerTasks   *sync.WaitGroup
+	conn           *websocket.Conn
+	queue          *outbound_queue.Queue
+	stopping       chan struct{}
+	drainTimeout   time.Duration
+	closeTimeout   time.Duration
+	botMutex       sync.Mutex
+	botInstance    *bot.Bot
+	lastGameState  *game_state.GameState
+	stateMutex     sync.Mutex
+	state          State
+	botReady       chan struct{}
+	botReadyOnce   sync.Once
+	spectator      *spectator.Spectator
+	rejected       chan struct{}
+	rejection      *RejectionError
+	lastSentMutex  sync.Mutex
+	lastSent       *outbound_queue.Item
+	tracker        *timing.Tracker
+	failureMutex   sync.Mutex
+	actionFailures []outbound_queue.Failure
+}
+
+func NewWebSocketClient(options Options) *WebSocketClient {
+	client := &WebSocketClient{
+		options:      options,
+		readTask:     &sync.WaitGroup{},
+		writeTask:    &sync.WaitGroup{},
+		handlerTasks: &sync.WaitGroup{},
+		stopping:     make(chan struct{}),
+		drainTimeout: defaultDrainTimeout,
+		closeTimeout: defaultCloseTimeout,
+		state:        Connecting,
+		botReady:     make(chan struct{}),
+		rejected:     make(chan struct{}),
+		tracker:      timing.NewTracker(),
+	}
+	client.queue = outbound_queue.New(sendQueueCapacity, client.onSendFailure)
+	return client
+}
+
+// State returns the current lifecycle state of the client.
+func (client *WebSocketClient) State() State {
+	client.stateMutex.Lock()
+	defer client.stateMutex.Unlock()
+	return client.state
+}
+
+// setState moves the client to the given state and notifies OnStateChange.
+func (client *WebSocketClient) setState(to State) {
+	client.stateMutex.Lock()
+	from := client.state
+	client.state = to
+	client.stateMutex.Unlock()
+
+	if from != to && client.options.OnStateChange != nil {
+		client.options.OnStateChange(from, to)
+	}
+}
+
+// advance validates a received packet against the current state and moves
+// the client to the next one. It returns false if the packet is not expected.
+func (client *WebSocketClient) advance(packetType packet.PacketType) bool {
+	current := client.State()
+	next, ok := nextState(current, packetType)
+	if !ok {
+		log.Printf("[System] 🚨 Unexpected %s packet in %s state, ignoring", packetType, current)
+		return false
+	}
+	client.setState(next)
+	return true
+}
+
+// waitForBot blocks until the bot, or the spectator, is created, and returns
+// false if the client starts shutting down first.
+func (client *WebSocketClient) waitForBot() bool {
+	select {
+	case <-client.botReady:
+		return true
+	case <-client.stopping:
+		return false
+	}
+}
+
+// Send encodes the packet and queues it for the writer. It never blocks, and
+// returns ErrClientClosed once the client has started shutting down.
+//
+// Only the action for the newest game state is kept until the writer picks
+// it up, others are dropped and reported to the bot through OnActionFailed.
+func (client *WebSocketClient) Send(p packet.Packet) error {
+	message, err := codec.Encode(p)
+	if err != nil {
+		return err
+	}
+
+	item := outbound_queue.Item{Type: p.Type, Message: message}
+	switch action := p.Payload.(type) {
+	case *bot_response.Action:
+		item.GameStateID = action.GameStateID
+	case bot_response.Action:
+		item.GameStateID = action.GameStateID
+	}
+	if timing, ok := client.tracker.Timing(item.GameStateID); ok && item.IsAction() {
+		item.Tick = timing.Tick
+	}
+	return client.queue.Push(item)
+}
+
+// ResponseStats returns the time between receiving game states and sending the actions for them.
+func (client *WebSocketClient) ResponseStats() timing.ResponseStats {
+	return client.tracker.Stats()
 }
 
-func NewWebSocketClient() *WebSocketClient {
-	return &WebSocketClient{
-		readTask:  &sync.WaitGroup{},
-		writeTask: &sync.WaitGroup{},
-		tx:        make(chan []byte, 100),
+// SendMetrics returns the counters of the packets sent to the server.
+func (client *WebSocketClient) SendMetrics() outbound_queue.Metrics {
+	return client.queue.Metrics()
+}
+
+// onSendFailure logs a packet that did not reach the server, and keeps failed
+// actions to be reported to the bot before its next move.
+func (client *WebSocketClient) onSendFailure(failure outbound_queue.Failure) {
+	if failure.GameStateID != "" {
+		log.Printf("[System] 🚨 %s action for game state %s not sent -> %v", failure.Type, failure.GameStateID, failure.Err)
+
+		client.failureMutex.Lock()
+		client.actionFailures = append(client.actionFailures, failure)
+		client.failureMutex.Unlock()
+		return
+	}
+	log.Printf("[System] 🚨 %s packet not sent -> %v", failure.Type, failure.Err)
+}
+
+// describeLastSent describes the last packet written to the server. The
+// server answers packets in order, so this is the packet an error refers to,
+// unless more packets were sent before the error arrived.
+func (client *WebSocketClient) describeLastSent() string {
+	client.lastSentMutex.Lock()
+	defer client.lastSentMutex.Unlock()
+
+	if client.lastSent == nil {
+		return "none"
+	}
+	return string(client.lastSent.Message)
+}
+
+// forgetGameState forgets the last game state handled, so the first game
+// state of the next game is not compared with the ones of the last game.
+func (client *WebSocketClient) forgetGameState() {
+	client.botMutex.Lock()
+	client.lastGameState = nil
+	client.botMutex.Unlock()
+}
+
+// takeActionFailures returns the action failures not yet reported to the bot.
+func (client *WebSocketClient) takeActionFailures() []outbound_queue.Failure {
+	client.failureMutex.Lock()
+	defer client.failureMutex.Unlock()
+
+	failures := client.actionFailures
+	client.actionFailures = nil
+	return failures
+}
+
+// isStopping reports whether the client has started shutting down.
+func (client *WebSocketClient) isStopping() bool {
+	select {
+	case <-client.stopping:
+		return true
+	default:
+		return false
 	}
 }
 
@@ -41,8 +257,22 @@ func (client *WebSocketClient) Connect(host string, port int, code string, nickn
 	url := client.constructURL(host, port, code, nickname)
 
 	fmt.Printf("[System] 📞 Connecting to the server: %s\n", url)
-	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	conn, response, err := websocket.DefaultDialer.Dial(url, nil)
 	if err != nil {
+		// The server refuses some connections before upgrading them, with the reason in the body
+		if errors.Is(err, websocket.ErrBadHandshake) && response != nil && response.StatusCode >= 400 && response.StatusCode < 500 {
+			body, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+			response.Body.Close()
+
+			reason := strings.TrimSpace(string(body))
+			if reason == "" {
+				reason = response.Status
+			}
+			rejection := newRejectionError(reason)
+			fmt.Printf("[System] 🚨 Connection rejected -> %s\n", rejection.Reason)
+			fmt.Printf("[System] 💡 %s\n", rejection.Hint())
+			return rejection
+		}
 		return fmt.Errorf("[System] 🌋 WebSocket connection error -> %v", err)
 	}
 	fmt.Println("[System] 🌟 Successfully connected to the server")
@@ -58,27 +288,115 @@ func (client *WebSocketClient) Connect(host string, port int, code string, nickn
 	return nil
 }
 
+// Run blocks until the context is cancelled, or the server rejects or
+// closes the connection, and then shuts the client down:
+//
+//  1. New packets are no longer handled and Send starts failing.
+//  2. Packets already queued are written for up to the drain timeout, after
+//     which the rest are dropped and the connection is closed.
+//  3. If the client is the one closing, a close frame is sent and the server
+//     has up to the close timeout to answer it.
+//  4. The connection is closed and Run waits for all of its goroutines,
+//     including the handlers still running the bot, to return.
+//
+// If the server rejected the connection, Run returns a *RejectionError.
 func (client *WebSocketClient) Run(ctx context.Context) error {
-	defer func() {
-		if err := client.conn.Close(); err != nil {
-			log.Printf("[System] 🚨 Error closing WebSocket connection: %v", err)
-		}
-		fmt.Println("[System] 👋 Connection closed")
-	}()
-
-	done := make(chan struct{})
-	go func() {
-		defer close(done)
-		client.readTask.Wait()
-	}()
+	readDone := waitGroupDone(client.readTask)
 
+	var err error
 	select {
 	case <-ctx.Done():
 		log.Println("[System] 🛑 Context cancelled, closing connection...")
-		return client.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
-	case <-done:
-		return nil
+		err = client.closeConnection(readDone)
+	case <-client.rejected:
+		// The server does not always close a rejected connection right away
+		client.closeConnection(readDone)
+	case <-readDone:
+		client.stopSending()
+		client.drainWriter()
+	}
+
+	if closeErr := client.conn.Close(); closeErr != nil && !errors.Is(closeErr, net.ErrClosed) {
+		log.Printf("[System] 🚨 Error closing WebSocket connection: %v", closeErr)
 	}
+	<-readDone
+	client.handlerTasks.Wait()
+	fmt.Println("[System] 👋 Connection closed")
+
+	// The server may close a rejected connection before the rejection is handled
+	select {
+	case <-client.rejected:
+		err = client.rejection
+	default:
+	}
+
+	metrics := client.SendMetrics()
+	fmt.Printf("[System] 📊 Packets sent: %d, superseded: %d, rejected: %d, dropped: %d, failed: %d\n",
+		metrics.Sent, metrics.Superseded, metrics.Rejected, metrics.Dropped, metrics.WriteFailed)
+	if stats := client.ResponseStats(); stats.Count > 0 {
+		fmt.Printf("[System] ⏱️ Actions sent: %d, average response time: %v, max: %v, late: %d\n",
+			stats.Count, stats.Average, stats.Max, stats.Late)
+	}
+
+	return err
+}
+
+// closeConnection stops sending, sends a close frame once the queued packets
+// are written, and waits for the server to answer it.
+func (client *WebSocketClient) closeConnection(readDone <-chan struct{}) error {
+	client.stopSending()
+	if !client.drainWriter() {
+		return fmt.Errorf("timed out flushing pending packets")
+	}
+
+	err := client.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(client.closeTimeout))
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-readDone:
+	case <-time.After(client.closeTimeout):
+		log.Println("[System] 🚨 Server did not answer the close frame")
+	}
+	return nil
+}
+
+// stopSending makes Send fail from now on and closes the send queue, so the
+// writer returns once it is drained.
+func (client *WebSocketClient) stopSending() {
+	close(client.stopping)
+	client.queue.Close()
+}
+
+// drainWriter waits for the writer to flush the send queue. If that takes
+// longer than the drain timeout the remaining packets are dropped and the
+// connection is closed to unblock a write stuck on a peer that stopped
+// reading. It returns false in that case.
+func (client *WebSocketClient) drainWriter() bool {
+	writeDone := waitGroupDone(client.writeTask)
+
+	select {
+	case <-writeDone:
+		return true
+	case <-time.After(client.drainTimeout):
+	}
+
+	log.Println("[System] 🚨 Timed out flushing pending packets, dropping the rest")
+	client.queue.Drop()
+	client.conn.Close()
+	<-writeDone
+	return false
+}
+
+// waitGroupDone returns a channel closed once the wait group is done.
+func waitGroupDone(wg *sync.WaitGroup) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		wg.Wait()
+	}()
+	return done
 }
 
 func (client *WebSocketClient) constructURL(host string, port int, code string, nickname string) string {
@@ -88,9 +406,15 @@ func (client *WebSocketClient) constructURL(host string, port int, code string,
 		Path:   "/",
 	}
 	q := u.Query()
-	q.Set("nickname", nickname)
+	if nickname != "" {
+		q.Set("nickname", nickname)
+	}
 	q.Set("enumSerializationFormat", "string")
-	q.Set("playerType", "hackathonBot")
+	if client.options.Spectate {
+		q.Set("playerType", "spectator")
+	} else {
+		q.Set("playerType", "hackathonBot")
+	}
 	if code != "" {
 		q.Set("joinCode", code)
 	}
@@ -100,17 +424,33 @@ func (client *WebSocketClient) constructURL(host string, port int, code string,
 
 func (client *WebSocketClient) createWriterTask() {
 	defer client.writeTask.Done()
-	for message := range client.tx {
-		if err := client.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+	for {
+		item, ok := client.queue.Next()
+		if !ok {
+			return
+		}
+		if err := client.conn.WriteMessage(websocket.TextMessage, item.Message); err != nil {
 			log.Printf("[System] 🌋 WebSocket send error -> %v", err)
+			client.queue.WriteFailed(item, err)
+			continue
 		}
+		client.queue.Sent()
+		if item.IsAction() {
+			client.tracker.Sent(item.GameStateID, time.Now())
+		}
+
+		client.lastSentMutex.Lock()
+		client.lastSent = &item
+		client.lastSentMutex.Unlock()
 	}
 }
 
 func (client *WebSocketClient) createReaderTask() {
 	defer client.readTask.Done()
+	defer client.setState(Closed)
 	for {
 		_, message, err := client.conn.ReadMessage()
+		receivedAt := time.Now()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				log.Printf("[System] 🌋 WebSocket unexpected close error: %v", err)
@@ -119,22 +459,55 @@ func (client *WebSocketClient) createReaderTask() {
 			}
 			return
 		}
-		go client.processMessage(message)
+		client.processMessage(message, receivedAt)
 	}
 }
 
-func (client *WebSocketClient) processMessage(message []byte) {
+// processMessage decodes a message and advances the lifecycle state. It runs
+// on the reader goroutine so that packets are validated in the order they
+// were received, and hands the packet off to be handled concurrently.
+func (client *WebSocketClient) processMessage(message []byte, receivedAt time.Time) {
 
-	var p packet.Packet
-	if err := json.Unmarshal(message, &p); err != nil {
+	p, err := codec.Decode(message)
+	if err != nil {
+		var unknownErr *codec.UnknownPacketTypeError
+		if errors.As(err, &unknownErr) {
+			log.Printf("[System] 🚨 Unknown packet type -> %s", unknownErr.Type)
+			return
+		}
 		log.Printf("[System] 🚨 Error processing text message -> %v", err)
 		log.Printf("[System] 🚨 Text Message -> %s", message)
 		return
 	}
 
+	if client.isStopping() || !client.advance(p.Type) {
+		return
+	}
+
+	// Arrival times are taken here, before handling delays them
+	switch payload := p.Payload.(type) {
+	case *lobby_data.LobbyData:
+		client.tracker.SetLobby(payload.PlayerID, time.Duration(payload.ServerSettings.BroadcastInterval)*time.Millisecond)
+	case *game_state.GameState:
+		client.tracker.Received(payload, receivedAt)
+	}
+	// Ticks start over in the next game
+	if p.Type == packet.GameStarting || p.Type == packet.GameEndedPacket {
+		client.tracker.Reset()
+	}
+
+	client.handlerTasks.Add(1)
+	go client.processPacket(p)
+}
+
+func (client *WebSocketClient) processPacket(p packet.Packet) {
+	defer client.handlerTasks.Done()
+
 	switch p.Type {
 	case packet.Ping:
-		client.tx <- []byte(`{"type":"pong"}`)
+		if err := client.Send(packet.Packet{Type: packet.Pong}); err != nil {
+			log.Printf("[System] 🚨 Error sending Pong: %v", err)
+		}
 	case packet.Pong:
 		fmt.Println("[System] 🏓 Received Pong")
 	default:
@@ -143,41 +516,34 @@ func (client *WebSocketClient) processMessage(message []byte) {
 }
 
 func (client *WebSocketClient) processTextMessage(p packet.Packet) {
+	if client.options.Spectate && client.processSpectatorPacket(p) {
+		return
+	}
+
 	switch p.Type {
 
 	case packet.ConnectionRejected:
-		fmt.Printf("[System] 🚨 Connection rejected -> %s\n", p.Payload)
+		connectionRejected := p.Payload.(*connection_rejected.ConnectionRejected)
+		client.rejection = newRejectionError(connectionRejected.Reason)
+		fmt.Printf("[System] 🚨 Connection rejected -> %s\n", client.rejection.Reason)
+		fmt.Printf("[System] 💡 %s\n", client.rejection.Hint())
+		close(client.rejected)
 	case packet.ConnectionAccepted:
 		fmt.Println("[System] 🎉 Connection accepted")
 
-		lobbyDataRequest := packet.Packet{
-			Type:    packet.LobbyDataRequest,
-			Payload: nil,
+		if err := client.Send(packet.Packet{Type: packet.LobbyDataRequest}); err != nil {
+			log.Printf("[System] 🚨 Error requesting lobby data: %v", err)
 		}
 
-		lobbyDataRequestJson, err := json.Marshal(lobbyDataRequest)
-		if err != nil {
-			log.Printf("[System] 🚨 Error marshalling LobbyDataRequest: %v", err)
-			return
-		}
-		client.tx <- lobbyDataRequestJson
-
 	case packet.LobbyDataPacket:
 		fmt.Println("[System] 🎳 Lobby data received")
-		var lobbyData lobby_data.LobbyData
-		payloadBytes, err := json.Marshal(p.Payload)
-		if err != nil {
-			log.Printf("[System] 🚨 Error marshalling payload: %v", err)
-			return
-		}
-		err = json.Unmarshal(payloadBytes, &lobbyData)
-		if err != nil {
-			log.Printf("[System] 🚨 Error unmarshalling payload into LobbyData: %v", err)
-			return
-		}
+		lobbyData := p.Payload.(*lobby_data.LobbyData)
 
 		client.botMutex.Lock()
-		err = handlers.HandlePrepareToGame(client.tx, &client.botInstance, &lobbyData)
+		err := handlers.HandlePrepareToGame(client, &client.botInstance, lobbyData, client.options.Bot)
+		if client.botInstance != nil {
+			client.botReadyOnce.Do(func() { close(client.botReady) })
+		}
 		client.botMutex.Unlock()
 		if err != nil {
 			log.Printf("[System] 🚨 Error handling prepare to game: %v", err)
@@ -188,22 +554,15 @@ func (client *WebSocketClient) processTextMessage(p packet.Packet) {
 
 	case packet.GameStarting:
 		fmt.Println("[System] 🎲 Game starting")
+		client.forgetGameState()
 
-		// Wait until bot is not None
-		for client.botInstance == nil {
-			time.Sleep(100 * time.Millisecond)
+		if !client.waitForBot() {
+			return
 		}
 
-		readyToReceiveGameState := packet.Packet{
-			Type:    packet.ReadyToReceiveGameState,
-			Payload: nil,
+		if err := client.Send(packet.Packet{Type: packet.ReadyToReceiveGameState}); err != nil {
+			log.Printf("[System] 🚨 Error sending ReadyToReceiveGameState: %v", err)
 		}
-		readyToReceiveGameStateJson, err := json.Marshal(readyToReceiveGameState)
-		if err != nil {
-			log.Printf("[System] 🚨 Error marshalling ReadyToReceiveGameState: %v", err)
-			return
-		}
-		client.tx <- readyToReceiveGameStateJson
 
 	case packet.GameStarted:
 		fmt.Println("[System] 🎲 Game started")
@@ -212,40 +571,53 @@ func (client *WebSocketClient) processTextMessage(p packet.Packet) {
 		fmt.Println("[System] 🎲 Game in progress")
 
 	case packet.GameStatePacket:
+		gameState := p.Payload.(*game_state.GameState)
 
-		var gameState game_state.GameState
-		payloadBytes, err := json.Marshal(p.Payload)
-		if err != nil {
-			log.Printf("[System] 🚨 Error marshalling payload: %v", err)
-			return
-		}
-		err = json.Unmarshal(payloadBytes, &gameState)
-		if err != nil {
-			log.Printf("[System] 🚨 Error unmarshalling payload into GameState: %v", err)
-			log.Printf("[System] 🚨 Text Message -> %s", p.Payload)
+		if !client.waitForBot() {
 			return
 		}
 
 		client.botMutex.Lock()
-		if client.botInstance != nil {
-			handlers.HandleNextMove(client.tx, client.botInstance, gameState)
-		} else {
-			log.Println("[System] 🚨 Received GameStatePacket, but bot is not initialized")
+		// Game states are handled concurrently, so a newer one may have been handled first
+		if client.lastGameState != nil && gameState.Tick <= client.lastGameState.Tick {
+			client.botMutex.Unlock()
+			log.Printf("[System] 🚨 Game state %s of tick %d arrived after tick %d, ignoring", gameState.ID, gameState.Tick, client.lastGameState.Tick)
+			return
+		}
+		if failures := client.takeActionFailures(); len(failures) > 0 {
+			if err := handlers.HandleActionFailures(client.botInstance, failures); err != nil {
+				log.Printf("[System] 🚨 Error handling action failures: %v", err)
+			}
+		}
+		if tickTiming, ok := client.tracker.Timing(gameState.ID); ok {
+			if err := handlers.HandleTiming(client.botInstance, tickTiming); err != nil {
+				log.Printf("[System] 🚨 Error handling timing: %v", err)
+			}
 		}
+		if client.options.GameEvents {
+			if err := handlers.HandleGameEvents(client.botInstance, client.lastGameState, gameState); err != nil {
+				log.Printf("[System] 🚨 Error handling game events: %v", err)
+			}
+		}
+		// The bot gets its own copy, so it can't change the state the next events are diffed against
+		client.lastGameState = gameState.Clone()
+		err := handlers.HandleNextMove(client, client.botInstance, *gameState, client.options.ActionValidation)
 		client.botMutex.Unlock()
+		if err != nil {
+			log.Printf("[System] 🚨 Error handling next move: %v", err)
+		}
 
 	case packet.GameEndedPacket:
 		fmt.Println("[System] 🏁 Game ended")
+		gameEnd := p.Payload.(*game_end.GameEnd)
+		client.forgetGameState()
 
-		var gameEnd game_end.GameEnd
-		payloadBytes, _ := json.Marshal(p.Payload)
-		if err := json.Unmarshal(payloadBytes, &gameEnd); err != nil {
-			log.Printf("[System] 🚨 Error unmarshalling GameEnd payload: %v", err)
+		if !client.waitForBot() {
 			return
 		}
 
 		client.botMutex.Lock()
-		err := handlers.HandleGameEnded(client.botInstance, gameEnd)
+		err := handlers.HandleGameEnded(client.botInstance, *gameEnd)
 		client.botMutex.Unlock()
 		if err != nil {
 			log.Printf("[System] 🚨 Error handling game ended: %v", err)
@@ -253,9 +625,9 @@ func (client *WebSocketClient) processTextMessage(p packet.Packet) {
 
 	// Warnings
 	case packet.CustomWarning:
-		message := p.Payload.(map[string]interface{})["message"].(string)
+		customWarning := p.Payload.(*custom_warning.CustomWarning)
 		client.botMutex.Lock()
-		handlers.HandleWarning(client.botInstance, warning.CustomWarning, &message)
+		handlers.HandleWarning(client.botInstance, warning.CustomWarning, &customWarning.Message)
 		client.botMutex.Unlock()
 	case packet.PlayerAlreadyMadeActionWarning:
 		client.botMutex.Lock()
@@ -273,8 +645,10 @@ func (client *WebSocketClient) processTextMessage(p packet.Packet) {
 	// Errors
 	case packet.InvalidPacketTypeError:
 		fmt.Println("[System] 🚨 Websocket client sent an invalid packet type error")
+		fmt.Printf("[System] 🚨 Last packet sent -> %s\n", client.describeLastSent())
 	case packet.InvalidPacketUsageError:
 		fmt.Println("[System] 🚨 Websocket client used packet in invalid way")
+		fmt.Printf("[System] 🚨 Last packet sent -> %s\n", client.describeLastSent())
 
 	default:
 		log.Printf("[System] 🚨 Unknown packet type -> %s", p.Type)
@@ -0,0 +1,607 @@
+package ws_client
+
+import (
+	"errors"
+	"fmt"
+	"hackarena2-0-mono-tanks-go/packet"
+	"hackarena2-0-mono-tanks-go/packet/packets/bot_response"
+	"hackarena2-0-mono-tanks-go/packet/packets/connection_rejected"
+	"hackarena2-0-mono-tanks-go/packet/packets/custom_warning"
+	"hackarena2-0-mono-tanks-go/packet/packets/game_end"
+	"hackarena2-0-mono-tanks-go/scenario"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestConnectQueryParameters(t *testing.T) {
+	server := newFakeServer(t)
+	result := server.start(NewWebSocketClient(Options{}), "GO1", "secret")
+	session := server.accept()
+
+	expected := map[string]string{
+		"nickname":                "GO1",
+		"playerType":              "hackathonBot",
+		"enumSerializationFormat": "string",
+		"joinCode":                "secret",
+	}
+	for key, value := range expected {
+		if got := session.query.Get(key); got != value {
+			t.Errorf("Expected query parameter %s to be %q, got %q", key, value, got)
+		}
+	}
+
+	session.closeNormally()
+	if err := waitForRun(t, result); err != nil {
+		t.Fatalf("Expected Run to return nil, got %v", err)
+	}
+}
+
+func TestConnectWithoutJoinCode(t *testing.T) {
+	server := newFakeServer(t)
+	result := server.start(NewWebSocketClient(Options{}), "GO1", "")
+	session := server.accept()
+
+	if session.query.Has("joinCode") {
+		t.Errorf("Expected no joinCode query parameter, got %q", session.query.Get("joinCode"))
+	}
+
+	session.closeNormally()
+	waitForRun(t, result)
+}
+
+func TestHandshake(t *testing.T) {
+	server := newFakeServer(t)
+	result := server.start(NewWebSocketClient(Options{}), "GO1", "")
+	session := server.accept()
+
+	session.send(packet.ConnectionAccepted, nil)
+	session.expect(packet.LobbyDataRequest)
+
+	session.send(packet.Ping, nil)
+	session.expect(packet.Pong)
+
+	session.closeNormally()
+	waitForRun(t, result)
+}
+
+func TestLobbyDataWithoutSandboxMode(t *testing.T) {
+	server := newFakeServer(t)
+	result := server.start(NewWebSocketClient(Options{}), "GO1", "")
+	session := server.accept()
+
+	session.send(packet.ConnectionAccepted, nil)
+	session.expect(packet.LobbyDataRequest)
+	session.send(packet.LobbyDataPacket, lobbyDataPayload(false))
+	session.expectNothing(200 * time.Millisecond)
+
+	session.closeNormally()
+	waitForRun(t, result)
+}
+
+func TestLobbyDataWithSandboxMode(t *testing.T) {
+	server := newFakeServer(t)
+	result := server.start(NewWebSocketClient(Options{}), "GO1", "")
+	session := server.accept()
+
+	session.send(packet.ConnectionAccepted, nil)
+	session.expect(packet.LobbyDataRequest)
+	session.send(packet.LobbyDataPacket, lobbyDataPayload(true))
+	session.expect(packet.ReadyToReceiveGameState)
+	session.expect(packet.GameStatusRequest)
+
+	// Lobby data changes must not create the bot or send the requests again
+	session.send(packet.LobbyDataPacket, lobbyDataPayload(true))
+	session.expectNothing(200 * time.Millisecond)
+
+	session.closeNormally()
+	waitForRun(t, result)
+}
+
+func TestGameFlow(t *testing.T) {
+	testGameFlow(t, Options{})
+}
+
+func TestGameFlowWithGameEvents(t *testing.T) {
+	testGameFlow(t, Options{GameEvents: true})
+}
+
+func testGameFlow(t *testing.T, options Options) {
+	const ticks = 5
+
+	server := newFakeServer(t)
+	result := server.start(NewWebSocketClient(options), "GO1", "")
+	session := server.accept()
+
+	session.send(packet.ConnectionAccepted, nil)
+	session.expect(packet.LobbyDataRequest)
+	session.send(packet.LobbyDataPacket, lobbyDataPayload(false))
+	session.send(packet.GameStarting, nil)
+	session.expect(packet.ReadyToReceiveGameState)
+	session.send(packet.GameStarted, nil)
+
+	for tick := 0; tick < ticks; tick++ {
+		gameStateID := fmt.Sprintf("state-%d", tick)
+		session.send(packet.GameStatePacket, gameStatePayload(gameStateID, uint64(tick)))
+
+		response := session.expectAction()
+		action := response.Payload.(*bot_response.Action)
+		if action.GameStateID != gameStateID {
+			t.Errorf("Expected %s response for game state %s, got %v", response.Type, gameStateID, action.GameStateID)
+		}
+	}
+
+	session.send(packet.CustomWarning, &custom_warning.CustomWarning{Message: "custom"})
+	session.send(packet.PlayerAlreadyMadeActionWarning, nil)
+	session.send(packet.SlowResponseWarning, nil)
+	session.send(packet.ActionIgnoredDueToDeadWarning, nil)
+
+	session.send(packet.GameEndedPacket, &game_end.GameEnd{
+		Players: []game_end.GameEndPlayer{
+			{ID: scenario.MyID, Nickname: "GO1", Color: 16711680, Score: 10, Kills: 1},
+		},
+	})
+	session.expectNothing(200 * time.Millisecond)
+
+	session.closeNormally()
+	if err := waitForRun(t, result); err != nil {
+		t.Fatalf("Expected Run to return nil, got %v", err)
+	}
+}
+
+func TestGameStateBeforeLobbyData(t *testing.T) {
+	server := newFakeServer(t)
+	result := server.start(NewWebSocketClient(Options{}), "GO1", "")
+	session := server.accept()
+
+	session.send(packet.GameStatePacket, gameStatePayload("state-0", 0))
+	session.expectNothing(200 * time.Millisecond)
+
+	session.closeNormally()
+	waitForRun(t, result)
+}
+
+func TestGameStartingBeforeLobbyData(t *testing.T) {
+	server := newFakeServer(t)
+	result := server.start(NewWebSocketClient(Options{}), "GO1", "")
+	session := server.accept()
+
+	session.send(packet.ConnectionAccepted, nil)
+	session.expect(packet.LobbyDataRequest)
+	session.send(packet.GameStarting, nil)
+	session.expectNothing(200 * time.Millisecond)
+
+	session.closeNormally()
+	waitForRun(t, result)
+}
+
+func TestStateTransitions(t *testing.T) {
+	transitions := make(chan [2]State, 10)
+	client := NewWebSocketClient(Options{
+		OnStateChange: func(from State, to State) {
+			transitions <- [2]State{from, to}
+		},
+	})
+
+	server := newFakeServer(t)
+	result := server.start(client, "GO1", "")
+	session := server.accept()
+
+	expectTransition := func(from State, to State) {
+		t.Helper()
+		select {
+		case transition := <-transitions:
+			if transition != [2]State{from, to} {
+				t.Fatalf("Expected transition %s -> %s, got %s -> %s", from, to, transition[0], transition[1])
+			}
+		case <-time.After(fakeServerTimeout):
+			t.Fatalf("Timed out waiting for transition %s -> %s", from, to)
+		}
+	}
+
+	if state := client.State(); state != Connecting {
+		t.Fatalf("Expected initial state Connecting, got %s", state)
+	}
+
+	session.send(packet.ConnectionAccepted, nil)
+	expectTransition(Connecting, Accepted)
+	session.expect(packet.LobbyDataRequest)
+
+	session.send(packet.LobbyDataPacket, lobbyDataPayload(false))
+	expectTransition(Accepted, InLobby)
+
+	session.send(packet.GameStarting, nil)
+	expectTransition(InLobby, Starting)
+	session.expect(packet.ReadyToReceiveGameState)
+
+	session.send(packet.GameStarted, nil)
+	expectTransition(Starting, InGame)
+
+	session.send(packet.GameStatePacket, gameStatePayload("state-0", 0))
+	session.expectAction()
+
+	session.send(packet.GameEndedPacket, &game_end.GameEnd{})
+	expectTransition(InGame, Ended)
+
+	// Game states after the game has ended are ignored
+	session.send(packet.GameStatePacket, gameStatePayload("state-1", 1))
+	session.expectNothing(200 * time.Millisecond)
+
+	session.closeNormally()
+	waitForRun(t, result)
+	expectTransition(Ended, Closed)
+
+	if state := client.State(); state != Closed {
+		t.Fatalf("Expected final state Closed, got %s", state)
+	}
+}
+
+func TestGameStateBeforeGameStarted(t *testing.T) {
+	client := NewWebSocketClient(Options{})
+	server := newFakeServer(t)
+	result := server.start(client, "GO1", "")
+	session := server.accept()
+
+	session.send(packet.ConnectionAccepted, nil)
+	session.expect(packet.LobbyDataRequest)
+	session.send(packet.LobbyDataPacket, lobbyDataPayload(false))
+	session.send(packet.GameStarting, nil)
+	session.expect(packet.ReadyToReceiveGameState)
+
+	session.send(packet.GameStatePacket, gameStatePayload("state-0", 0))
+	if action := session.expectAction().Payload.(*bot_response.Action); action.GameStateID != "state-0" {
+		t.Errorf("Expected a response to state-0, got %v", action.GameStateID)
+	}
+
+	session.send(packet.GameStarted, nil)
+	session.send(packet.GameStatePacket, gameStatePayload("state-1", 1))
+	if action := session.expectAction().Payload.(*bot_response.Action); action.GameStateID != "state-1" {
+		t.Errorf("Expected a response to state-1, got %v", action.GameStateID)
+	}
+	if state := client.State(); state != InGame {
+		t.Errorf("Expected state InGame, got %s", state)
+	}
+
+	session.closeNormally()
+	waitForRun(t, result)
+}
+
+func TestGameStateInSandboxModeBeforeGameStarted(t *testing.T) {
+	client := NewWebSocketClient(Options{})
+	server := newFakeServer(t)
+	result := server.start(client, "GO1", "")
+	session := server.accept()
+
+	session.send(packet.ConnectionAccepted, nil)
+	session.expect(packet.LobbyDataRequest)
+	session.send(packet.LobbyDataPacket, lobbyDataPayload(true))
+	session.expect(packet.ReadyToReceiveGameState)
+	session.expect(packet.GameStatusRequest)
+
+	// The sandbox game is already running, so its game states follow the lobby data
+	session.send(packet.GameStatePacket, gameStatePayload("state-0", 0))
+	if action := session.expectAction().Payload.(*bot_response.Action); action.GameStateID != "state-0" {
+		t.Errorf("Expected a response to state-0, got %v", action.GameStateID)
+	}
+
+	session.send(packet.GameStarted, nil)
+	session.send(packet.GameStatePacket, gameStatePayload("state-1", 1))
+	if action := session.expectAction().Payload.(*bot_response.Action); action.GameStateID != "state-1" {
+		t.Errorf("Expected a response to state-1, got %v", action.GameStateID)
+	}
+	if state := client.State(); state != InGame {
+		t.Errorf("Expected state InGame, got %s", state)
+	}
+
+	session.closeNormally()
+	waitForRun(t, result)
+}
+
+func TestNewGameAfterGameEnded(t *testing.T) {
+	client := NewWebSocketClient(Options{})
+	server := newFakeServer(t)
+	result := server.start(client, "GO1", "")
+	session := server.accept()
+
+	session.send(packet.ConnectionAccepted, nil)
+	session.expect(packet.LobbyDataRequest)
+	session.send(packet.LobbyDataPacket, lobbyDataPayload(true))
+	session.send(packet.GameStarted, nil)
+	session.send(packet.GameStatePacket, gameStatePayload("first-5", 5))
+	session.expectAction()
+	session.send(packet.GameEndedPacket, &game_end.GameEnd{})
+	session.expectNothing(100 * time.Millisecond)
+
+	// The sandbox game is restarted
+	session.send(packet.GameStarting, nil)
+	session.expect(packet.ReadyToReceiveGameState)
+	session.send(packet.GameStarted, nil)
+	session.send(packet.GameStatePacket, gameStatePayload("second-0", 0))
+	if action := session.expectAction().Payload.(*bot_response.Action); action.GameStateID != "second-0" {
+		t.Errorf("Expected a response to the new game's state, got %v", action.GameStateID)
+	}
+	if state := client.State(); state != InGame {
+		t.Errorf("Expected state InGame, got %s", state)
+	}
+
+	session.closeNormally()
+	waitForRun(t, result)
+}
+
+func TestLateGameStateIgnored(t *testing.T) {
+	client := NewWebSocketClient(Options{GameEvents: true})
+	server := newFakeServer(t)
+	result := server.start(client, "GO1", "")
+	session := server.accept()
+
+	session.send(packet.ConnectionAccepted, nil)
+	session.expect(packet.LobbyDataRequest)
+	session.send(packet.LobbyDataPacket, lobbyDataPayload(false))
+	session.send(packet.GameStarted, nil)
+	session.send(packet.GameStatePacket, gameStatePayload("state-5", 5))
+	session.expectAction()
+
+	// A game state older than the last one handled gets no response
+	session.send(packet.GameStatePacket, gameStatePayload("state-3", 3))
+	session.expectNothing(100 * time.Millisecond)
+
+	session.send(packet.GameStatePacket, gameStatePayload("state-6", 6))
+	if action := session.expectAction().Payload.(*bot_response.Action); action.GameStateID != "state-6" {
+		t.Errorf("Expected a response to state-6, got %v", action.GameStateID)
+	}
+
+	session.closeNormally()
+	waitForRun(t, result)
+}
+
+func TestConnectionRejected(t *testing.T) {
+	client := NewWebSocketClient(Options{})
+	server := newFakeServer(t)
+	result := server.start(client, "GO1", "")
+	session := server.accept()
+
+	session.send(packet.ConnectionRejected, &connection_rejected.ConnectionRejected{Reason: "Nickname already exists"})
+	session.send(packet.ConnectionAccepted, nil)
+
+	// The client closes a rejected connection without waiting for the server
+	err := waitForRun(t, result)
+	if !errors.Is(err, ErrNicknameTaken) || !errors.Is(err, ErrConnectionRejected) {
+		t.Fatalf("Expected Run to return a nickname rejection, got %v", err)
+	}
+	var rejection *RejectionError
+	if !errors.As(err, &rejection) || rejection.Reason != "Nickname already exists" {
+		t.Errorf("Expected the server's reason in the RejectionError, got %v", err)
+	}
+
+	if closeErr := session.expectClose(); !websocket.IsCloseError(closeErr, websocket.CloseNormalClosure) {
+		t.Fatalf("Expected a normal close frame from the client, got %v", closeErr)
+	}
+	if state := client.State(); state != Closed {
+		t.Fatalf("Expected state Closed after rejection, got %s", state)
+	}
+	for p := range session.received {
+		t.Errorf("Expected no packets after the rejection, got %s", p.Type)
+	}
+}
+
+func TestConnectionRejectedDuringHandshake(t *testing.T) {
+	server := newFakeServer(t)
+	server.rejectHandshake = "Invalid join code"
+
+	host, port := server.address()
+	err := NewWebSocketClient(Options{}).Connect(host, port, "wrong", "GO1")
+	if !errors.Is(err, ErrInvalidJoinCode) {
+		t.Fatalf("Expected Connect to return an invalid join code rejection, got %v", err)
+	}
+}
+
+func TestServerErrorDescribesLastSentPacket(t *testing.T) {
+	client := NewWebSocketClient(Options{})
+	server := newFakeServer(t)
+	result := server.start(client, "GO1", "")
+	session := server.accept()
+
+	if described := client.describeLastSent(); described != "none" {
+		t.Errorf("Expected no packet sent yet, got %s", described)
+	}
+
+	if err := client.Send(packet.Packet{Type: packet.GameStatusRequest}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	session.expect(packet.GameStatusRequest)
+	session.send(packet.InvalidPacketUsageError, nil)
+
+	expected := `{"type":"gameStatusRequest"}`
+	if described := client.describeLastSent(); described != expected {
+		t.Errorf("Expected last sent packet %s, got %s", expected, described)
+	}
+
+	session.closeNormally()
+	waitForRun(t, result)
+}
+
+func TestMalformedMessage(t *testing.T) {
+	server := newFakeServer(t)
+	result := server.start(NewWebSocketClient(Options{}), "GO1", "")
+	session := server.accept()
+
+	session.sendRaw(`{"type": "gameState", "payload": `)
+	session.sendRaw(`{"type": "someFuturePacket"}`)
+	session.sendRaw(`{"type": "customWarning", "payload": {"msg": 1}}`)
+	session.sendRaw(`{"type": "customWarning"}`)
+	session.send(packet.Ping, nil)
+	session.expect(packet.Pong)
+
+	session.closeNormally()
+	waitForRun(t, result)
+}
+
+func TestAbruptClose(t *testing.T) {
+	server := newFakeServer(t)
+	result := server.start(NewWebSocketClient(Options{}), "GO1", "")
+	session := server.accept()
+
+	session.send(packet.ConnectionAccepted, nil)
+	session.expect(packet.LobbyDataRequest)
+	session.drop()
+
+	if err := waitForRun(t, result); err != nil {
+		t.Fatalf("Expected Run to return nil, got %v", err)
+	}
+}
+
+func TestContextCancellationSendsCloseFrame(t *testing.T) {
+	server := newFakeServer(t)
+	result := server.start(NewWebSocketClient(Options{}), "GO1", "")
+	session := server.accept()
+
+	session.send(packet.ConnectionAccepted, nil)
+	session.expect(packet.LobbyDataRequest)
+
+	server.cancelClient()
+	waitForRun(t, result)
+
+	err := session.expectClose()
+	if !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Fatalf("Expected a normal close frame from the client, got %v", err)
+	}
+}
+
+func TestShutdownOnContextCancellation(t *testing.T) {
+	client := NewWebSocketClient(Options{})
+	server := newFakeServer(t)
+	result := server.start(client, "GO1", "")
+	session := server.accept()
+
+	session.send(packet.ConnectionAccepted, nil)
+	session.expect(packet.LobbyDataRequest)
+	session.send(packet.LobbyDataPacket, lobbyDataPayload(false))
+	session.send(packet.GameStarting, nil)
+	session.expect(packet.ReadyToReceiveGameState)
+	session.send(packet.GameStarted, nil)
+	session.send(packet.GameStatePacket, gameStatePayload("state-0", 0))
+	session.expectAction()
+
+	server.cancelClient()
+	if err := waitForRun(t, result); err != nil {
+		t.Fatalf("Expected Run to return nil, got %v", err)
+	}
+
+	err := session.expectClose()
+	if !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Fatalf("Expected a normal close frame from the client, got %v", err)
+	}
+
+	expectNoClientGoroutines(t)
+	if err := client.Send(packet.Packet{Type: packet.Pong}); err != ErrClientClosed {
+		t.Errorf("Expected Send after shutdown to return ErrClientClosed, got %v", err)
+	}
+
+	// Lobby data request, readiness and the action
+	if metrics := client.SendMetrics(); metrics.Sent != 3 || metrics.Rejected != 1 {
+		t.Errorf("Expected 3 sent and 1 rejected packets, got %+v", metrics)
+	}
+	if stats := client.ResponseStats(); stats.Count != 1 {
+		t.Errorf("Expected the response time of 1 action, got %+v", stats)
+	}
+}
+
+func TestShutdownOnServerClose(t *testing.T) {
+	client := NewWebSocketClient(Options{})
+	server := newFakeServer(t)
+	result := server.start(client, "GO1", "")
+	session := server.accept()
+
+	session.send(packet.ConnectionAccepted, nil)
+	session.expect(packet.LobbyDataRequest)
+
+	session.closeNormally()
+	if err := waitForRun(t, result); err != nil {
+		t.Fatalf("Expected Run to return nil, got %v", err)
+	}
+
+	expectNoClientGoroutines(t)
+	if err := client.Send(packet.Packet{Type: packet.Pong}); err != ErrClientClosed {
+		t.Errorf("Expected Send after shutdown to return ErrClientClosed, got %v", err)
+	}
+}
+
+func TestShutdownFlushesQueuedPackets(t *testing.T) {
+	const queued = 10
+
+	client := NewWebSocketClient(Options{})
+	server := newFakeServer(t)
+	result := server.start(client, "GO1", "")
+	session := server.accept()
+
+	for i := 0; i < queued; i++ {
+		if err := client.Send(packet.Packet{Type: packet.GameStatusRequest}); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+	server.cancelClient()
+
+	for i := 0; i < queued; i++ {
+		session.expect(packet.GameStatusRequest)
+	}
+	if err := waitForRun(t, result); err != nil {
+		t.Fatalf("Expected Run to return nil, got %v", err)
+	}
+
+	err := session.expectClose()
+	if !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Fatalf("Expected the close frame after the queued packets, got %v", err)
+	}
+	expectNoClientGoroutines(t)
+}
+
+func TestShutdownWithoutCloseAnswer(t *testing.T) {
+	client := NewWebSocketClient(Options{})
+	client.closeTimeout = 100 * time.Millisecond
+
+	server := newFakeServer(t)
+	server.ignoreClose = true
+	result := server.start(client, "GO1", "")
+	session := server.accept()
+
+	session.send(packet.ConnectionAccepted, nil)
+	session.expect(packet.LobbyDataRequest)
+
+	server.cancelClient()
+	if err := waitForRun(t, result); err != nil {
+		t.Fatalf("Expected Run to return nil, got %v", err)
+	}
+	expectNoClientGoroutines(t)
+}
+
+func TestSpectator(t *testing.T) {
+	server := newFakeServer(t)
+	result := server.start(NewWebSocketClient(Options{Spectate: true}), "", "")
+	session := server.accept()
+
+	if playerType := session.query.Get("playerType"); playerType != "spectator" {
+		t.Errorf("Expected playerType spectator, got %q", playerType)
+	}
+	if session.query.Has("nickname") {
+		t.Errorf("Expected no nickname query parameter, got %q", session.query.Get("nickname"))
+	}
+
+	session.send(packet.ConnectionAccepted, nil)
+	session.expect(packet.LobbyDataRequest)
+	session.send(packet.LobbyDataPacket, lobbyDataPayload(false))
+	session.send(packet.GameStarting, nil)
+	session.send(packet.GameStarted, nil)
+	for tick := 0; tick < 3; tick++ {
+		session.send(packet.GameStatePacket, gameStatePayload(fmt.Sprintf("state-%d", tick), uint64(tick)))
+	}
+	session.send(packet.GameEndedPacket, &game_end.GameEnd{})
+
+	// Spectators neither announce they are ready nor respond to game states
+	session.expectNothing(200 * time.Millisecond)
+
+	session.closeNormally()
+	if err := waitForRun(t, result); err != nil {
+		t.Fatalf("Expected Run to return nil, got %v", err)
+	}
+}
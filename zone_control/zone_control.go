@@ -0,0 +1,157 @@
+// Package zone_control summarizes the zones of a game state, so bots can
+// reason about who holds them instead of switching over the ZoneStatus
+// variants.
+//
+// The statuses of a zone follow each other like this:
+//
+//	neutral         nobody holds the zone
+//	beingCaptured   a player is alone in a neutral zone and takes it over
+//	captured        the zone is held by a player
+//	beingContested  several players are inside, nothing changes hands
+//	beingRetaken    a player is alone in a zone held by someone else
+package zone_control
+
+import (
+	"hackarena2-0-mono-tanks-go/packet/packets/game_state"
+	"hackarena2-0-mono-tanks-go/pathing"
+)
+
+// Zone status types sent by the server.
+const (
+	Neutral        = "neutral"
+	BeingCaptured  = "beingCaptured"
+	Captured       = "captured"
+	BeingContested = "beingContested"
+	BeingRetaken   = "beingRetaken"
+)
+
+// Options describe the rules of the server, which are not part of the lobby data.
+type Options struct {
+	// CaptureTicks is how long capturing or retaking a zone takes, used for
+	// the capture progress. The progress is left at zero when it is unknown.
+	CaptureTicks uint64
+
+	// PointsPerTick is the score a held zone is assumed to give its owner
+	// every tick.
+	PointsPerTick float64
+}
+
+// DefaultOptions leave the capture time unknown, and count one point per
+// held zone, which still ranks the players by their income from zones.
+var DefaultOptions = Options{PointsPerTick: 1}
+
+// Report describes a zone from the point of view of a player.
+type Report struct {
+	// Zone is the zone from the game state.
+	Zone game_state.Zone
+
+	// Status is the type of the zone status.
+	Status string
+
+	// OwnerID is the ID of the player holding the zone, empty if nobody does.
+	OwnerID string
+
+	// CapturerID is the ID of the player capturing or retaking the zone, empty otherwise.
+	CapturerID string
+
+	// RemainingTicks is the number of ticks until CapturerID takes the zone over.
+	RemainingTicks uint64
+
+	// Progress is how far the capture or retake is, from 0 to 1. It needs
+	// Options.CaptureTicks and is zero without it.
+	Progress float64
+
+	// Inside lists the IDs of the owners of the visible tanks inside the zone.
+	Inside []string
+
+	// VisibleTiles lists the tiles of the zone we can see.
+	VisibleTiles []pathing.Point
+
+	// ReachTicks is the number of ticks our tank needs to enter the zone, or
+	// -1 if it is destroyed or can't get there.
+	ReachTicks int
+}
+
+// Tiles returns the number of tiles of the zone.
+func (r *Report) Tiles() int {
+	return int(r.Zone.Width * r.Zone.Height)
+}
+
+// Contains reports whether the tile is inside of the zone.
+func Contains(zone game_state.Zone, x, y int) bool {
+	return x >= int(zone.X) && x < int(zone.X+zone.Width) && y >= int(zone.Y) && y < int(zone.Y+zone.Height)
+}
+
+// Analyze reports on every zone of the game state, with the reach times of
+// the tank of the player with playerID.
+func Analyze(gameState *game_state.GameState, playerID string, options Options) []Report {
+	var search *pathing.Search
+	if tank := gameState.Tank(playerID); tank != nil {
+		search = pathing.NewMap(gameState, playerID).Search(pathing.PoseOf(tank))
+	}
+
+	reports := make([]Report, 0, len(gameState.Zones))
+	for _, zone := range gameState.Zones {
+		report := Report{Zone: zone, Status: zone.Status.Type, ReachTicks: -1}
+		readStatus(&report, zone.Status, options)
+
+		for _, tank := range gameState.Tanks {
+			if Contains(zone, tank.X, tank.Y) {
+				report.Inside = append(report.Inside, tank.OwnerID)
+			}
+		}
+
+		var tiles []pathing.Point
+		for y := int(zone.Y); y < int(zone.Y+zone.Height); y++ {
+			for x := int(zone.X); x < int(zone.X+zone.Width); x++ {
+				tiles = append(tiles, pathing.Point{X: x, Y: y})
+				if y < len(gameState.Visibility) && x < len(gameState.Visibility[y]) && gameState.Visibility[y][x] {
+					report.VisibleTiles = append(report.VisibleTiles, pathing.Point{X: x, Y: y})
+				}
+			}
+		}
+
+		if search != nil {
+			if _, ticks, ok := search.Nearest(tiles); ok {
+				report.ReachTicks = ticks
+			}
+		}
+
+		reports = append(reports, report)
+	}
+	return reports
+}
+
+func readStatus(report *Report, status game_state.ZoneStatus, options Options) {
+	switch {
+	case status.BeingCaptured != nil:
+		report.CapturerID = status.BeingCaptured.PlayerID
+		report.RemainingTicks = status.BeingCaptured.RemainingTicks
+	case status.Captured != nil:
+		report.OwnerID = status.Captured.PlayerID
+	case status.BeingContested != nil:
+		if status.BeingContested.CapturedByID != nil {
+			report.OwnerID = *status.BeingContested.CapturedByID
+		}
+	case status.BeingRetaken != nil:
+		report.OwnerID = status.BeingRetaken.CapturedByID
+		report.CapturerID = status.BeingRetaken.RetakenByID
+		report.RemainingTicks = status.BeingRetaken.RemainingTicks
+	}
+
+	if report.CapturerID != "" && options.CaptureTicks > 0 && report.RemainingTicks <= options.CaptureTicks {
+		report.Progress = 1 - float64(report.RemainingTicks)/float64(options.CaptureTicks)
+	}
+}
+
+// PointsPerTick estimates the score each player earns from the zones every
+// tick. A zone earns for its owner until it is retaken, contested or not.
+func PointsPerTick(reports []Report, options Options) map[string]float64 {
+	points := make(map[string]float64)
+	for _, report := range reports {
+		if report.OwnerID != "" {
+			points[report.OwnerID] += options.PointsPerTick
+		}
+	}
+	return points
+}
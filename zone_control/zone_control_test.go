@@ -0,0 +1,91 @@
+package zone_control
+
+import (
+	"hackarena2-0-mono-tanks-go/packet/packets/game_state"
+	"hackarena2-0-mono-tanks-go/scenario"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	s := scenario.MustParse(`
+		# # # # # #
+		# > . a a #
+		# . . a T #
+		# b b ~ ~ #
+		# b b ~ ~ #
+		# # # # # #
+	`)
+	gameState := s.GameState
+	enemyID := s.Enemies()[0].OwnerID
+
+	// Zone A is drawn around the enemy tank, so stretch it over its tile
+	gameState.Zones[0].Status = game_state.ZoneStatus{
+		Type:          BeingCaptured,
+		BeingCaptured: &game_state.BeingCapturedStatus{PlayerID: enemyID, RemainingTicks: 30},
+	}
+	gameState.Zones[1].Status = game_state.ZoneStatus{
+		Type:     Captured,
+		Captured: &game_state.CapturedStatus{PlayerID: scenario.MyID},
+	}
+
+	reports := Analyze(gameState, scenario.MyID, Options{CaptureTicks: 40, PointsPerTick: 2})
+	if len(reports) != 2 {
+		t.Fatalf("Expected 2 reports, got %d", len(reports))
+	}
+
+	a := reports[0]
+	if a.CapturerID != enemyID || a.OwnerID != "" || a.RemainingTicks != 30 || a.Progress != 0.25 {
+		t.Errorf("Expected zone A a quarter captured by the enemy, got %+v", a)
+	}
+	if len(a.Inside) != 1 || a.Inside[0] != enemyID {
+		t.Errorf("Expected the enemy inside zone A, got %v", a.Inside)
+	}
+	if len(a.VisibleTiles) != 4 || a.Tiles() != 4 {
+		t.Errorf("Expected all 4 tiles of zone A to be visible, got %v", a.VisibleTiles)
+	}
+	if a.ReachTicks != 2 {
+		t.Errorf("Expected zone A in 2 ticks, got %d", a.ReachTicks)
+	}
+
+	b := reports[1]
+	if b.OwnerID != scenario.MyID || b.CapturerID != "" || b.Progress != 0 {
+		t.Errorf("Expected zone B held by us, got %+v", b)
+	}
+	// Rotate down, then drive down twice
+	if b.ReachTicks != 3 {
+		t.Errorf("Expected zone B in 3 ticks, got %d", b.ReachTicks)
+	}
+
+	points := PointsPerTick(reports, Options{PointsPerTick: 2})
+	if len(points) != 1 || points[scenario.MyID] != 2 {
+		t.Errorf("Expected only us to earn 2 points per tick, got %v", points)
+	}
+}
+
+func TestAnalyzeStatuses(t *testing.T) {
+	owner := "owner"
+	tests := []struct {
+		status     game_state.ZoneStatus
+		ownerID    string
+		capturerID string
+	}{
+		{game_state.ZoneStatus{Type: Neutral}, "", ""},
+		{game_state.ZoneStatus{Type: BeingContested, BeingContested: &game_state.BeingContestedStatus{}}, "", ""},
+		{game_state.ZoneStatus{Type: BeingContested, BeingContested: &game_state.BeingContestedStatus{CapturedByID: &owner}}, owner, ""},
+		{game_state.ZoneStatus{Type: BeingRetaken, BeingRetaken: &game_state.BeingRetakenStatus{CapturedByID: owner, RetakenByID: "thief", RemainingTicks: 5}}, owner, "thief"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.status.Type, func(t *testing.T) {
+			gameState := &game_state.GameState{Zones: []game_state.Zone{{Index: 'A', Width: 1, Height: 1, Status: test.status}}}
+
+			report := Analyze(gameState, scenario.MyID, DefaultOptions)[0]
+			if report.OwnerID != test.ownerID || report.CapturerID != test.capturerID {
+				t.Errorf("Expected owner %q and capturer %q, got %+v", test.ownerID, test.capturerID, report)
+			}
+			if report.ReachTicks != -1 {
+				t.Errorf("Expected no reach time without our tank, got %d", report.ReachTicks)
+			}
+		})
+	}
+}